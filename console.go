@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	netService "qBox/services/net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Время ожидания ответа прибора по умолчанию для команды console
+const consoleTimeout = 3 * time.Second
+
+const consoleHelp = `Кадр вводится в шестнадцатеричном виде без контрольной суммы, например: 55 01 FE 0F 01 03 00 00 07
+  =байты          отправить кадр как есть, без контрольной суммы
+  !!              повторить последний кадр
+  !N              повторить кадр N из истории
+  history         история отправленных кадров
+  protocol КОД    сменить протокол: tem, mbus, modbus, sku02, none
+  timeout СЕКУНДЫ время ожидания ответа
+  help            эта справка
+  exit            выход`
+
+/**
+Ручной обмен кадрами с прибором (команда console) для диагностики на объекте.
+Кадр вводится в шестнадцатеричном виде, контрольная сумма добавляется по протоколу обмена. Части ответа выводятся со
+временем получения от момента отправки, эхо запроса определяется и выводится отдельно. Сеанс дописывается в файл
+флага consoleLog в формате, который читает команда decode.
+*/
+func runConsole(configService configPackage.Config, reader io.Reader, writer io.Writer, logger *logPackage.LoggerService) (models.StageEnum, error) {
+	protocol, err := configService.GetConsoleProtocol()
+	if err != nil {
+		return models.StageConfig, err
+	}
+	host, port, err := netService.SplitHostPort(configService.GetHostPort())
+	if err != nil {
+		return models.StageConfig, err
+	}
+
+	network := netService.NewNetwork(host, port, *logger)
+	err = network.Connect()
+	if err != nil {
+		return models.StageConnect, err
+	}
+	defer func() {
+		_ = network.Close()
+	}()
+
+	var session io.Writer = io.Discard
+	if configService.GetConsoleLog() != "" {
+		file, err := os.OpenFile(configService.GetConsoleLog(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return models.StageConfig, err
+		}
+		defer file.Close()
+		session = file
+		fmt.Fprintf(session, "# qBox console: %s, протокол %s, %s\n",
+			configService.GetHostPort(), protocol.Code(), time.Now().Format(time.RFC3339))
+	}
+
+	console := frameConsole{network: network, protocol: protocol, timeout: consoleTimeout, writer: writer, session: session}
+	fmt.Fprintf(writer, "Соединение с %s установлено, протокол: %s. Список команд - help\n",
+		configService.GetHostPort(), protocol)
+
+	scanner := bufio.NewScanner(reader)
+	for {
+		fmt.Fprintf(writer, "%s> ", console.protocol.Code())
+		if !scanner.Scan() {
+			fmt.Fprintln(writer)
+			return models.StageNone, scanner.Err()
+		}
+		if !console.execute(strings.TrimSpace(scanner.Text())) {
+			return models.StageNone, nil
+		}
+	}
+}
+
+type frameConsole struct {
+	network  *netService.Network
+	protocol models.ProtocolEnum
+	timeout  time.Duration
+	history  []string
+	writer   io.Writer
+	session  io.Writer
+}
+
+// Выполнение введённой строки. Возвращает false, если работа с консолью завершена
+func (console *frameConsole) execute(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "exit", "quit":
+		return false
+	case "help", "?":
+		fmt.Fprintln(console.writer, consoleHelp)
+		return true
+	case "history":
+		for i, entry := range console.history {
+			fmt.Fprintf(console.writer, "%4d  %s\n", i+1, entry)
+		}
+		return true
+	case "protocol":
+		if len(fields) != 2 {
+			fmt.Fprintln(console.writer, "Укажите протокол: protocol tem")
+			return true
+		}
+		protocol, err := models.ProtocolByCode(strings.ToLower(fields[1]))
+		if err != nil {
+			fmt.Fprintln(console.writer, err.Error())
+			return true
+		}
+		console.protocol = protocol
+		fmt.Fprintf(console.writer, "Протокол: %s\n", protocol)
+		return true
+	case "timeout":
+		seconds, err := strconv.ParseFloat(strings.Join(fields[1:], ""), 64)
+		if err != nil || seconds <= 0 {
+			fmt.Fprintln(console.writer, "Укажите время ожидания в секундах: timeout 5")
+			return true
+		}
+		console.timeout = time.Duration(seconds * float64(time.Second))
+		fmt.Fprintf(console.writer, "Время ожидания ответа: %s\n", console.timeout)
+		return true
+	}
+
+	if strings.HasPrefix(line, "!") {
+		number := len(console.history)
+		if line != "!!" {
+			var err error
+			number, err = strconv.Atoi(line[1:])
+			if err != nil {
+				number = 0
+			}
+		}
+		if number < 1 || number > len(console.history) {
+			fmt.Fprintln(console.writer, "Кадра с таким номером нет в истории")
+			return true
+		}
+		line = console.history[number-1]
+		fmt.Fprintln(console.writer, line)
+	}
+
+	raw := strings.HasPrefix(line, "=")
+	frame, err := netService.ParseHex(strings.TrimPrefix(line, "="))
+	if err != nil || len(frame) == 0 {
+		fmt.Fprintln(console.writer, "Неизвестная команда или неверные байты кадра. Список команд - help")
+		return true
+	}
+	console.history = append(console.history, line)
+
+	request := frame
+	if !raw {
+		request = console.protocol.Seal(frame)
+	}
+	console.send(request, len(frame))
+	return true
+}
+
+/**
+Отправка кадра и вывод ответа. sealed - длина кадра до добавления контрольной суммы, добавленные байты выводятся отдельно.
+*/
+func (console *frameConsole) send(request []byte, sealed int) {
+	if sealed < len(request) {
+		fmt.Fprintf(console.writer, "> % X  [% X]\n", request[:sealed], request[sealed:])
+	} else {
+		fmt.Fprintf(console.writer, "> % X\n", request)
+	}
+
+	protocol := console.protocol
+	chunks, err := console.network.Transmit(request, console.timeout, protocol.Complete)
+
+	var received []byte
+	for _, chunk := range chunks {
+		fmt.Fprintf(console.writer, "< %7.3f с  % X\n", chunk.Elapsed.Seconds(), chunk.Bytes)
+		received = append(received, chunk.Bytes...)
+	}
+	response := received
+	if bytes.HasPrefix(received, request) {
+		response = received[len(request):]
+		fmt.Fprintf(console.writer, "Эхо запроса: %d байт\n", len(request))
+	}
+
+	fmt.Fprintf(console.session, "# %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(console.session, "> %X\n", request)
+	if len(response) > 0 {
+		fmt.Fprintf(console.session, "< %X\n", response)
+	}
+
+	if err != nil {
+		fmt.Fprintf(console.writer, "Ошибка обмена: %s\n", err.Error())
+		fmt.Fprintf(console.session, "# ошибка: %s\n", err.Error())
+		if err == io.EOF {
+			fmt.Fprintln(console.writer, "Соединение закрыто прибором, выполняется повторное подключение")
+			console.network.Reconnect()
+		}
+		return
+	}
+	if len(response) == 0 {
+		fmt.Fprintf(console.writer, "Нет ответа за %s\n", console.timeout)
+		return
+	}
+
+	last := chunks[len(chunks)-1].Elapsed.Seconds()
+	summary := fmt.Sprintf("Ответ %d байт за %.3f с", len(response), last)
+	if protocol != models.ProtocolNone {
+		if protocol.Complete(response) {
+			summary += ", кадр получен полностью, контрольная сумма верна"
+		} else {
+			summary += ", кадр неполный или контрольная сумма неверна (разбор кадра - команда decode)"
+		}
+	}
+	fmt.Fprintln(console.writer, summary)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	netService "qBox/services/net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/**
+Разбор кадров прибора для отладки протокола (команда decode).
+Ответы из сеанса передаются драйверу через воспроизведение обмена, поэтому значения полей получены кодом самого драйвера.
+Расположение полей в кадре определяется опробованием: каждый байт ответа по очереди изменяется, контрольная сумма
+пересчитывается по протоколу драйвера, драйвер запускается заново, и байт относится к тем полям данных, значения которых
+изменились. Если после изменения байта драйвер возвращает ошибку, байт проверяется драйвером (заголовок, адрес, команда).
+*/
+func runDecode(configService configPackage.Config, writer io.Writer, logger *logPackage.LoggerService) error {
+	if configService.IsAutoDetect() {
+		return errors.New("для команды decode тип теплосчётчика задаётся явно флагом \"-type\"")
+	}
+	driver, err := configService.GetDriver()
+	if err != nil {
+		return err
+	}
+	session, err := configService.GetDecodeSession()
+	if err != nil {
+		return err
+	}
+	protocol := models.ProtocolNone
+	if protocolDriver, ok := driver.(models.IProtocolDriver); ok {
+		protocol = protocolDriver.Protocol()
+	}
+
+	logger.Check("app")
+	logger.Info("Разбор сеанса из %d обменов драйвером %s", len(session), configPackage.DriverName(driver))
+	decoder := frameDecoder{config: configService, protocol: protocol}
+	base := decoder.run(session, logger)
+
+	// Значения, которые меняются от запуска к запуску без изменения кадров (например, зависят от текущего времени),
+	// при опробовании не учитываются
+	quiet := logPackage.LoggerService{}
+	quiet.OpenDiscard()
+	decoder.unstable = base.changed(decoder.run(session, &quiet))
+
+	fmt.Fprintf(writer, "Драйвер: %s (тип %s), протокол: %s\n",
+		configPackage.DriverName(driver), configPackage.DriverType(driver), protocol)
+	if base.err != nil {
+		fmt.Fprintf(writer, "Разбор прерван на этапе \"%s\": %s\n", base.stage, base.err.Error())
+	} else {
+		fmt.Fprintln(writer, "Разбор выполнен без ошибок")
+	}
+
+	used := make([]bool, len(session))
+	for n, call := range base.calls {
+		fmt.Fprintln(writer)
+		fmt.Fprintf(writer, "Запрос %d драйвера: % X\n", n+1, call.Request)
+		if call.Exchange < 0 {
+			fmt.Fprintln(writer, "  ответ в сеансе не найден")
+			continue
+		}
+		used[call.Exchange] = true
+		exchange := session[call.Exchange]
+		if exchange.Request != nil && string(exchange.Request) != string(call.Request) {
+			fmt.Fprintf(writer, "  в сеансе запрос отличается: % X\n", exchange.Request)
+		}
+		status := "пройдена"
+		if !call.Passed {
+			status = "не пройдена"
+		}
+		fmt.Fprintf(writer, "  ответ %d из сеанса, %d байт, проверка ответа драйвером: %s\n",
+			call.Exchange+1, len(exchange.Response), status)
+		decoder.print(writer, session, call.Exchange, base, &quiet)
+	}
+
+	for i, exchange := range session {
+		if !used[i] {
+			fmt.Fprintln(writer)
+			fmt.Fprintf(writer, "Ответ %d из сеанса драйвер не запросил: % X\n", i+1, exchange.Response)
+		}
+	}
+	return nil
+}
+
+type frameDecoder struct {
+	config   configPackage.Config
+	protocol models.ProtocolEnum
+	unstable map[string]bool
+}
+
+/**
+Результат запуска драйвера на сеансе: значения полей данных, запросы драйвера и первая ошибка с этапом, на котором она
+произошла. Паника драйвера, например при выходе за границы кадра, считается ошибкой.
+*/
+type decodeOutcome struct {
+	values map[string]string
+	calls  []netService.ReplayCall
+	stage  models.StageEnum
+	err    error
+}
+
+func (decoder frameDecoder) run(session []netService.Exchange, logger *logPackage.LoggerService) (outcome decodeOutcome) {
+	outcome.values = map[string]string{}
+	driver, err := decoder.config.GetDriver()
+	if err != nil {
+		outcome.stage, outcome.err = models.StageConfig, err
+		return
+	}
+	network := netService.NewReplayNetwork(session, *logger)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			outcome.err = fmt.Errorf("паника в драйвере: %v", recovered)
+		}
+		outcome.calls = network.ReplayCalls()
+	}()
+
+	logger.Check("driver")
+	outcome.stage = models.StageInit
+	outcome.err = driver.Init(decoder.config.GetCounterNumber(), network, logger)
+	if outcome.err != nil {
+		return
+	}
+
+	outcome.stage = models.StageRead
+	deviceData, err := driver.Read()
+	if deviceData != nil {
+		flattenValue(outcome.values, "", reflect.ValueOf(*deviceData))
+	}
+	if err != nil {
+		outcome.err = err
+		return
+	}
+
+	eventDriver, ok := driver.(models.IEventDriver)
+	if ok && decoder.config.IsReadEvents() {
+		outcome.stage = models.StageEvents
+		events, err := eventDriver.ReadEvents()
+		flattenValue(outcome.values, "Events", reflect.ValueOf(events))
+		if err != nil {
+			outcome.err = err
+			return
+		}
+	}
+	outcome.stage = models.StageNone
+	return
+}
+
+// Поля, значения которых отличаются в другом запуске драйвера. Появившиеся и пропавшие поля не учитываются:
+// изменение количества систем или событий видно по полю len(Systems) или len(Events)
+func (outcome decodeOutcome) changed(other decodeOutcome) map[string]bool {
+	changed := map[string]bool{}
+	for name, value := range outcome.values {
+		if otherValue, ok := other.values[name]; ok && otherValue != value {
+			changed[name] = true
+		}
+	}
+	return changed
+}
+
+/**
+Влияние байта кадра на разбор: поля данных, значения которых зависят от байта, и признак проверки байта драйвером.
+Байт изменяется несколькими масками, чтобы найти и поля, которые используют отдельные биты байта.
+*/
+type byteEffect struct {
+	fields  []string
+	checked bool
+}
+
+var probeMasks = []byte{0xFF, 0x01, 0x80}
+
+const decodeRowBytes = 16
+
+func (decoder frameDecoder) probe(session []netService.Exchange, exchange int, offset int, base decodeOutcome,
+	logger *logPackage.LoggerService) byteEffect {
+
+	original := session[exchange].Response
+	_, checksum := decoder.protocol.Checksum(original)
+	reseal := checksum != nil && decoder.protocol.Dissect(original, false).ChecksumOk
+
+	fields := map[string]bool{}
+	effect := byteEffect{}
+	for _, mask := range probeMasks {
+		frame := append([]byte{}, original...)
+		frame[offset] ^= mask
+		if reseal {
+			position, sum := decoder.protocol.Checksum(frame)
+			copy(frame[position:], sum)
+		}
+		mutated := append([]netService.Exchange{}, session...)
+		mutated[exchange].Response = frame
+
+		outcome := decoder.run(mutated, logger)
+		if (outcome.err == nil) != (base.err == nil) || outcome.err != nil && outcome.stage != base.stage {
+			effect.checked = true
+			continue
+		}
+		for name := range base.changed(outcome) {
+			if !decoder.unstable[name] {
+				fields[name] = true
+			}
+		}
+	}
+	for name := range fields {
+		effect.fields = append(effect.fields, name)
+	}
+	sort.Strings(effect.fields)
+	return effect
+}
+
+// Вывод разбора ответа: служебные поля кадра по протоколу и байты данных, сгруппированные по полям драйвера
+func (decoder frameDecoder) print(writer io.Writer, session []netService.Exchange, exchange int, base decodeOutcome,
+	logger *logPackage.LoggerService) {
+
+	response := session[exchange].Response
+	if len(response) == 0 {
+		return
+	}
+	frame := decoder.protocol.Dissect(response, false)
+	service := map[int]models.FrameField{}
+	for _, field := range frame.Fields {
+		for i := field.Offset; i < field.Offset+field.Size; i++ {
+			service[i] = field
+		}
+	}
+
+	effects := make([]byteEffect, len(response))
+	for offset := range response {
+		if _, ok := service[offset]; !ok {
+			effects[offset] = decoder.probe(session, exchange, offset, base, logger)
+		}
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "  Смещение\tБайты\tПоле\tЗначение")
+	for offset := 0; offset < len(response); {
+		if field, ok := service[offset]; ok {
+			fmt.Fprintf(table, "  %s\t% X\t%s\t%s\n", offsetRange(field.Offset, field.Size),
+				response[field.Offset:field.Offset+field.Size], field.Name, field.Value)
+			offset = field.Offset + field.Size
+			continue
+		}
+
+		effect := effects[offset]
+		size := 1
+		for offset+size < len(response) {
+			if _, ok := service[offset+size]; ok {
+				break
+			}
+			next := effects[offset+size]
+			if next.checked != effect.checked || strings.Join(next.fields, ",") != strings.Join(effect.fields, ",") {
+				break
+			}
+			size++
+		}
+		// Длинные группы байт выводятся строками по decodeRowBytes байт
+		name, value := describeEffect(effect, base)
+		for row := offset; row < offset+size; row += decodeRowBytes {
+			rowSize := offset + size - row
+			if rowSize > decodeRowBytes {
+				rowSize = decodeRowBytes
+			}
+			fmt.Fprintf(table, "  %s\t% X\t%s\t%s\n", offsetRange(row, rowSize), response[row:row+rowSize], name, value)
+			name, value = "", ""
+		}
+		offset += size
+	}
+	_ = table.Flush()
+}
+
+// Наименование и значение группы байт данных. Для нескольких полей выводятся первые три
+func describeEffect(effect byteEffect, base decodeOutcome) (string, string) {
+	if len(effect.fields) == 0 {
+		if effect.checked {
+			return "проверяется драйвером", ""
+		}
+		return "не используется драйвером", ""
+	}
+
+	name := effect.fields[0]
+	value := base.values[name]
+	if len(effect.fields) > 1 {
+		name = fmt.Sprintf("полей: %d", len(effect.fields))
+		var values []string
+		for i, field := range effect.fields {
+			if i == 3 {
+				values = append(values, fmt.Sprintf("и ещё %d", len(effect.fields)-i))
+				break
+			}
+			values = append(values, field+" = "+base.values[field])
+		}
+		value = strings.Join(values, "; ")
+	}
+	if effect.checked {
+		name += " (проверяется драйвером)"
+	}
+	return name, value
+}
+
+// Смещение в кадре в шестнадцатеричном виде, как в комментариях драйверов. Например: 0046 или 0046-0049
+func offsetRange(offset int, size int) string {
+	if size <= 1 {
+		return fmt.Sprintf("%04X", offset)
+	}
+	return fmt.Sprintf("%04X-%04X", offset, offset+size-1)
+}
+
+// Поля, которые заполняет ядро, а не драйвер
+var decodeSkipFields = map[string]bool{
+	"Driver":       true,
+	"DeviceType":   true,
+	"PollDuration": true,
+	"Errors":       true,
+	"Result":       true,
+	"TimeRequest":  true,
+}
+
+/**
+Значения полей данных в плоском виде: путь к полю и значение. Например: Systems[0].Q1 = 12.5, len(Systems) = 2
+Неэкспортируемые поля, пустые указатели и нулевое время пропускаются.
+*/
+func flattenValue(values map[string]string, path string, value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			flattenValue(values, path, value.Elem())
+		}
+	case reflect.Struct:
+		if t, ok := value.Interface().(time.Time); ok {
+			if !t.IsZero() {
+				values[path] = t.Format("02.01.2006 15:04:05")
+			}
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" || path == "" && decodeSkipFields[field.Name] {
+				continue
+			}
+			name := field.Name
+			if path != "" {
+				name = path + "." + name
+			}
+			flattenValue(values, name, value.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice {
+			values["len("+path+")"] = strconv.Itoa(value.Len())
+		}
+		for i := 0; i < value.Len(); i++ {
+			flattenValue(values, path+"["+strconv.Itoa(i)+"]", value.Index(i))
+		}
+	case reflect.Float32:
+		values[path] = strconv.FormatFloat(value.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		values[path] = strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	default:
+		values[path] = fmt.Sprint(value.Interface())
+	}
+}
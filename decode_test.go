@@ -0,0 +1,62 @@
+package main
+
+import (
+	"qBox/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOffsetRange(t *testing.T) {
+	tests := []struct {
+		offset int
+		size   int
+		text   string
+	}{
+		{0x46, 1, "0046"},
+		{0x46, 0, "0046"},
+		{0x46, 4, "0046-0049"},
+		{0x1FF, 2, "01FF-0200"},
+	}
+	for _, test := range tests {
+		if text := offsetRange(test.offset, test.size); text != test.text {
+			t.Errorf("offsetRange(%X, %d) = %s, ожидалось %s", test.offset, test.size, text, test.text)
+		}
+	}
+}
+
+func TestFlattenValue(t *testing.T) {
+	device := models.DataDevice{
+		Serial:       "58369",
+		Time:         time.Date(2024, 5, 17, 12, 30, 0, 0, time.Local),
+		Driver:       "drivers.TEM05OLD",
+		PollDuration: time.Second,
+	}
+	device.AddNewSystem(1)
+	device.Systems[0].Q1 = 12.5
+	device.Systems[1].T1 = 51.53
+
+	values := map[string]string{}
+	flattenValue(values, "", reflect.ValueOf(&device))
+
+	expected := map[string]string{
+		"Serial":            "58369",
+		"Time":              "17.05.2024 12:30:00",
+		"len(Systems)":      "2",
+		"Systems[0].Q1":     "12.5",
+		"Systems[1].T1":     "51.53",
+		"Systems[1].V1":     "0",
+		"Systems[0].Status": "false",
+	}
+	for path, value := range expected {
+		if values[path] != value {
+			t.Errorf("%s = %q, ожидалось %q", path, values[path], value)
+		}
+	}
+	// Поля ядра, нулевое время и пустые указатели не выводятся
+	for _, path := range []string{"Driver", "PollDuration", "TimeRequest", "MeterStatus", "Systems[0].MeterStatus"} {
+		if value, ok := values[path]; ok {
+			t.Errorf("%s = %q, поле не должно выводиться", path, value)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+)
+
+// Чтение конфигурации (настроек) теплосчётчика и вывод её в заданном формате
+func readConfig(driver models.IDeviceDriver, configService configPackage.Config, logger *logPackage.LoggerService) error {
+	configDriver, ok := driver.(models.IConfigDriver)
+	if !ok {
+		return errors.New("драйвер не поддерживает чтение конфигурации теплосчётчика")
+	}
+
+	logger.Check("driver")
+	logger.Info("Чтение конфигурации теплосчётчика")
+	deviceConfig, err := configDriver.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	logger.Check("app")
+	logger.Info("Получение формата результата")
+	deviceFormatter, err := configService.GetFormatter()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	formatter, ok := deviceFormatter.(models.ConfigFormatter)
+	if !ok {
+		return errors.New("формат вывода не поддерживает вывод конфигурации теплосчётчика")
+	}
+
+	logger.Info("Вывод конфигурации")
+	formatter.RenderConfig(os.Stdout, deviceConfig)
+	return nil
+}
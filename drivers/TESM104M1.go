@@ -1,7 +1,9 @@
 package drivers
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"qBox/models"
 	"qBox/services/log"
 	"qBox/services/net"
@@ -14,7 +16,8 @@ type TESMART01 struct {
 	network       *net.Network
 	logger        *log.LoggerService
 	counterNumber byte
-	systemCount   int // количество активных систем
+	systemCount   int    // количество активных систем
+	configuration []byte // блок конфигурации систем из начала 2К памяти
 }
 
 // функция чтения 2К памяти
@@ -59,9 +62,11 @@ func (tem *TESMART01) Init(counterNumber byte, network *net.Network, logger *log
 		return errors.New("ответ от прибора не корректный")
 	}
 	logger.Debug("Получено: %s", string(response[6:13])) // наименование прибора
+	tem.data.Model = string(response[6:13])
+	tem.data.Manufacturer = TemManufacturer
 
 	// запрос на получение к-ва систем и конфигурации
-	response, err = tem.read2K(0x00, 0x00, 0x07)
+	response, err = tem.read2K(0x00, 0x00, 0x1C)
 	for err != nil {
 		return err
 	}
@@ -74,10 +79,16 @@ func (tem *TESMART01) Init(counterNumber byte, network *net.Network, logger *log
 	// первые 6 байт ответа - заголовок АА, 01-адрес, FE-!адрес, 0F-группа команд, 01-идентификатор команды, число посылаемых байт
 	tem.systemCount = int(response[6]) // количество активных систем (не более 6)
 	tem.logger.Debug("Активировано систем - %d", tem.systemCount)
+	if tem.systemCount < 1 || tem.systemCount > 6 {
+		return errors.New("некорректное количество систем прибора")
+	}
+	tem.configuration = response[6 : 6+0x1C]
 
-	// это потом поменяем , когда разберемся с многосистемными
-	if response[6] != 0x01 {
-		return errors.New("Это не односистемный прибор, воспользуйтесь другим драйвером!")
+	tem.data.AddNewSystem(tem.systemCount - 1)
+	for i := 0; i < tem.systemCount; i++ {
+		tem.data.Systems[i].Status = true
+		tem.logger.Debug("Система %d: расходомеры %v, ТСП %v, датчики Р %v", i+1,
+			tem.channelsOf(i, tesmartFlowMasks), tem.channelsOf(i, tesmartTspMasks), tem.channelsOf(i, tesmartPressureMasks))
 	}
 
 	// запрос на чтение заводского номера прибора 4 байта и типа флэш памяти 28 байт
@@ -93,96 +104,266 @@ func (tem *TESMART01) Init(counterNumber byte, network *net.Network, logger *log
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+func (tem *TESMART01) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	name, err := ProbeTemName(counterNumber, network, logger)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации ТЭМ. " + err.Error()
+	}
+	if !bytes.HasPrefix(name, temNameTSM104) {
+		return false, fmt.Sprintf("наименование прибора - %X", name)
+	}
+	return true, "прибор ответил наименованием TSM-104"
+}
+
 // Реализация интерфейса IDeviceDriver::Read
+// Текущие значения хранятся массивами по каналам прибора, интеграторы энергии и время наработки - по системам.
+// Каналы распределяются по системам согласно маскам из блока конфигурации: первый канал системы из маски
+// расходомеров попадает в GV1/V1/M1, второй - в GV2/V2/M2, аналогично для ТСП и датчиков давления.
+// Состояние прибора не заполняется: адреса ошибок в памяти ТЭСМАРТ.01 драйверу неизвестны.
 func (tem *TESMART01) Read() (*models.DataDevice, error) {
 	tem.logger.Info("Чтение текущих данных")
 
-	var response []byte
-	var err error
-
-	tem.data.AddNewSystem(1)
-	tem.data.Systems[0].Status = true
-
-	response, err = tem.read2K(0x02, 0x00, 0x68)
+	parameters, err := tem.read2K(0x02, 0x00, 0x68)
 	for err != nil {
 		return &tem.data, err
 	}
 	// AA01FE0F0168 | 425B4432 422822AF 00000000 00000000 00000000 00000000 00000000 | 00000000 00000000 00000000 00000000 00000000 00000000 | 3F333333 3ECCCCCD 00000000 0000
 	// 				температура 0x200-0x233,										  давление 0x234-0x287,									  и расход 0x288-0x2CF
-	tem.data.Systems[0].T1 = calculateFloatByPointer(response, 0x06+0x00)
-	tem.data.Systems[0].T2 = calculateFloatByPointer(response, 0x06+0x04)
-	tem.data.Systems[0].T3 = calculateFloatByPointer(response, 0x06+0x08)
-	tem.data.Systems[0].P1 = calculateFloatByPointer(response, 0x06+0x34)      // 0x06+0x34
-	tem.data.Systems[0].P2 = calculateFloatByPointer(response, 0x06+0x34+0x04) // 0x06+0x34+0x04
-	tem.data.Systems[0].P3 = calculateFloatByPointer(response, 0x06+0x34+0x08) // 0x06+0x34+0x08
 
 	// разбито на два запроса	от 0x02 0x88 L-0x48 (72)
 	// запрос на чтение G 79 байт
-
-	response, _ = tem.read2K(0x02, 0x88, 0x48)
+	// 0x288-0x29F объёмный расход, 0x2A0-0x2B7 массовый расход (Float по 6 шт.)
+	flows, err := tem.read2K(0x02, 0x88, 0x48)
 	for err != nil {
 		return &tem.data, err
 	}
 
-	tem.data.Systems[0].GV1 = calculateFloatByPointer(response, 0x06+0x00)      // 0x06+0x88
-	tem.data.Systems[0].GV2 = calculateFloatByPointer(response, 0x06+0x04)      // 0x06+0x88+0x04
-	tem.data.Systems[0].GM1 = calculateFloatByPointer(response, 0x06+0x18)      // 0x06+0xA0
-	tem.data.Systems[0].GM2 = calculateFloatByPointer(response, 0x06+0x18+0x04) // 0x06+0xA0+0x04
-
 	// запрос на чтение V и M, 96 байт (Float по 6 шт.)
-
-	response, _ = tem.read2K(0x03, 0x00, 0x60)
+	// объем 0x300-0x317 (дробная часть), 0x318-0x32F (целая часть), масса 0x330-0x35F аналогично
+	integrators, err := tem.read2K(0x03, 0x00, 0x60)
 	for err != nil {
 		return &tem.data, err
 	}
-	// объем 0x300-0x317, масса 0x330-0x35F (Float по 6 шт.)
-	tem.data.Systems[0].V1 = float64(float32(tem.readLongFrom(response, 0x06+0x18)) + tem.readFloatFrom(response, 0x06+0x00))
-	tem.data.Systems[0].V2 = float64(float32(tem.readLongFrom(response, 0x06+0x1C)) + tem.readFloatFrom(response, 0x06+0x04))
-	tem.data.Systems[0].M1 = float64(float32(tem.readLongFrom(response, 0x06+0x48)) + tem.readFloatFrom(response, 0x06+0x30))
-	tem.data.Systems[0].M2 = float64(float32(tem.readLongFrom(response, 0x06+0x4C)) + tem.readFloatFrom(response, 0x06+0x34))
 
 	// запрос на чтение Q, 56 байт
-	response, _ = tem.read2K(0x03, 0x60, 0x38)
+	// энергия по системам 0х360-0х377 (дробная часть, F), 0х378-0x38F (целая часть, L)
+	// AA01FE0F0138 3F7E1E36 00000000 00000000 00000000 00000000 00000000 0000005E 00000000 00000000 00000000 00000000 00000000 00000000 00000000 9F
+	energy, err := tem.read2K(0x03, 0x60, 0x38)
 	for err != nil {
 		return &tem.data, err
 	}
-	// энергия 0х360-0х3FF, 0х378 (L 4 байта) + 0x360 (F 4 байта)
-	tem.data.Systems[0].Q1 = float64(float32(tem.readLongFrom(response, 0x06+0x18)) + tem.readFloatFrom(response, 0x06+0x00))
-	// AA01FE0F0138 3F7E1E36 00000000 00000000 00000000 00000000 00000000 0000005E 00000000 00000000 00000000 00000000 00000000 00000000 00000000 9F
 
 	// запрос на чтение таймеров, 28 байт
-	// можно прочитать 128 байт с подробностями аварийного таймера
-	// 0x404-0x41B время ошибки G<min системы 1, 2, 3, 4, 5, 6
-	// 0x404-0x41B время ошибки G>max системы 1, 2, 3, 4, 5, 6
-	// 0x404-0x41B время ошибки dT системы 1, 2, 3, 4, 5, 6
-	// 0x404-0x41B время ошибки Тех.неиспр. системы 1, 2, 3, 4, 5, 6
-
-	response, _ = tem.read2K(0x04, 0x00, 0x1C)
+	// 0x400-0x403 время общее
+	// 0x404-0x41B время системы 1, 2, 3, 4, 5, 6
+	timers, err := tem.read2K(0x04, 0x00, 0x1C)
 	for err != nil {
 		return &tem.data, err
 	}
-	// 0x400-0x403 время общее
-	// 0x404-0x41B время системы 1, 2, 3, 4, 5, 6
-	tem.data.TimeOn = calculateLongByPointer(response, 0x06+0x00)
-	tem.data.TimeRunCommon = calculateLongByPointer(response, 0x06+0x04)
-	tem.data.Systems[0].TimeRunSys = calculateLongByPointer(response, 0x06+0x04)
+	tem.data.TimeOn = calculateLongByPointer(timers, 0x06+0x00)
+	tem.data.TimeRunCommon = calculateLongByPointer(timers, 0x06+0x04)
+
+	for i := 0; i < tem.systemCount; i++ {
+		system := &tem.data.Systems[i]
+
+		for n, channel := range tem.channelsOf(i, tesmartTspMasks) {
+			t := calculateFloatByPointer(parameters, uint8(0x06+channel*4))
+			switch n {
+			case 0:
+				system.T1 = t
+				system.SetSupported(models.FieldT1)
+			case 1:
+				system.T2 = t
+				system.SetSupported(models.FieldT2)
+			case 2:
+				system.T3 = t
+				system.SetSupported(models.FieldT3)
+			}
+		}
+
+		for n, channel := range tem.channelsOf(i, tesmartPressureMasks) {
+			p := calculateFloatByPointer(parameters, uint8(0x06+0x34+channel*4))
+			switch n {
+			case 0:
+				system.P1 = p
+				system.SetSupported(models.FieldP1)
+			case 1:
+				system.P2 = p
+				system.SetSupported(models.FieldP2)
+			case 2:
+				system.P3 = p
+				system.SetSupported(models.FieldP3)
+			}
+		}
+
+		for n, channel := range tem.channelsOf(i, tesmartFlowMasks) {
+			gv := calculateFloatByPointer(flows, uint8(0x06+channel*4))
+			gm := calculateFloatByPointer(flows, uint8(0x06+0x18+channel*4))
+			v := ToIntegrator(tem.readLongFrom(integrators, 0x06+0x18+channel*4), tem.readFloatFrom(integrators, 0x06+0x00+channel*4))
+			m := ToIntegrator(tem.readLongFrom(integrators, 0x06+0x48+channel*4), tem.readFloatFrom(integrators, 0x06+0x30+channel*4))
+			switch n {
+			case 0:
+				system.GV1, system.GM1, system.V1, system.M1 = gv, gm, v, m
+				system.SetSupported(models.FieldGV1 | models.FieldGM1 | models.FieldV1 | models.FieldM1)
+			case 1:
+				system.GV2, system.GM2, system.V2, system.M2 = gv, gm, v, m
+				system.SetSupported(models.FieldGV2 | models.FieldGM2 | models.FieldV2 | models.FieldM2)
+			}
+		}
+
+		system.Q1 = ToIntegrator(tem.readLongFrom(energy, 0x06+0x18+i*4), tem.readFloatFrom(energy, 0x06+0x00+i*4))
+		system.TimeRunSys = calculateLongByPointer(timers, uint8(0x06+0x04+i*4))
+		system.SetSupported(models.FieldQ1 | models.FieldTimeRunSys)
+	}
 	tem.data.TimeRequest = time.Now()
 
-	// читаем время на приборе
-	response, _ = tem.read2K(0x04, 0x82, 0x0C)
+	tem.data.Time, err = tem.ReadTime()
 	for err != nil {
 		return &tem.data, err
 	}
 
+	return &tem.data, nil
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+// Время читается из памяти 2К с адреса 482h в двоично-десятичном виде: секунды, минуты, часы, день, месяц, год.
+func (tem *TESMART01) ReadTime() (time.Time, error) {
+	tem.logger.Info("Получение даты времени на теплосчётчике")
+	response, err := tem.read2K(0x04, 0x82, 0x0C)
+	for err != nil {
+		return time.Time{}, err
+	}
+
 	year := 2000 + DecodeBcd([]byte{response[11]})
 	month := time.Month(DecodeBcd([]byte{response[10]}))
 	day := DecodeBcd([]byte{response[9]})
 	hour := DecodeBcd([]byte{response[8]})
 	min := DecodeBcd([]byte{response[7]})
 	sek := DecodeBcd([]byte{response[6]})
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
 
-	return &tem.data, nil
+// Реализация интерфейса IClockDriver::WriteTime
+// В памяти 2К находится только копия часов, которую прибор обновляет сам. Адрес часов в памяти таймера ТЭСМАРТ.01
+// не документирован, поэтому запись времени не выполняется.
+func (tem *TESMART01) WriteTime(time.Time) error {
+	return models.ErrClockReadOnly
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *TESMART01) TimeResolution() time.Duration {
+	return time.Second
+}
+
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *TESMART01) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
+// Реализация интерфейса IEventDriver::ReadEvents
+// Прибор ведёт таймеры нештатных ситуаций по системам, в секундах:
+// 0x41C-0x433 G<min, 0x434-0x44B G>max, 0x44C-0x463 dT, 0x464-0x47B техническая неисправность.
+func (tem *TESMART01) ReadEvents() ([]models.Event, error) {
+	tem.logger.Info("Чтение таймеров нештатных ситуаций")
+
+	response, err := tem.read2K(0x04, 0x00, 0x80)
+	for err != nil {
+		return nil, err
+	}
+
+	timers := []struct {
+		eventType models.EventType
+		address   int
+	}{
+		{models.EventGMin, 0x1C},
+		{models.EventGMax, 0x34},
+		{models.EventDeltaT, 0x4C},
+		{models.EventFault, 0x64},
+	}
+
+	var events []models.Event
+	for i, system := range tem.data.Systems {
+		if system.Status == false {
+			continue
+		}
+		for _, timer := range timers {
+			duration := tem.readLongFrom(response, 0x06+timer.address+i*4)
+			if duration == 0 {
+				continue
+			}
+			events = append(events, models.Event{Type: timer.eventType, System: i + 1, Duration: duration})
+		}
+	}
+
+	return events, nil
+}
+
+// Реализация интерфейса IConfigDriver::ReadConfig
+// Конфигурация хранится в начале 2К памяти: число систем, типы систем 6-char, маски расходомеров 6-char,
+// маски ТСП 6-char, маски датчиков Р 6-char. Бит маски n соответствует каналу n+1.
+// Наименования типов систем по протоколу неизвестны, поэтому передаются только коды.
+func (tem *TESMART01) ReadConfig() (*models.DeviceConfig, error) {
+	tem.logger.Info("Чтение конфигурации систем")
+
+	response, err := tem.read2K(0x00, 0x00, 0x1C)
+	for err != nil {
+		return nil, err
+	}
+	data := response[6:]
+
+	config := models.DeviceConfig{
+		Serial: tem.data.Serial,
+		UnitQ:  tem.data.UnitQ,
+	}
+
+	masks := []struct {
+		quantity models.QuantityEnum
+		address  int
+	}{
+		{models.QuantityFlow, tesmartFlowMasks},
+		{models.QuantityTemperature, tesmartTspMasks},
+		{models.QuantityPressure, tesmartPressureMasks},
+	}
+
+	for i := 0; i < int(data[0]) && i < 6; i++ {
+		system := models.SystemConfig{
+			Number:     i + 1,
+			SchemeCode: int(data[1+i]),
+			Enabled:    true,
+		}
+		for _, mask := range masks {
+			for _, channel := range channelsFromMask(data[mask.address+i]) {
+				system.Channels = append(system.Channels, models.ChannelAssignment{Quantity: mask.quantity, Channel: channel + 1})
+			}
+		}
+		config.Systems = append(config.Systems, system)
+	}
+
+	return &config, nil
+}
+
+// Адреса масок каналов по системам в блоке конфигурации, по 6 байт на каждую величину
+const (
+	tesmartFlowMasks     = 7
+	tesmartTspMasks      = 13
+	tesmartPressureMasks = 19
+)
+
+// Номера каналов (начиная с 0), заданных в маске системы. system - индекс системы, начиная с 0
+func (tem *TESMART01) channelsOf(system int, maskAddress int) []int {
+	return channelsFromMask(tem.configuration[maskAddress+system])
+}
+
+// Бит маски n соответствует каналу n
+func channelsFromMask(mask byte) []int {
+	var channels []int
+	for bit := 0; bit < 6; bit++ {
+		if mask&(1<<uint(bit)) != 0 {
+			channels = append(channels, bit)
+		}
+	}
+	return channels
 }
 
 func (tem *TESMART01) readLongFrom(response []byte, cursor int) uint32 {
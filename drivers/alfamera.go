@@ -20,9 +20,9 @@ type Alfamera struct {
 	number  byte
 
 	/*
-		Коэф ед. давления
+		Единицы измерения давления прибора
 	*/
-	coefficientP float32
+	unitP models.UnitPEnum
 
 	/*
 		Коэф. расхода, воды
@@ -41,6 +41,8 @@ func (tm3 *Alfamera) Init(counterNumber byte, network *net.Network, logger *log.
 	tm3.network = network
 	tm3.number = counterNumber
 	tm3.logger.Info("Инициализация прибора, № %d", tm3.number)
+	// Производитель в протоколе прибора не указан
+	tm3.data.Model = "Альфамера"
 
 	tm3.logger.Info("Запрос серийного номера прибора")
 	/**
@@ -127,13 +129,13 @@ func (tm3 *Alfamera) Init(counterNumber byte, network *net.Network, logger *log.
 	unitP := int(toWord([2]byte{response[0], response[1]}))
 
 	if unitP == 0 { // КПа
-		tm3.coefficientP = 0.001
+		tm3.unitP = models.KPa
 	} else if unitP == 1 { // кгс/см3
-		tm3.coefficientP = 0.0980665
+		tm3.unitP = models.KgfCm2
 	} else if unitP == 2 { // бар
-		tm3.coefficientP = 0.1
+		tm3.unitP = models.Bar
 	} else if unitP == 3 { // МПа
-		tm3.coefficientP = 1.0
+		tm3.unitP = models.MPa
 	} else {
 		tm3.logger.Debug("Ошибка при расшифровке ед. измерения давления: %d ", unitP)
 		return errors.New("не определены единицы измерения давления")
@@ -150,7 +152,7 @@ func (tm3 *Alfamera) Init(counterNumber byte, network *net.Network, logger *log.
 
 	if unitV == 0 { // м3 или т
 		tm3.coefficientV = 1.0
-	} else if unitP == 1 { // тысячи м3 или тысячи тонн
+	} else if unitV == 1 { // тысячи м3 или тысячи тонн
 		tm3.coefficientV = 0.001
 	} else {
 		tm3.logger.Debug("Ошибка при расшифровке ед. измерения воды: %d ", unitV)
@@ -183,6 +185,10 @@ func (tm3 *Alfamera) Read() (*models.DataDevice, error) {
 	for i < len(tm3.data.Systems) {
 
 		tm3.data.Systems[i].Status = true
+		// Объёмы прибор не передаёт, только массы
+		tm3.data.Systems[i].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ | models.FieldQ1 | models.FieldQ2 |
+			models.FieldQ3 | models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2 | models.FieldGV1 |
+			models.FieldGV2 | models.FieldT1 | models.FieldT2 | models.FieldT3 | models.FieldP1 | models.FieldP2 | models.FieldP3)
 
 		tm3.logger.Info("Запрос данных для системы %d", i+1)
 		response, err = tm3.runIO([]byte{tm3.number, 0x03, 0x70, byte(i * 4), 0x00, 0x3A})
@@ -190,17 +196,17 @@ func (tm3 *Alfamera) Read() (*models.DataDevice, error) {
 			return &tm3.data, err
 		}
 
-		tm3.data.Systems[i].SigmaQ = float64(float32(toDouble(response[0:8]) / 1000000))
+		tm3.data.Systems[i].SigmaQ = toDouble(response[0:8]) / 1000000
 
-		tm3.data.Systems[i].Q1 = float64(float32(toDouble(response[8:16]) / 1000000))
-		tm3.data.Systems[i].M1 = float64(float32(toDouble(response[16:24])) * 0.001)
+		tm3.data.Systems[i].Q1 = toDouble(response[8:16]) / 1000000
+		tm3.data.Systems[i].M1 = toDouble(response[16:24]) / 1000
 		tm3.data.Systems[i].GM1 = calculateFloatByPointer(response, 24) * 0.001
 		tm3.data.Systems[i].GV1 = calculateFloatByPointer(response, 28) * tm3.coefficientV
 		tm3.data.Systems[i].T1 = calculateFloatByPointer(response, 32)
-		tm3.data.Systems[i].P1 = calculateFloatByPointer(response, 36) * tm3.coefficientP
+		tm3.data.Systems[i].P1 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 36))
 
 		if true {
-			tm3.data.Systems[i].Q2 = float64(float32(toDouble(response[40:48]) / 1000000))
+			tm3.data.Systems[i].Q2 = toDouble(response[40:48]) / 1000000
 		} else {
 			// По договорённости тут должно лежать Q2, но при работе счётчика в "замкнутом" режиме по каким-то причинам
 			// не кладёт в этот адрес значение Q2. Значение лежит для первой системы в регистре 0x0480 в типе DOUBLE.
@@ -210,16 +216,16 @@ func (tm3 *Alfamera) Read() (*models.DataDevice, error) {
 			for err != nil {
 				return &tm3.data, err
 			}
-			tm3.data.Systems[i].Q2 = float64(float32(toDouble(responseQ2) / 1000000))
+			tm3.data.Systems[i].Q2 = toDouble(responseQ2) / 1000000
 		}
 
-		tm3.data.Systems[i].M2 = float64(float32(toDouble(response[48:56]) * 0.001))
+		tm3.data.Systems[i].M2 = toDouble(response[48:56]) / 1000
 		tm3.data.Systems[i].GM2 = calculateFloatByPointer(response, 56) * 0.001
 		tm3.data.Systems[i].GV2 = calculateFloatByPointer(response, 60) * tm3.coefficientV
 		tm3.data.Systems[i].T2 = calculateFloatByPointer(response, 64)
-		tm3.data.Systems[i].P2 = calculateFloatByPointer(response, 68) * tm3.coefficientP
+		tm3.data.Systems[i].P2 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 68))
 
-		tm3.data.Systems[i].Q3 = float64(float32(toDouble(response[72:80]) / 1000000))
+		tm3.data.Systems[i].Q3 = toDouble(response[72:80]) / 1000000
 
 		/**
 		Трубопровод подпитки. В ядре он не учтён.
@@ -231,7 +237,7 @@ func (tm3 *Alfamera) Read() (*models.DataDevice, error) {
 		//давление подпитки = toFloat([4]byte{response[103], response[70], response[69], response[100]})
 
 		tm3.data.Systems[i].T3 = calculateFloatByPointer(response, 104)
-		tm3.data.Systems[i].P3 = calculateFloatByPointer(response, 108) * tm3.coefficientP
+		tm3.data.Systems[i].P3 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 108))
 		tm3.data.Systems[i].TimeRunSys = calculateLongByPointer(response, 112)
 
 		i++
@@ -247,6 +253,11 @@ func (tm3 *Alfamera) Read() (*models.DataDevice, error) {
 
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tm3 *Alfamera) Protocol() models.ProtocolEnum {
+	return models.ProtocolModbus
+}
+
 func (tm3 *Alfamera) checkResponse(response []byte) bool {
 
 	if len(response) < 3 {
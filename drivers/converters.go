@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"math"
+	"time"
 )
 
 func ToLong(bytes [4]byte) uint32 {
@@ -52,6 +53,27 @@ func DecodeBcd(bcd []byte) int {
 	return x
 }
 
+/*
+Значение интегратора ТЭМ: целая часть хранится в LONG, дробная - в FLOAT.
+Сложение выполняется в float64, т.к. у float32 всего 24 бита мантиссы и при больших интеграторах дробная часть теряется.
+ */
+func ToIntegrator(whole uint32, fraction float32) float64 {
+	return float64(whole) + float64(fraction)
+}
+
+// Приведение целого от 0 до 99 к двоично-десятичному виду. Например: 59 => 0x59
+func EncodeBcd(value int) byte {
+	return byte(value/10%10<<4 | value%10)
+}
+
+// Номер дня недели, где понедельник - 1, воскресенье - 7
+func IsoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
 // Приведение целого к байтам
 // Порядок байт - BigEndian
 // Пример: 8704 (dec) будет приведено к 2200 (hex)
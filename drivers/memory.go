@@ -0,0 +1,106 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"qBox/services/log"
+	"qBox/services/net"
+)
+
+type TemMemoryEnum byte // Область памяти прибора ТЭМ, доступная для чтения командами протокола
+const (
+	TemMemory2K    TemMemoryEnum = 0x01 // Память 2К (EEPROM 512 у ТЭМ-104К): настройки и интеграторы, команда 0F01h
+	TemMemoryRam   TemMemoryEnum = 0x02 // Оперативная память: текущие значения, команда 0C01h
+	TemMemoryFlash TemMemoryEnum = 0x03 // Flash (EEPROM 64К): архивы и журнал событий, команда 0F03h
+)
+
+var temMemoryCodes = map[TemMemoryEnum]string{
+	TemMemory2K:    "2k",
+	TemMemoryRam:   "ram",
+	TemMemoryFlash: "flash",
+}
+
+var temMemoryNames = map[TemMemoryEnum]string{
+	TemMemory2K:    "память 2К",
+	TemMemoryRam:   "оперативная память",
+	TemMemoryFlash: "flash память",
+}
+
+// Команда протокола ТЭМ: группа команд и команда чтения области памяти
+var temMemoryCommands = map[TemMemoryEnum][2]byte{
+	TemMemory2K:    {0x0F, 0x01},
+	TemMemoryRam:   {0x0C, 0x01},
+	TemMemoryFlash: {0x0F, 0x03},
+}
+
+/**
+Размер области памяти. Для оперативной памяти размер зависит от модели прибора и не задан.
+*/
+var temMemorySizes = map[TemMemoryEnum]int{
+	TemMemory2K:    0x800,
+	TemMemoryFlash: 0x10000,
+}
+
+// Обозначение области памяти для флагов утилиты. Например: 2k
+func (memory TemMemoryEnum) Code() string {
+	return temMemoryCodes[memory]
+}
+
+// Наименование области памяти. Например: оперативная память
+func (memory TemMemoryEnum) String() string {
+	return temMemoryNames[memory]
+}
+
+// Размер области памяти в байтах, 0 - размер не известен
+func (memory TemMemoryEnum) Size() int {
+	return temMemorySizes[memory]
+}
+
+// Область памяти по обозначению из флага утилиты
+func TemMemoryByCode(code string) (TemMemoryEnum, error) {
+	for memory, memoryCode := range temMemoryCodes {
+		if memoryCode == code {
+			return memory, nil
+		}
+	}
+	return 0, errors.New("область памяти задана не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+/**
+Чтение области памяти прибора ТЭМ блоками по chunk байт.
+Большие блоки на медленных каналах приходят не полностью (см. чтение 2К памяти в tem104.go), поэтому размер блока
+задаётся вызывающим, для GPRS безопасно не более 64 байт. При ошибке возвращаются байты, прочитанные до неё.
+*/
+func ReadTemMemory(counterNumber byte, network *net.Network, logger *log.LoggerService,
+	memory TemMemoryEnum, address int, size int, chunk int) ([]byte, error) {
+
+	command := temMemoryCommands[memory]
+	var dump []byte
+	for offset := 0; offset < size; offset += chunk {
+		length := chunk
+		if size-offset < length {
+			length = size - offset
+		}
+		start := address + offset
+		logger.Info("Чтение: %s, адрес %04X, %d байт", memory, start, length)
+
+		request := []byte{0x55, counterNumber, ToNotByte(counterNumber), command[0], command[1], 0x03,
+			byte(start >> 8), byte(start), byte(length)}
+		var sum byte = 0
+		for _, b := range request {
+			sum += b
+		}
+		temRequest := net.PrepareRequest(append(request, ^sum))
+		temRequest.SecondsReadTimeout = 5
+		temRequest.ControlFunction = checkTemFrame
+		response, err := network.RunIO(temRequest)
+		for err != nil {
+			return dump, fmt.Errorf("адрес %04X: %w", start, err)
+		}
+		if int(response[5]) != length {
+			return dump, fmt.Errorf("адрес %04X: получено %d байт вместо %d", start, response[5], length)
+		}
+		dump = append(dump, response[6:6+length]...)
+	}
+	return dump, nil
+}
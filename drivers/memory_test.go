@@ -0,0 +1,90 @@
+package drivers
+
+import (
+	"bytes"
+	"errors"
+	"qBox/services/log"
+	"qBox/services/net"
+	"testing"
+)
+
+func TestTemMemoryByCode(t *testing.T) {
+	for memory, code := range temMemoryCodes {
+		found, err := TemMemoryByCode(code)
+		if err != nil || found != memory {
+			t.Errorf("TemMemoryByCode(%q) = %v, %v", code, found, err)
+		}
+	}
+	if _, err := TemMemoryByCode("eeprom"); err == nil {
+		t.Error("TemMemoryByCode: неизвестная область памяти принята")
+	}
+	if TemMemory2K.Size() != 0x800 || TemMemoryRam.Size() != 0 {
+		t.Errorf("размер 2К - %d, оперативной памяти - %d", TemMemory2K.Size(), TemMemoryRam.Size())
+	}
+}
+
+// Ответ прибора ТЭМ на чтение памяти: байты data с контрольной суммой
+func temMemoryResponse(data []byte) []byte {
+	response := append([]byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, byte(len(data))}, data...)
+	var sum byte
+	for _, b := range response {
+		sum += b
+	}
+	return append(response, ^sum)
+}
+
+func TestReadTemMemory(t *testing.T) {
+	logger := log.LoggerService{}
+	logger.OpenDiscard()
+	network := net.NewReplayNetwork([]net.Exchange{
+		{Response: temMemoryResponse([]byte{0x01, 0x02, 0x03, 0x04})},
+		{Response: temMemoryResponse([]byte{0x05, 0x06, 0x07, 0x08})},
+		{Response: temMemoryResponse([]byte{0x09, 0x0A})},
+	}, logger)
+
+	dump, err := ReadTemMemory(1, network, &logger, TemMemory2K, 0x1FC, 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dump, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A}) {
+		t.Errorf("дамп % X", dump)
+	}
+
+	// Запросы блоками: команда 0F01h, адрес и длина блока, последний блок - остаток
+	expected := [][]byte{
+		{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0xFC, 0x04},
+		{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x02, 0x00, 0x04},
+		{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x02, 0x04, 0x02},
+	}
+	calls := network.ReplayCalls()
+	if len(calls) != len(expected) {
+		t.Fatalf("запросов %d, ожидалось %d", len(calls), len(expected))
+	}
+	for i, call := range calls {
+		if !bytes.Equal(call.Request[:len(call.Request)-1], expected[i]) {
+			t.Errorf("запрос %d: % X, ожидалось % X", i, call.Request, expected[i])
+		}
+	}
+}
+
+func TestReadTemMemoryShortResponse(t *testing.T) {
+	logger := log.LoggerService{}
+	logger.OpenDiscard()
+	network := net.NewReplayNetwork([]net.Exchange{
+		{Response: temMemoryResponse([]byte{0x01, 0x02})},
+		{Response: temMemoryResponse([]byte{0x03})},
+	}, logger)
+
+	dump, err := ReadTemMemory(1, network, &logger, TemMemoryRam, 0, 4, 2)
+	if err == nil {
+		t.Fatal("неполный блок принят")
+	}
+	if !bytes.Equal(dump, []byte{0x01, 0x02}) {
+		t.Errorf("при ошибке возвращены байты % X, ожидались прочитанные до неё", dump)
+	}
+
+	dump, err = ReadTemMemory(1, network, &logger, TemMemoryRam, 4, 2, 2)
+	if !errors.Is(err, net.ErrNoResponse) || len(dump) != 0 {
+		t.Errorf("нет ответа в сеансе: % X, %v", dump, err)
+	}
+}
@@ -0,0 +1,199 @@
+package drivers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"qBox/models"
+	"qBox/services/log"
+	"qBox/services/net"
+	"strconv"
+)
+
+/**
+Запросы идентификации приборов для автоматического определения типа теплосчётчика (-type=auto).
+Запросы только читают данные прибора и отправляются без повторов, чтобы не затягивать перебор драйверов.
+*/
+
+// Наименования приборов, которые возвращает команда идентификации 0000h приборов ТЭМ
+var (
+	temNameTSM104  = []byte("TSM-104")
+	temNameTEM104M = []byte("TEM-104M")
+)
+
+// Производитель приборов ТЭМ
+const TemManufacturer = "АРВАС"
+
+// Идентификация прибора ТЭМ (команда 0000h). Возвращается наименование прибора.
+func ProbeTemName(counterNumber byte, network *net.Network, logger *log.LoggerService) ([]byte, error) {
+	logger.Info("Запрос идентификации прибора ТЭМ")
+	response, err := probeTem(counterNumber, network, logger, []byte{0x00, 0x00, 0x00})
+	for err != nil {
+		return nil, err
+	}
+	return response[6 : len(response)-1], nil
+}
+
+// Заполнение модели и производителя прибора ТЭМ. Модель - наименование из ответа на команду идентификации 0000h
+// (раздел 3.1 протокола ТЭМ-104М), если прибор не ответил, то model - модель по протоколу, по которому написан драйвер.
+func PopulateTemIdentity(counterNumber byte, network *net.Network, logger *log.LoggerService, device *models.DataDevice, model string) {
+	device.Manufacturer = TemManufacturer
+	device.Model = model
+	name, err := ProbeTemName(counterNumber, network, logger)
+	for err != nil {
+		logger.Info("Наименование прибора не получено, модель по протоколу драйвера - %s. %s", model, err.Error())
+		return
+	}
+	device.Model = string(name)
+}
+
+// Определение прибора ТЭМ-104М по наименованию. Протокол ТЭМ-104М (раздел 3.1) не различает исполнения прибора:
+// ТЭМ-104М и ТЭМ-104М-1 отвечают одним наименованием, поэтому его принимают драйверы типов 6, 11 и 13, а при
+// автоматическом определении тип приходится задавать флагом "-type".
+func DetectTem104M(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	name, err := ProbeTemName(counterNumber, network, logger)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации ТЭМ. " + err.Error()
+	}
+	if !bytes.HasPrefix(name, temNameTEM104M) {
+		return false, fmt.Sprintf("наименование прибора - %X", name)
+	}
+	return true, "прибор ответил наименованием " + string(name)
+}
+
+// Чтение блока памяти 2К прибора ТЭМ (команда 0F01h) без заголовка и контрольной суммы
+func probeTem2K(counterNumber byte, network *net.Network, logger *log.LoggerService, address uint16, size byte) ([]byte, error) {
+	logger.Info("Чтение памяти 2К прибора ТЭМ с адреса %X", address)
+	command := append([]byte{0x0F, 0x01, 0x03}, intToBigEndian(address)...)
+	response, err := probeTem(counterNumber, network, logger, append(command, size))
+	for err != nil {
+		return nil, err
+	}
+	if len(response) < 6+int(size)+1 {
+		return nil, errors.New("размер полученных данных меньше ожидаемого")
+	}
+	return response[6 : 6+int(size)], nil
+}
+
+func probeTem(counterNumber byte, network *net.Network, logger *log.LoggerService, commandBytes []byte) ([]byte, error) {
+	command := append([]byte{0x55, counterNumber, ToNotByte(counterNumber)}, commandBytes...)
+	var sum byte = 0
+	for _, b := range command {
+		sum += b
+	}
+	request := net.PrepareRequest(append(command, ^sum))
+	request.Attempts = 0
+	request.ControlFunction = checkTemFrame
+	return network.RunIO(request)
+}
+
+// Проверка кадра ответа прибора ТЭМ: заголовок, инверсный адрес, длина и контрольная сумма
+func checkTemFrame(response []byte) bool {
+	if len(response) < 7 || response[0] != 0xAA || ^response[1] != response[2] {
+		return false
+	}
+	if len(response) < 6+int(response[5])+1 {
+		return false
+	}
+	var sum byte = 0
+	for _, b := range response[:len(response)-1] {
+		sum += b
+	}
+	return ^sum == response[len(response)-1]
+}
+
+// Код производителя приборов SKU-02-B и SKU-02-K в заголовке ответа M-Bus
+const MBusManufacturerSKU = "AXI"
+
+/**
+Заголовок ответа прибора M-Bus на запрос REQ_UD2: 68 L L 68 C A CI ID(4) Man(2) Ver Med ...
+*/
+type MBusIdentity struct {
+	Address      byte
+	Id           string // Заводской номер, BCD
+	Manufacturer string // Код производителя, три латинских буквы. Например: AXI
+	Version      byte
+	Medium       byte
+	MoreData     bool // Последняя запись кадра - DIF 1Fh: данные продолжаются в следующем кадре
+}
+
+func (identity MBusIdentity) String() string {
+	text := fmt.Sprintf("M-Bus: производитель %s, версия %X, среда %X, номер %s",
+		identity.Manufacturer, identity.Version, identity.Medium, identity.Id)
+	if identity.MoreData {
+		text += ", данные в нескольких кадрах"
+	}
+	return text
+}
+
+/**
+Идентификация прибора M-Bus: сброс канального уровня SND_NKE и запрос данных класса 2 REQ_UD2.
+control - управляющий байт запроса: 5Bh или 7Bh (бит FCB). Часть приборов отвечает только на один из них.
+*/
+func ProbeMBus(counterNumber byte, network *net.Network, logger *log.LoggerService, control byte) (MBusIdentity, error) {
+	logger.Info("Запрос идентификации прибора M-Bus")
+	request := net.PrepareRequest([]byte{0x10, 0x40, counterNumber, 0x40 + counterNumber, 0x16})
+	request.Attempts = 0
+	request.ControlFunction = func(response []byte) bool {
+		return len(response) > 0 && response[0] == 0xE5
+	}
+	_, err := network.RunIO(request)
+	for err != nil {
+		return MBusIdentity{}, err
+	}
+
+	logger.Info("Запрос данных класса 2, управляющий байт %X", control)
+	request = net.PrepareRequest([]byte{0x10, control, counterNumber, control + counterNumber, 0x16})
+	request.Attempts = 0
+	request.ControlFunction = func(response []byte) bool {
+		if len(response) < 6 || response[0] != 0x68 || response[3] != 0x68 || response[1] != response[2] {
+			return false
+		}
+		if len(response) < int(response[1])+6 || response[len(response)-1] != 0x16 {
+			return false
+		}
+		var sum byte = 0
+		for _, b := range response[4 : len(response)-2] {
+			sum += b
+		}
+		return sum == response[len(response)-2]
+	}
+	response, err := network.RunIO(request)
+	for err != nil {
+		return MBusIdentity{}, err
+	}
+	if len(response) < 15 || response[6] != 0x72 {
+		return MBusIdentity{}, errors.New("ответ прибора M-Bus не содержит заголовка данных")
+	}
+
+	identity := ParseMBusIdentity(response)
+	identity.MoreData = len(response) > 19+2 && response[len(response)-3] == 0x1F
+	return identity, nil
+}
+
+// Признак прибора SKU-02-B: производитель AXI, как у SKU-02-K, но номер протокола отличается от 06 (SKU-02-K)
+func (identity MBusIdentity) isSku02B() bool {
+	return identity.Manufacturer == MBusManufacturerSKU && identity.Version != sku02KVersion
+}
+
+// Разбор заголовка длинного кадра M-Bus. Длина кадра должна быть проверена заранее, не менее 15 байт
+func ParseMBusIdentity(response []byte) MBusIdentity {
+	manufacturer := uint16(response[12])<<8 | uint16(response[11])
+	return MBusIdentity{
+		Address: response[5],
+		Id:      fmt.Sprintf("%X", []byte{response[10], response[9], response[8], response[7]}),
+		Manufacturer: string([]byte{
+			byte(manufacturer>>10&0x1F) + 64,
+			byte(manufacturer>>5&0x1F) + 64,
+			byte(manufacturer&0x1F) + 64}),
+		Version: response[13],
+		Medium:  response[14],
+	}
+}
+
+// Заполнение сведений о приборе. Версия в заголовке M-Bus в протоколах СКУ называется номером протокола
+func (identity MBusIdentity) Populate(device *models.DataDevice) {
+	device.Address = strconv.Itoa(int(identity.Address))
+	device.Manufacturer = identity.Manufacturer
+	device.ProtocolVersion = strconv.Itoa(int(identity.Version))
+}
@@ -2,9 +2,11 @@ package skm2
 
 import (
 	"encoding/hex"
+	"qBox/drivers"
 	"qBox/drivers/skm2/data"
 	"qBox/drivers/skm2/systems"
 	"qBox/models"
+	"qBox/services/convert"
 	"qBox/services/log"
 	"qBox/services/net"
 	"time"
@@ -29,6 +31,7 @@ func (skm *SKM) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	skm.network = network
 	skm.counterNumber = counterNumber
 	skm.checks = data.Checks{Logger: logger}
+	skm.data.Model = "СКМ-2"
 
 	// Согласно переписке с производителем СКМ-2 счётчиков.
 	// ПО верхнего уровня для преобразования использует коэффициент:
@@ -48,6 +51,20 @@ func (skm *SKM) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	return err
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// СКМ-2 передаёт текущие данные одним кадром, в отличие от СКМ-2М. Код производителя СКМ-2 драйверу не известен,
+// поэтому прибором СКМ считается прибор M-Bus, код производителя которого отличается от SKU-02 (AXI).
+func (skm *SKM) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	identity, err := drivers.ProbeMBus(counterNumber, network, logger, 0x7B)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации M-Bus. " + err.Error()
+	}
+	if identity.Manufacturer == drivers.MBusManufacturerSKU || identity.MoreData {
+		return false, identity.String()
+	}
+	return true, identity.String()
+}
+
 /**
 Чтение текущих данных для СКМ-2 согласно протоколу M-bus EN 60870-5
 */
@@ -77,6 +94,8 @@ func (skm *SKM) Read() (*models.DataDevice, error) {
 	}
 
 	skm.data.Serial = hex.EncodeToString([]byte{response[10], response[9], response[8], response[7]})
+	skm.data.MeterStatus = drivers.MBusStatus(response[drivers.MBusStatusIndex])
+	drivers.ParseMBusIdentity(response).Populate(&skm.data)
 
 	c := systems.Common{DataDevice: &skm.data}
 	c.PopulateFromBytes(response[19:])
@@ -90,3 +109,35 @@ func (skm *SKM) Read() (*models.DataDevice, error) {
 
 	return &skm.data, nil
 }
+
+// Реализация интерфейса IProtocolDriver::Protocol
+func (skm *SKM) Protocol() models.ProtocolEnum {
+	return models.ProtocolMbus
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+// Время прибора передаётся только в составе текущих данных
+func (skm *SKM) ReadTime() (time.Time, error) {
+	device, err := skm.Read()
+	return device.Time, err
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Запись даты времени телеграммой SND_UD (DIF 04h, VIF 6Dh, тип F). Часы прибора имеют точность до минуты.
+func (skm *SKM) WriteTime(t time.Time) error {
+	skm.logger.Info("Запись даты времени на теплосчётчик")
+	userData := append([]byte{0x53, skm.counterNumber, 0x51, 0x04, 0x6D}, convert.EncodeTypeF(t.Round(time.Minute))...)
+	length := byte(len(userData))
+	request := net.PrepareRequest(append(append([]byte{0x68, length, length, 0x68}, userData...),
+		skm.checks.CalculateCheckSum(userData), 0x16))
+	request.ControlFunction = skm.checks.CheckSimpleFrame
+	request.Attempts = 0
+	_, err := skm.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+// Время типа F передаётся с точностью до минуты
+func (skm *SKM) TimeResolution() time.Duration {
+	return time.Minute
+}
@@ -14,6 +14,7 @@ type FirstSystem struct {
 
 func (firstSystem *FirstSystem) PopulateFromBytes(b []byte) {
 	firstSystem.grabber = data.Grabber{Datum: b}
+	firstSystem.System.ResetSupported()
 	firstSystem.populateEnergy()
 
 	firstSystem.populateM1()
@@ -42,15 +43,15 @@ func (firstSystem *FirstSystem) populateEnergy() {
 	var grabValue []byte
 
 	type Block struct {
-		data   []byte
-		factor float32
+		data    []byte
+		divisor float64
 	}
 
 	blocks := map[int]Block{
-		1: {[]byte{0x04, 0x07}, 0.01},
-		2: {[]byte{0x04, 0x06}, 0.001},
-		3: {[]byte{0x84, 0x80, 0x40, 0x07}, 0.01},
-		4: {[]byte{0x84, 0x80, 0x40, 0x06}, 0.001},
+		1: {[]byte{0x04, 0x07}, 100},
+		2: {[]byte{0x04, 0x06}, 1000},
+		3: {[]byte{0x84, 0x80, 0x40, 0x07}, 100},
+		4: {[]byte{0x84, 0x80, 0x40, 0x06}, 1000},
 	}
 
 	// Требуется гарантированная сортировка, так как Q3 не должен попадать вместо Q1
@@ -69,7 +70,8 @@ func (firstSystem *FirstSystem) populateEnergy() {
 				valueBytes[i] = invertBytes[i]
 			}
 			firstSystem.System.Status = true
-			firstSystem.System.SigmaQ = float64(float32(convert.ToLong(valueBytes)) * blocks[key].factor)
+			firstSystem.System.SetSupported(models.FieldSigmaQ)
+			firstSystem.System.SigmaQ = float64(convert.ToLong(valueBytes)) / blocks[key].divisor
 			return
 		}
 	}
@@ -85,7 +87,8 @@ func (firstSystem *FirstSystem) populateV1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.V1 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		firstSystem.System.SetSupported(models.FieldV1)
+		firstSystem.System.V1 = float64(convert.ToLong(valueBytes)) / 1000
 		return
 	}
 	// На одном из ЦТП произведена замена плат и VID сменился на 0x14 (Очень похоже на протокол SKU-02)
@@ -96,7 +99,8 @@ func (firstSystem *FirstSystem) populateV1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.V1 = float64(float32(convert.ToLong(valueBytes)) * 0.01)
+		firstSystem.System.SetSupported(models.FieldV1)
+		firstSystem.System.V1 = float64(convert.ToLong(valueBytes)) / 100
 		return
 	}
 }
@@ -111,7 +115,8 @@ func (firstSystem *FirstSystem) populateV2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.V2 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		firstSystem.System.SetSupported(models.FieldV2)
+		firstSystem.System.V2 = float64(convert.ToLong(valueBytes)) / 1000
 		return
 	}
 	// На одном из ЦТП произведена замена плат и VID сменился на 0x14 (Очень похоже на протокол SKU-02)
@@ -122,7 +127,8 @@ func (firstSystem *FirstSystem) populateV2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.V2 = float64(float32(convert.ToLong(valueBytes)) * 0.01)
+		firstSystem.System.SetSupported(models.FieldV2)
+		firstSystem.System.V2 = float64(convert.ToLong(valueBytes)) / 100
 		return
 	}
 }
@@ -137,7 +143,8 @@ func (firstSystem *FirstSystem) populateM1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.M1 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		firstSystem.System.SetSupported(models.FieldM1)
+		firstSystem.System.M1 = float64(convert.ToLong(valueBytes)) / 1000
 		return
 	}
 }
@@ -152,7 +159,8 @@ func (firstSystem *FirstSystem) populateM2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
-		firstSystem.System.M2 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		firstSystem.System.SetSupported(models.FieldM2)
+		firstSystem.System.M2 = float64(convert.ToLong(valueBytes)) / 1000
 	}
 }
 
@@ -166,6 +174,7 @@ func (firstSystem *FirstSystem) populateGV1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldGV1)
 		firstSystem.System.GV1 = convert.ToFloat(valueBytes)
 	}
 }
@@ -180,6 +189,7 @@ func (firstSystem *FirstSystem) populateGV2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldGV2)
 		firstSystem.System.GV2 = convert.ToFloat(valueBytes)
 	}
 }
@@ -194,6 +204,7 @@ func (firstSystem *FirstSystem) populateGM1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldGM1)
 		firstSystem.System.GM1 = convert.ToFloat(valueBytes)
 	}
 }
@@ -208,6 +219,7 @@ func (firstSystem *FirstSystem) populateGM2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldGM2)
 		firstSystem.System.GM2 = convert.ToFloat(valueBytes)
 	}
 }
@@ -219,6 +231,7 @@ func (firstSystem *FirstSystem) populateT1() {
 	if cap(valueBytes) == len(grabValue) {
 		valueBytes[0], valueBytes[1] = grabValue[1], grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldT1)
 		firstSystem.System.T1 = float32(convert.ToWord(valueBytes)) * 0.01
 	}
 }
@@ -230,6 +243,7 @@ func (firstSystem *FirstSystem) populateT2() {
 	if cap(valueBytes) == len(grabValue) {
 		valueBytes[0], valueBytes[1] = grabValue[1], grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldT2)
 		firstSystem.System.T2 = float32(convert.ToWord(valueBytes)) * 0.01
 	}
 }
@@ -241,6 +255,7 @@ func (firstSystem *FirstSystem) populateT3() {
 	if cap(valueBytes) == len(grabValue) {
 		valueBytes[0], valueBytes[1] = grabValue[1], grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldT3)
 		firstSystem.System.T3 = float32(convert.ToWord(valueBytes)) * 0.01
 	}
 }
@@ -255,6 +270,7 @@ func (firstSystem *FirstSystem) populateP1() {
 		valueBytes[2] = grabValue[0]
 		valueToConvert := [4]byte{0x00, grabValue[2], grabValue[1], grabValue[0]}
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldP1)
 		firstSystem.System.P1 = float32(convert.ToLong(valueToConvert)) * 0.0001
 	}
 }
@@ -269,6 +285,7 @@ func (firstSystem *FirstSystem) populateP2() {
 		valueBytes[2] = grabValue[0]
 		valueToConvert := [4]byte{0x00, grabValue[2], grabValue[1], grabValue[0]}
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldP2)
 		firstSystem.System.P2 = float32(convert.ToLong(valueToConvert)) * 0.0001
 	}
 }
@@ -283,6 +300,7 @@ func (firstSystem *FirstSystem) populateTime() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		firstSystem.System.Status = true
+		firstSystem.System.SetSupported(models.FieldTimeRunSys)
 		firstSystem.System.TimeRunSys = convert.ToLong(valueBytes)
 	}
 }
@@ -13,6 +13,7 @@ type SecondSystem struct {
 
 func (secondSystem *SecondSystem) PopulateFromBytes(b []byte) {
 	secondSystem.grabber = data.Grabber{Datum: b}
+	secondSystem.System.ResetSupported()
 	secondSystem.populateEnergy()
 
 	secondSystem.populateM1()
@@ -40,13 +41,13 @@ func (secondSystem *SecondSystem) populateEnergy() {
 	var grabValue []byte
 
 	type Block struct {
-		data   []byte
-		factor float32
+		data    []byte
+		divisor float64
 	}
 
 	blocks := map[int]Block{
-		0: {[]byte{0x84, 0x40, 0x07}, 0.01},
-		1: {[]byte{0x84, 0x40, 0x06}, 0.001},
+		0: {[]byte{0x84, 0x40, 0x07}, 100},
+		1: {[]byte{0x84, 0x40, 0x06}, 1000},
 	}
 
 	for _, block := range blocks {
@@ -57,7 +58,8 @@ func (secondSystem *SecondSystem) populateEnergy() {
 			valueBytes[2] = grabValue[1]
 			valueBytes[3] = grabValue[0]
 			secondSystem.System.Status = true
-			secondSystem.System.SigmaQ = float64(float32(convert.ToLong(valueBytes)) * block.factor)
+			secondSystem.System.SetSupported(models.FieldSigmaQ)
+			secondSystem.System.SigmaQ = float64(convert.ToLong(valueBytes)) / block.divisor
 			return
 		}
 	}
@@ -73,7 +75,8 @@ func (secondSystem *SecondSystem) populateV1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
-		secondSystem.System.V1 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		secondSystem.System.SetSupported(models.FieldV1)
+		secondSystem.System.V1 = float64(convert.ToLong(valueBytes)) / 1000
 	}
 }
 
@@ -87,7 +90,8 @@ func (secondSystem *SecondSystem) populateV2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
-		secondSystem.System.V2 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		secondSystem.System.SetSupported(models.FieldV2)
+		secondSystem.System.V2 = float64(convert.ToLong(valueBytes)) / 1000
 	}
 }
 
@@ -101,7 +105,8 @@ func (secondSystem *SecondSystem) populateM1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
-		secondSystem.System.M1 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		secondSystem.System.SetSupported(models.FieldM1)
+		secondSystem.System.M1 = float64(convert.ToLong(valueBytes)) / 1000
 	}
 }
 
@@ -115,7 +120,8 @@ func (secondSystem *SecondSystem) populateM2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
-		secondSystem.System.M2 = float64(float32(convert.ToLong(valueBytes)) * 0.001)
+		secondSystem.System.SetSupported(models.FieldM2)
+		secondSystem.System.M2 = float64(convert.ToLong(valueBytes)) / 1000
 	}
 }
 
@@ -129,6 +135,7 @@ func (secondSystem *SecondSystem) populateGV1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldGV1)
 		secondSystem.System.GV1 = convert.ToFloat(valueBytes)
 	}
 }
@@ -143,6 +150,7 @@ func (secondSystem *SecondSystem) populateGV2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldGV2)
 		secondSystem.System.GV2 = convert.ToFloat(valueBytes)
 	}
 }
@@ -157,6 +165,7 @@ func (secondSystem *SecondSystem) populateGM1() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldGM1)
 		secondSystem.System.GM1 = convert.ToFloat(valueBytes)
 	}
 }
@@ -171,6 +180,7 @@ func (secondSystem *SecondSystem) populateGM2() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldGM2)
 		secondSystem.System.GM2 = convert.ToFloat(valueBytes)
 	}
 }
@@ -182,6 +192,7 @@ func (secondSystem *SecondSystem) populateT1() {
 	if cap(valueBytes) == len(grabValue) {
 		valueBytes[0], valueBytes[1] = grabValue[1], grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldT1)
 		secondSystem.System.T1 = float32(convert.ToWord(valueBytes)) * 0.01
 	}
 }
@@ -193,6 +204,7 @@ func (secondSystem *SecondSystem) populateT2() {
 	if cap(valueBytes) == len(grabValue) {
 		valueBytes[0], valueBytes[1] = grabValue[1], grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldT2)
 		secondSystem.System.T2 = float32(convert.ToWord(valueBytes)) * 0.01
 	}
 }
@@ -207,6 +219,7 @@ func (secondSystem *SecondSystem) populateP1() {
 		valueBytes[2] = grabValue[0]
 		valueToConvert := [4]byte{0x00, grabValue[2], grabValue[1], grabValue[0]}
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldP1)
 		secondSystem.System.P1 = float32(convert.ToLong(valueToConvert)) * 0.0001
 	}
 }
@@ -221,6 +234,7 @@ func (secondSystem *SecondSystem) populateP2() {
 		valueBytes[2] = grabValue[0]
 		valueToConvert := [4]byte{0x00, grabValue[2], grabValue[1], grabValue[0]}
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldP2)
 		secondSystem.System.P2 = float32(convert.ToLong(valueToConvert)) * 0.0001
 	}
 }
@@ -235,6 +249,7 @@ func (secondSystem *SecondSystem) populateTime() {
 		valueBytes[2] = grabValue[1]
 		valueBytes[3] = grabValue[0]
 		secondSystem.System.Status = true
+		secondSystem.System.SetSupported(models.FieldTimeRunSys)
 		secondSystem.System.TimeRunSys = convert.ToLong(valueBytes)
 	}
 }
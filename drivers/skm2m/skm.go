@@ -2,6 +2,7 @@ package skm2m
 
 import (
 	"encoding/hex"
+	"qBox/drivers"
 	"qBox/drivers/skm2/data"
 	"qBox/models"
 	"qBox/services/convert"
@@ -30,6 +31,7 @@ func (skm *SKM) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	skm.network = network
 	skm.counterNumber = counterNumber
 	skm.checks = data.Checks{Logger: logger}
+	skm.data.Model = "СКМ-2М"
 
 	// Согласно переписке с производителем СКМ-2 счётчиков.
 	// ПО верхнего уровня для преобразования использует коэффициент:
@@ -49,6 +51,20 @@ func (skm *SKM) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	return err
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// СКМ-2М передаёт текущие данные двумя кадрами: первый заканчивается записью DIF 1Fh. Код производителя СКМ-2 драйверу
+// не известен, поэтому прибором СКМ считается прибор M-Bus, код производителя которого отличается от SKU-02 (AXI).
+func (skm *SKM) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	identity, err := drivers.ProbeMBus(counterNumber, network, logger, 0x7B)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации M-Bus. " + err.Error()
+	}
+	if identity.Manufacturer == drivers.MBusManufacturerSKU || !identity.MoreData {
+		return false, identity.String()
+	}
+	return true, identity.String()
+}
+
 /*
 *
 Чтение текущих данных для СКМ-2 согласно протоколу M-bus EN 60870-5
@@ -88,19 +104,26 @@ func (skm *SKM) Read() (*models.DataDevice, error) {
 	}
 
 	skm.data.Serial = hex.EncodeToString([]byte{response1[10], response1[9], response1[8], response1[7]})
+	skm.data.MeterStatus = drivers.MBusStatus(response1[drivers.MBusStatusIndex])
+	drivers.ParseMBusIdentity(response1).Populate(&skm.data)
 
 	skm.PopulateFromBytes(response1, response2)
 
 	return &skm.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (skm *SKM) Protocol() models.ProtocolEnum {
+	return models.ProtocolMbus
+}
+
 func (skm *SKM) PopulateFromBytes(b1 []byte, b2 []byte) {
 	skm.data.Time = time.Date(2000+int(convert.ByteFromBDC(b1[24])), time.Month(int(convert.ByteFromBDC(b1[23]))), int(convert.ByteFromBDC(b1[22])), int(convert.ByteFromBDC(b1[21])), int(convert.ByteFromBDC(b1[20])), int(convert.ByteFromBDC(b1[19])), 0, time.Local)
 	tt1 := b2[177]
 	tt2 := b2[177+1]
 	tt3 := b2[177+2]
 	tt4 := b2[177+3]
-	skm.logger.Debug("%s,%s,%s,%s", tt1, tt2, tt3, tt4)
+	skm.logger.Debug("%d,%d,%d,%d", tt1, tt2, tt3, tt4)
 	skm.data.TimeOn = convert.LongLittleEndianByPointer(b2, 177)
 
 	skm.data.AddNewSystem(0)
@@ -108,6 +131,9 @@ func (skm *SKM) PopulateFromBytes(b1 []byte, b2 []byte) {
 
 	if convert.LongLittleEndianByPointer(b2, 181) > 0 {
 		skm.data.Systems[0].Status = true
+		skm.data.Systems[0].SetSupported(models.FieldTimeRunSys | models.FieldQ1 | models.FieldV1 | models.FieldV2 |
+			models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2 | models.FieldGV1 | models.FieldGV2 |
+			models.FieldT1 | models.FieldT2 | models.FieldT3 | models.FieldP1 | models.FieldP2 | models.FieldP3)
 		skm.data.Systems[0].TimeRunSys = convert.LongLittleEndianByPointer(b2, 181)
 		skm.data.Systems[0].Q1 = float64(convert.LongLongLittleEndianByPointer(b1, 25)&0x0001FFFFFFFFFFFF) / 4.1868 * 1.163 / 1000000
 		skm.data.Systems[0].T1 = convert.FloatLittleEndianByPointer(b2, 19)
@@ -129,6 +155,9 @@ func (skm *SKM) PopulateFromBytes(b1 []byte, b2 []byte) {
 
 	if convert.LongLittleEndianByPointer(b2, 185) > 0 {
 		skm.data.Systems[1].Status = true
+		skm.data.Systems[1].SetSupported(models.FieldTimeRunSys | models.FieldQ1 | models.FieldV1 | models.FieldV2 |
+			models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2 | models.FieldGV1 | models.FieldGV2 |
+			models.FieldT1 | models.FieldT2 | models.FieldT3 | models.FieldP1 | models.FieldP2 | models.FieldP3)
 		skm.data.Systems[1].TimeRunSys = convert.LongLittleEndianByPointer(b2, 185)
 		skm.data.Systems[1].T1 = convert.FloatLittleEndianByPointer(b2, 27)
 		skm.data.Systems[1].T2 = convert.FloatLittleEndianByPointer(b2, 31)
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"errors"
+	"fmt"
 	"qBox/models"
 	"qBox/services/log"
 	"qBox/services/net"
@@ -27,6 +28,8 @@ type SKU02 struct {
 func (sku *SKU02) Init(counterNumber byte, network *net.Network, logger *log.LoggerService) error {
 	sku.logger = logger
 	sku.network = network
+	sku.data.Model = "SKU-02"
+	sku.data.Manufacturer = MBusManufacturerSKU
 	//Система всегда одна
 	sku.data.AddNewSystem(1)
 	sku.data.Systems[0].Status = true
@@ -45,24 +48,58 @@ func (sku *SKU02) Read() (*models.DataDevice, error) {
 	}
 	sku.populate(response)
 
-	sku.logger.Info("Запрос на чтение даты времени")
 	sku.data.TimeRequest = time.Now()
+	sku.data.Time, err = sku.ReadTime()
+	for err != nil {
+		return &sku.data, err
+	}
+
+	return &sku.data, nil
+}
+
+// Реализация интерфейса IDetectDriver::Detect
+// SKU-02 не использует M-Bus: прибор распознаётся по ответу на запрос времени (28h), в 4 и 5 байтах которого
+// возвращается тип прибора 0002h.
+func (sku *SKU02) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	sku.logger = logger
+	logger.Info("Запрос идентификации прибора SKU-02")
+	request := net.PrepareRequest(createRequest(0x28))
+	request.Attempts = 0
+	request.ControlFunction = sku.checkFrame
+	response, err := network.RunIO(request)
+	for err != nil {
+		return false, "нет ответа на запрос времени SKU-02. " + err.Error()
+	}
+	if len(response) < 6 || response[4] != 0x00 || response[5] != 0x02 {
+		return false, fmt.Sprintf("тип прибора - %X", response[4:6])
+	}
+	return true, "прибор ответил типом SKU-02"
+}
+
+// Реализация интерфейса IProtocolDriver::Protocol
+func (sku *SKU02) Protocol() models.ProtocolEnum {
+	return models.ProtocolSku02
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (sku *SKU02) ReadTime() (time.Time, error) {
+	sku.logger.Info("Запрос на чтение даты времени")
 	/**
 	При каждом запросе в шапке данных(с 25 по 28 байт) содержится текущее время счётчика, но структура не содержит минуты.
 	Отсутствие минут, секунд критично. Поэтому делается отдельный запрос с командой 0x28. В ответе содержится
 	текущее время счётчика с минутами и секундами.
 	*/
-	request = net.PrepareRequest(createRequest(0x28))
+	request := net.PrepareRequest(createRequest(0x28))
 	request.ControlFunction = sku.checkFrame
-	response, err = sku.network.RunIO(request)
+	response, err := sku.network.RunIO(request)
 	for err != nil {
-		return &sku.data, err
+		return time.Time{}, err
 	}
 
-	if len(response) < 36 {
-		return &sku.data, errors.New("получены некорректные данные")
+	if len(response) < 37 {
+		return time.Time{}, errors.New("получены некорректные данные")
 	}
-	sku.data.Time = time.Date(
+	return time.Date(
 		int(response[30])*0x100+int(response[31]),
 		time.Month(response[32]),
 		int(response[33]),
@@ -70,9 +107,19 @@ func (sku *SKU02) Read() (*models.DataDevice, error) {
 		int(response[35]),
 		int(response[36]),
 		0,
-		time.Local)
+		time.Local), nil
+}
 
-	return &sku.data, nil
+// Реализация интерфейса IClockDriver::WriteTime
+// Протокол SKU-02, по которому написан драйвер, содержит только команды чтения (20h - текущие данные, 28h - время),
+// команды установки часов в нём нет.
+func (sku *SKU02) WriteTime(time.Time) error {
+	return models.ErrClockReadOnly
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (sku *SKU02) TimeResolution() time.Duration {
+	return time.Second
 }
 
 /**
@@ -118,6 +165,8 @@ func (sku *SKU02) checkFrame(response []byte) bool {
 func (sku *SKU02) populate(datum []byte) {
 
 	sku.data.Serial = strconv.FormatUint(uint64(ToLong([4]byte{datum[21], datum[22], datum[23], datum[24]})), 10)
+	// В 6-9 байтах шапки лежит версия прибора (float)
+	sku.data.Firmware = strconv.FormatFloat(float64(calculateFloatByPointer(datum, 6)), 'f', -1, 32)
 	sku.data.TimeOn = calculateLongByPointer(datum, 46)
 
 	/*
@@ -152,9 +201,13 @@ func (sku *SKU02) populate(datum []byte) {
 		100.0, 100.0, 100.0, 100.0,
 		100.0, 100.0, 100.0, 100.0}[int(datum[18]&0xF0)>>4]
 
-	sku.data.Systems[0].SigmaQ = float64(float32(calculateLongByPointer(datum, 50)))
-	sku.data.Systems[0].Q1 = float64(float32(calculateLongByPointer(datum, 30)))
-	sku.data.Systems[0].Q2 = float64(float32(calculateLongByPointer(datum, 34)))
+	sku.data.Systems[0].ResetSupported()
+	sku.data.Systems[0].SetSupported(models.FieldSigmaQ | models.FieldQ1 | models.FieldQ2 | models.FieldT1 | models.FieldT2 |
+		models.FieldT3 | models.FieldP1 | models.FieldP2)
+
+	sku.data.Systems[0].SigmaQ = float64(calculateLongByPointer(datum, 50))
+	sku.data.Systems[0].Q1 = float64(calculateLongByPointer(datum, 30))
+	sku.data.Systems[0].Q2 = float64(calculateLongByPointer(datum, 34))
 
 	switch int(datum[18] & 0x0F) {
 	case 0:
@@ -176,23 +229,26 @@ func (sku *SKU02) populate(datum []byte) {
 
 	switch int(datum[20] & 0x0F) {
 	case 0:
-		sku.data.Systems[0].V1 = float64(float32(calculateLongByPointer(datum, 38)) * 0.01 * dimension)
-		sku.data.Systems[0].V2 = float64(float32(calculateLongByPointer(datum, 42)) * 0.01 * dimension)
+		sku.data.Systems[0].V1 = float64(calculateLongByPointer(datum, 38)) * float64(dimension) / 100
+		sku.data.Systems[0].V2 = float64(calculateLongByPointer(datum, 42)) * float64(dimension) / 100
 		sku.data.Systems[0].GV1 = calculateFloatByPointer(datum, 70)
 		sku.data.Systems[0].GV2 = calculateFloatByPointer(datum, 74)
+		sku.data.Systems[0].SetSupported(models.FieldV1 | models.FieldV2 | models.FieldGV1 | models.FieldGV2)
 		break
 
 	case 2:
-		sku.data.Systems[0].M1 = float64(float32(calculateLongByPointer(datum, 38)) * 0.01 * dimension)
-		sku.data.Systems[0].M2 = float64(float32(calculateLongByPointer(datum, 42)) * 0.01 * dimension)
+		sku.data.Systems[0].M1 = float64(calculateLongByPointer(datum, 38)) * float64(dimension) / 100
+		sku.data.Systems[0].M2 = float64(calculateLongByPointer(datum, 42)) * float64(dimension) / 100
 		sku.data.Systems[0].GM1 = calculateFloatByPointer(datum, 70)
 		sku.data.Systems[0].GM2 = calculateFloatByPointer(datum, 74)
+		sku.data.Systems[0].SetSupported(models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2)
 		break
 	default:
-		sku.data.Systems[0].M1 = float64(float32(calculateLongByPointer(datum, 38)) * 0.01 * dimension)
-		sku.data.Systems[0].M2 = float64(float32(calculateLongByPointer(datum, 42)) * 0.01 * dimension)
+		sku.data.Systems[0].M1 = float64(calculateLongByPointer(datum, 38)) * float64(dimension) / 100
+		sku.data.Systems[0].M2 = float64(calculateLongByPointer(datum, 42)) * float64(dimension) / 100
 		sku.data.Systems[0].GM1 = calculateFloatByPointer(datum, 70)
 		sku.data.Systems[0].GM2 = calculateFloatByPointer(datum, 74)
+		sku.data.Systems[0].SetSupported(models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2)
 		break
 	}
 
@@ -204,8 +260,8 @@ func (sku *SKU02) populate(datum []byte) {
 	sku.data.Systems[0].P2 = calculateFloatByPointer(datum, 102)
 
 	if int(datum[19]&0x0F) == 0 { // значение передано в килоПаскалях
-		sku.data.Systems[0].P1 *= 0.001
-		sku.data.Systems[0].P2 *= 0.001
+		sku.data.Systems[0].P1 = models.KPa.ToMPa(sku.data.Systems[0].P1)
+		sku.data.Systems[0].P2 = models.KPa.ToMPa(sku.data.Systems[0].P2)
 	}
 }
 
@@ -3,6 +3,7 @@ package drivers
 import (
 	"encoding/hex"
 	"qBox/models"
+	"qBox/services/convert"
 	"qBox/services/log"
 	"qBox/services/net"
 	"time"
@@ -31,9 +32,27 @@ func (sku *SKU02B) Init(counterNumber byte, network *net.Network, logger *log.Lo
 	sku.logger = logger
 	sku.network = network
 	sku.counterNumber = counterNumber
+	sku.data.Model = "SKU-02-B"
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// SKU-02-B отвечает кодом производителя AXI и номером протокола, отличным от SKU-02-K. Драйвер читает данные запросом
+// 5Bh и выбирается, только если прибор не отвечает на запрос 7Bh: иначе подходит SKU-02-B (7b), тип 10.
+func (sku *SKU02B) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	identity, err := ProbeMBus(counterNumber, network, logger, 0x5B)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации M-Bus 5Bh. " + err.Error()
+	}
+	if !identity.isSku02B() {
+		return false, identity.String()
+	}
+	if _, err = ProbeMBus(counterNumber, network, logger, 0x7B); err == nil {
+		return false, identity.String() + ", прибор отвечает и на запрос 7Bh"
+	}
+	return true, identity.String() + ", прибор отвечает только на запрос 5Bh"
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (sku *SKU02B) Read() (*models.DataDevice, error) {
 
@@ -73,10 +92,44 @@ func (sku *SKU02B) Read() (*models.DataDevice, error) {
 	}
 
 	sku.data.Serial = hex.EncodeToString([]byte{response[10], response[9], response[8], response[7]})
+	sku.data.MeterStatus = MBusStatus(response[MBusStatusIndex])
+	ParseMBusIdentity(response).Populate(&sku.data)
 	sku.populate(response[19:])
 	return &sku.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (sku *SKU02B) Protocol() models.ProtocolEnum {
+	return models.ProtocolMbus
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+// Время прибора передаётся только в составе текущих данных
+func (sku *SKU02B) ReadTime() (time.Time, error) {
+	device, err := sku.Read()
+	return device.Time, err
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Запись даты времени телеграммой SND_UD (DIF 04h, VIF 6Dh, тип F). Часы прибора имеют точность до минуты.
+func (sku *SKU02B) WriteTime(t time.Time) error {
+	sku.logger.Info("Запись даты времени на теплосчётчик")
+	userData := append([]byte{0x53, sku.counterNumber, 0x51, 0x04, 0x6D}, convert.EncodeTypeF(t.Round(time.Minute))...)
+	length := byte(len(userData))
+	request := net.PrepareRequest(append(append([]byte{0x68, length, length, 0x68}, userData...),
+		sku.calculateCheckSum(userData), 0x16))
+	request.ControlFunction = sku.checkSimpleFrame
+	request.Attempts = 0
+	_, err := sku.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+// Время типа F передаётся с точностью до минуты
+func (sku *SKU02B) TimeResolution() time.Duration {
+	return time.Minute
+}
+
 func (sku *SKU02B) checkSimpleFrame(response []byte) bool {
 	if len(response) == 0 {
 		sku.logger.Info("Получен пустой ответ.")
@@ -147,8 +200,10 @@ func (sku *SKU02B) populate(datum []byte) {
 	i := 0
 	sku.data.AddNewSystem(1)
 	sku.data.Systems[0].Status = true
+	sku.data.Systems[0].ResetSupported()
 	for {
 		cursor := i
+		var supported models.FieldEnum // поле, значение которого прочитано из записи
 		switch dib := datum[cursor]; dib {
 		case 0x84:
 			if datum[cursor+1] == 0x40 { // Ox84 0x40 - DIF
@@ -157,160 +212,112 @@ func (sku *SKU02B) populate(datum []byte) {
 				case 0xFB: // 0.1 MWh Тепловая энергия
 					if datum[cursor+1] == 0x00 { //0xFB 0x00 - VIF
 						cursor++
-						factor := float32(0.1)
+						divisor := 10.0
 						cursor += 4
 						sku.data.UnitQ = models.MWh
-						sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-							datum[cursor],
-							datum[cursor-1],
-							datum[cursor-2],
-							datum[cursor-3]})) * factor)
+						supported = models.FieldQ1
+						sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 						break
 					}
 					if datum[cursor+1] == 0x08 { //0xFB 0x08 - VIF
 						cursor++
-						factor := float32(0.1)
+						divisor := 10.0
 						cursor += 4
 						sku.data.UnitQ = models.GJ
-						sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-							datum[cursor],
-							datum[cursor-1],
-							datum[cursor-2],
-							datum[cursor-3]})) * factor)
+						supported = models.FieldQ1
+						sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 						break
 					}
 				case 0x0F:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x07:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x0E:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x06:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x05:
-					factor := float32(0.0001)
+					divisor := 10000.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x0D:
-					factor := float32(0.0001)
+					divisor := 10000.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ1
+					sku.data.Systems[0].Q1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x16:
-					factor := float32(1)
+					divisor := 1.0
 					cursor += 4
-					sku.data.Systems[0].V2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldV2
+					sku.data.Systems[0].V2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x96:
 				case 0x1E: // встретилось в одном из приборов
-					factor := float32(1)
+					divisor := 1.0
 					cursor += 4
-					sku.data.Systems[0].M2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldM2
+					sku.data.Systems[0].M2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x15:
-					factor := float32(0.1)
+					divisor := 10.0
 					cursor += 4
-					sku.data.Systems[0].V2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldV2
+					sku.data.Systems[0].V2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x95:
-					factor := float32(0.1)
+					divisor := 10.0
 					cursor += 4
-					sku.data.Systems[0].M2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldM2
+					sku.data.Systems[0].M2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x14:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
-					sku.data.Systems[0].V2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldV2
+					sku.data.Systems[0].V2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x94:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
-					sku.data.Systems[0].M2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldM2
+					sku.data.Systems[0].M2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x13:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
-					sku.data.Systems[0].V2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldV2
+					sku.data.Systems[0].V2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x93:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
-					sku.data.Systems[0].M2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldM2
+					sku.data.Systems[0].M2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				default:
 					cursor -= 2 // VIB не найден, возврат курсора
@@ -322,87 +329,63 @@ func (sku *SKU02B) populate(datum []byte) {
 				case 0xFB:
 					if datum[cursor+1] == 0x00 { //0xFB 0x00 - VIF
 						cursor += 1
-						factor := float32(0.1)
+						divisor := 10.0
 						cursor += 4
 						sku.data.UnitQ = models.MWh
-						sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-							datum[cursor],
-							datum[cursor-1],
-							datum[cursor-2],
-							datum[cursor-3]})) * factor)
+						supported = models.FieldQ2
+						sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 						break
 					}
 					if datum[cursor+1] == 0x08 { //0xFB 0x08 - VIF
 						cursor += 1
-						factor := float32(0.1)
+						divisor := 10.0
 						cursor += 4
 						sku.data.UnitQ = models.GJ
-						sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-							datum[cursor],
-							datum[cursor-1],
-							datum[cursor-2],
-							datum[cursor-3]})) * factor)
+						supported = models.FieldQ2
+						sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 						break
 					}
 				case 0x0F:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x07:
-					factor := float32(0.01)
+					divisor := 100.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x0E:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x06:
-					factor := float32(0.001)
+					divisor := 1000.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x05:
-					factor := float32(0.0001)
+					divisor := 10000.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				case 0x0D:
-					factor := float32(0.0001)
+					divisor := 10000.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldQ2
+					sku.data.Systems[0].Q2 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				default:
 					cursor -= 3 // VIB не найден, возврат курсора
@@ -415,160 +398,112 @@ func (sku *SKU02B) populate(datum []byte) {
 			case 0xFB:
 				if datum[cursor+1] == 0x00 { //0xFB 0x00 - VIF
 					cursor += 1
-					factor := float32(0.1)
+					divisor := 10.0
 					cursor += 4
 					sku.data.UnitQ = models.MWh
-					sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldSigmaQ
+					sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				}
 				if datum[cursor+1] == 0x08 { //0xFB 0x08 - VIF
 					cursor += 1
-					factor := float32(0.1)
+					divisor := 10.0
 					cursor += 4
 					sku.data.UnitQ = models.GJ
-					sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]})) * factor)
+					supported = models.FieldSigmaQ
+					sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 					break
 				}
 			case 0x0F:
-				factor := float32(0.01)
+				divisor := 100.0
 				cursor += 4
 				sku.data.UnitQ = models.GJ
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x07:
-				factor := float32(0.01)
+				divisor := 100.0
 				cursor += 4
 				sku.data.UnitQ = models.MWh
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x0E:
-				factor := float32(0.001)
+				divisor := 1000.0
 				cursor += 4
 				sku.data.UnitQ = models.GJ
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x06:
-				factor := float32(0.001)
+				divisor := 1000.0
 				cursor += 4
 				sku.data.UnitQ = models.MWh
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x05:
-				factor := float32(0.0001)
+				divisor := 10000.0
 				cursor += 4
 				sku.data.UnitQ = models.MWh
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x0D:
-				factor := float32(0.0001)
+				divisor := 10000.0
 				cursor += 4
 				sku.data.UnitQ = models.GJ
-				sku.data.Systems[0].SigmaQ = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldSigmaQ
+				sku.data.Systems[0].SigmaQ = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x16:
-				factor := float32(1)
+				divisor := 1.0
 				cursor += 4
-				sku.data.Systems[0].V1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldV1
+				sku.data.Systems[0].V1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x96:
 			case 0x1E: // встретилось в одном из приборов
-				factor := float32(1)
+				divisor := 1.0
 				cursor += 4
-				sku.data.Systems[0].M1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldM1
+				sku.data.Systems[0].M1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x15:
-				factor := float32(0.1)
+				divisor := 10.0
 				cursor += 4
-				sku.data.Systems[0].V1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldV1
+				sku.data.Systems[0].V1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x95:
-				factor := float32(0.1)
+				divisor := 10.0
 				cursor += 4
-				sku.data.Systems[0].M1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldM1
+				sku.data.Systems[0].M1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x14:
-				factor := float32(0.01)
+				divisor := 100.0
 				cursor += 4
-				sku.data.Systems[0].V1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldV1
+				sku.data.Systems[0].V1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x94:
-				factor := float32(0.01)
+				divisor := 100.0
 				cursor += 4
-				sku.data.Systems[0].M1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldM1
+				sku.data.Systems[0].M1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x13:
-				factor := float32(0.001)
+				divisor := 1000.0
 				cursor += 4
-				sku.data.Systems[0].V1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldV1
+				sku.data.Systems[0].V1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x93:
-				factor := float32(0.001)
+				divisor := 1000.0
 				cursor += 4
-				sku.data.Systems[0].M1 = float64(float32(ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})) * factor)
+				supported = models.FieldM1
+				sku.data.Systems[0].M1 = float64(ToLong(reversedBytes(datum, cursor))) / divisor
 				break
 			case 0x6D:
 				cursor += 4
@@ -581,19 +516,12 @@ func (sku *SKU02B) populate(datum []byte) {
 				break
 			case 0x20:
 				cursor += 4
-				sku.data.TimeOn = ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})
+				sku.data.TimeOn = ToLong(reversedBytes(datum, cursor))
 				break
 			case 0x24:
 				cursor += 4
-				sku.data.TimeRunCommon = ToLong([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]})
+				sku.data.TimeRunCommon = ToLong(reversedBytes(datum, cursor))
+				supported = models.FieldTimeRunSys
 				sku.data.Systems[0].TimeRunSys = sku.data.TimeRunCommon
 				break
 			default:
@@ -606,20 +534,14 @@ func (sku *SKU02B) populate(datum []byte) {
 			case 0x3E:
 				factor := float32(1.0)
 				cursor += 4
-				sku.data.Systems[0].GV1 = ToFloat([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]}) * factor
+				supported = models.FieldGV1
+				sku.data.Systems[0].GV1 = ToFloat(reversedBytes(datum, cursor)) * factor
 				break
 			case 0x56:
 				factor := float32(1.0)
 				cursor += 4
-				sku.data.Systems[0].GM1 = ToFloat([4]byte{
-					datum[cursor],
-					datum[cursor-1],
-					datum[cursor-2],
-					datum[cursor-3]}) * factor
+				supported = models.FieldGM1
+				sku.data.Systems[0].GM1 = ToFloat(reversedBytes(datum, cursor)) * factor
 				break
 			default:
 				cursor-- // VIB не найден, возврат курсора
@@ -632,20 +554,14 @@ func (sku *SKU02B) populate(datum []byte) {
 				case 0x3E:
 					factor := float32(1.0)
 					cursor += 4
-					sku.data.Systems[0].GV2 = ToFloat([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]}) * factor
+					supported = models.FieldGV2
+					sku.data.Systems[0].GV2 = ToFloat(reversedBytes(datum, cursor)) * factor
 					break
 				case 0x56:
 					factor := float32(1.0)
 					cursor += 4
-					sku.data.Systems[0].GM2 = ToFloat([4]byte{
-						datum[cursor],
-						datum[cursor-1],
-						datum[cursor-2],
-						datum[cursor-3]}) * factor
+					supported = models.FieldGM2
+					sku.data.Systems[0].GM2 = ToFloat(reversedBytes(datum, cursor)) * factor
 					break
 				default:
 					cursor -= 2 // VIB не найден, возврат курсора
@@ -658,11 +574,13 @@ func (sku *SKU02B) populate(datum []byte) {
 			case 0x59:
 				factor := float32(0.01)
 				cursor += 2
+				supported = models.FieldT1
 				sku.data.Systems[0].T1 = float32(toWord([2]byte{datum[cursor], datum[cursor-1]})) * factor
 				break
 			case 0x5D:
 				factor := float32(0.01)
 				cursor += 2
+				supported = models.FieldT2
 				sku.data.Systems[0].T2 = float32(toWord([2]byte{datum[cursor], datum[cursor-1]})) * factor
 				break
 			default:
@@ -676,6 +594,7 @@ func (sku *SKU02B) populate(datum []byte) {
 				case 0x65:
 					factor := float32(0.01)
 					cursor += 2
+					supported = models.FieldT3
 					sku.data.Systems[0].T3 = float32(toWord([2]byte{datum[cursor], datum[cursor-1]})) * factor
 					break
 				case 0x59: // температура 3
@@ -691,6 +610,7 @@ func (sku *SKU02B) populate(datum []byte) {
 			case 0x68:
 				factor := float32(0.0001)
 				cursor += 3
+				supported = models.FieldP1
 				sku.data.Systems[0].P1 = float32(ToLong([4]byte{
 					0x00,
 					datum[cursor],
@@ -708,6 +628,7 @@ func (sku *SKU02B) populate(datum []byte) {
 				case 0x68:
 					factor := float32(0.0001)
 					cursor += 3
+					supported = models.FieldP2
 					sku.data.Systems[0].P2 = float32(ToLong([4]byte{
 						0x00,
 						datum[cursor],
@@ -719,6 +640,7 @@ func (sku *SKU02B) populate(datum []byte) {
 				}
 			}
 		}
+		sku.data.Systems[0].SetSupported(supported)
 
 		if i == cursor {
 			cursor++
@@ -738,3 +660,8 @@ func (sku *SKU02B) populate(datum []byte) {
 		}
 	}
 }
+
+// 4 байта значения записи, заканчивающегося на cursor, от старшего к младшему: M-Bus передаёт младший байт первым
+func reversedBytes(datum []byte, cursor int) [4]byte {
+	return [4]byte{datum[cursor], datum[cursor-1], datum[cursor-2], datum[cursor-3]}
+}
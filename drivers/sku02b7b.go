@@ -21,6 +21,19 @@ func (sku *SKU02B7B) Init(counterNumber byte, network *net.Network, logger *log.
 	return sku.sku.Init(counterNumber, network, logger)
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// SKU-02-B отвечает кодом производителя AXI и номером протокола, отличным от SKU-02-K
+func (sku *SKU02B7B) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	identity, err := ProbeMBus(counterNumber, network, logger, 0x7B)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации M-Bus 7Bh. " + err.Error()
+	}
+	if !identity.isSku02B() {
+		return false, identity.String()
+	}
+	return true, identity.String()
+}
+
 func (sku *SKU02B7B) Read() (*models.DataDevice, error) {
 	sku.sku.logger.Info("Запрос на инициализацию прибора, № %d", sku.sku.counterNumber)
 	request := net.PrepareRequest([]byte{
@@ -58,6 +71,30 @@ func (sku *SKU02B7B) Read() (*models.DataDevice, error) {
 	}
 
 	sku.sku.data.Serial = hex.EncodeToString([]byte{response[10], response[9], response[8], response[7]})
+	sku.sku.data.MeterStatus = MBusStatus(response[MBusStatusIndex])
+	ParseMBusIdentity(response).Populate(&sku.sku.data)
 	sku.sku.populate(response[19:])
 	return &sku.sku.data, nil
-}
\ No newline at end of file
+}
+
+// Реализация интерфейса IProtocolDriver::Protocol
+func (sku *SKU02B7B) Protocol() models.ProtocolEnum {
+	return models.ProtocolMbus
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (sku *SKU02B7B) ReadTime() (time.Time, error) {
+	device, err := sku.Read()
+	return device.Time, err
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+func (sku *SKU02B7B) WriteTime(t time.Time) error {
+	return sku.sku.WriteTime(t)
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+// Время типа F передаётся с точностью до минуты
+func (sku *SKU02B7B) TimeResolution() time.Duration {
+	return time.Minute
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"qBox/drivers/skm2/data"
 	"qBox/models"
+	"qBox/services/convert"
 	"qBox/services/log"
 	"qBox/services/net"
 	"time"
@@ -30,9 +31,26 @@ func (sku *SKU02K) Init(counterNumber byte, network *net.Network, logger *log.Lo
 	sku.logger = logger
 	sku.network = network
 	sku.counterNumber = counterNumber
+	sku.data.Model = "SKU-02-K"
 	return nil
 }
 
+// Номер протокола SKU-02-K в заголовке ответа M-Bus
+const sku02KVersion = 0x06
+
+// Реализация интерфейса IDetectDriver::Detect
+// SKU-02-K отвечает кодом производителя AXI и номером протокола 06.
+func (sku *SKU02K) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	identity, err := ProbeMBus(counterNumber, network, logger, 0x7B)
+	for err != nil {
+		return false, "нет ответа на запрос идентификации M-Bus. " + err.Error()
+	}
+	if identity.Manufacturer != MBusManufacturerSKU || identity.Version != sku02KVersion {
+		return false, identity.String()
+	}
+	return true, identity.String()
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (sku *SKU02K) Read() (*models.DataDevice, error) {
 
@@ -152,6 +170,8 @@ func (sku *SKU02K) Read() (*models.DataDevice, error) {
 
 	sku.data.TimeRequest = time.Now()
 	sku.data.Serial = hex.EncodeToString([]byte{response[10], response[9], response[8], response[7]})
+	sku.data.MeterStatus = MBusStatus(response[MBusStatusIndex])
+	ParseMBusIdentity(response).Populate(&sku.data)
 
 	// У прошивки sku03 нет текущий температур и расходов, только часовые, суточные, месячные
 	sku.logger.Info("Запрос на просмотр суточных")
@@ -202,6 +222,38 @@ func (sku *SKU02K) Read() (*models.DataDevice, error) {
 	return &sku.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (sku *SKU02K) Protocol() models.ProtocolEnum {
+	return models.ProtocolMbus
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+// Время прибора передаётся только в составе текущих данных
+func (sku *SKU02K) ReadTime() (time.Time, error) {
+	device, err := sku.Read()
+	return device.Time, err
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Запись даты времени телеграммой SND_UD (DIF 04h, VIF 6Dh, тип F). Часы прибора имеют точность до минуты.
+func (sku *SKU02K) WriteTime(t time.Time) error {
+	sku.logger.Info("Запись даты времени на теплосчётчик")
+	userData := append([]byte{0x73, sku.counterNumber, 0x51, 0x04, 0x6D}, convert.EncodeTypeF(t.Round(time.Minute))...)
+	length := byte(len(userData))
+	request := net.PrepareRequest(append(append([]byte{0x68, length, length, 0x68}, userData...),
+		sku.calculateCheckSum(userData), 0x16))
+	request.ControlFunction = sku.checkSimpleFrame
+	request.Attempts = 0
+	_, err := sku.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+// Время типа F передаётся с точностью до минуты
+func (sku *SKU02K) TimeResolution() time.Duration {
+	return time.Minute
+}
+
 /**
 Проверка контрольной суммы для SKU-02K
 Представляет собой сумму значений из bytes, урезенную до одного байта
@@ -219,6 +271,7 @@ func (sku *SKU02K) calculateCheckSum(bytes []byte) byte {
 func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 	sku.data.AddNewSystem(1)
 	sku.data.Systems[0].Status = true
+	sku.data.Systems[0].ResetSupported()
 
 	// принят Energy Unit Index 16 bit
 	// cursor = 84
@@ -228,79 +281,82 @@ func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 	grabberForDay := data.Grabber{Datum: datumForDay}
 	result := grabber.GrabValueBytes([]byte{0x01, 0xFF}, 1)
 
-	factor := float32(0.1)
+	divisor := 10.0
 
 	// Q в ГДж или MWh предположение на основе протокола SKU-02K
 	switch result[0] {
 	case 0x0E:
 		sku.logger.Debug("Q в Gj")
 		sku.data.UnitQ = models.GJ
-		factor = float32(0.001)
+		divisor = 1000
 		break
 	case 0x06:
 		sku.logger.Debug("Q в MWh")
 		sku.data.UnitQ = models.MWh // или КВт
-		factor = float32(0.001)
+		divisor = 1000
 		break
 	case 0x05:
 		sku.logger.Debug("Q в MWh")
 		sku.data.UnitQ = models.MWh
-		factor = float32(0.0001)
+		divisor = 10000
 		break
 	case 0x0D:
 		sku.logger.Debug("Q в Gj")
 		sku.data.UnitQ = models.MWh
-		factor = float32(0.0001)
+		divisor = 10000
 		break
 	case 0x07:
 		sku.logger.Debug("Q в MWh")
 		sku.data.UnitQ = models.MWh
-		factor = float32(0.01)
+		divisor = 100
 		break
 	case 0x0F:
 		sku.logger.Debug("Q в Gj")
 		sku.data.UnitQ = models.MWh
-		factor = float32(0.01)
+		divisor = 100
 		break
 	default:
 		sku.logger.Debug("Q в Gj")
 		sku.data.UnitQ = models.GJ
-		factor = float32(0.001)
+		divisor = 1000
 		break
 	}
 
 	result = grabber.GrabValueBytes([]byte{0x04, 0x86, 0x3B}, 4)
 	if 4 == len(result) {
 		// Для прошивки как SKU-04
-		sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
+		sku.data.Systems[0].SetSupported(models.FieldQ1 | models.FieldSigmaQ)
+		sku.data.Systems[0].Q1 = float64(ToLong([4]byte{
 			result[3],
 			result[2],
 			result[1],
-			result[0]})) * factor)
+			result[0]})) / divisor
 		sku.data.Systems[0].SigmaQ = sku.data.Systems[0].Q1
 	} else {
 		result = grabber.GrabValueBytes([]byte{0x04, 0x8E, 0x3B}, 4)
 		if 4 == len(result) {
 			// Для прошивки как QALCOSONIC HEAT1 SKU-03
-			sku.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{
+			sku.data.Systems[0].SetSupported(models.FieldQ1 | models.FieldSigmaQ)
+			sku.data.Systems[0].Q1 = float64(ToLong([4]byte{
 				result[3],
 				result[2],
 				result[1],
-				result[0]})) * factor)
+				result[0]})) / divisor
 			sku.data.Systems[0].SigmaQ = sku.data.Systems[0].Q1
 		} else {
 			sku.logger.Info("Не найдены байты для Q1")
 		}
 	}
 
-	// factor = float32(0.001)
+	// divisor = 1000
 	result = grabber.GrabValueBytes([]byte{0x04, 0x13}, 4)
 	if 4 == len(result) {
-		sku.data.Systems[0].V1 = float64(float32(ToLong([4]byte{
+		sku.data.Systems[0].SetSupported(models.FieldV1)
+		sku.data.Systems[0].V1 = float64(ToLong([4]byte{
 			result[3],
 			result[2],
 			result[1],
-			result[0]})) * factor)
+			result[0]})) / divisor
 	} else {
 		sku.logger.Info("Не найдены байты для V1")
 	}
@@ -338,29 +394,32 @@ func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 			result[2],
 			result[1],
 			result[0]})
+		sku.data.Systems[0].SetSupported(models.FieldTimeRunSys)
 		sku.data.Systems[0].TimeRunSys = sku.data.TimeRunCommon
 	} else {
 		sku.logger.Info("Не найдены байты для TimeRunCommon")
 	}
 
 	// G
-	factor = float32(1.0)
+	factorG := float32(1.0)
 	result = grabber.GrabValueBytes([]byte{0x05, 0x3E}, 4)
 	if 4 == len(result) {
+		sku.data.Systems[0].SetSupported(models.FieldGV1)
 		sku.data.Systems[0].GV1 = ToFloat([4]byte{
 			result[3],
 			result[2],
 			result[1],
-			result[0]}) * factor
+			result[0]}) * factorG
 	} else {
 		// Данные могут лежать в суточных
 		result = grabberForDay.GrabValueBytes([]byte{0x85, 0x08, 0x3E}, 4)
 		if 4 == len(result) {
+			sku.data.Systems[0].SetSupported(models.FieldGV1)
 			sku.data.Systems[0].GV1 = ToFloat([4]byte{
 				result[3],
 				result[2],
 				result[1],
-				result[0]}) * factor
+				result[0]}) * factorG
 		} else {
 			sku.logger.Info("Не найдены байты для GV1")
 		}
@@ -371,6 +430,7 @@ func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 	// T1
 	result = grabber.GrabValueBytes([]byte{0x05, 0x5B}, 4)
 	if 4 == len(result) {
+		sku.data.Systems[0].SetSupported(models.FieldT1)
 		sku.data.Systems[0].T1 = ToFloat([4]byte{
 			result[3],
 			result[2],
@@ -380,20 +440,21 @@ func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 		// Данные могут лежать в суточных
 		result = grabberForDay.GrabValueBytes([]byte{0x85, 0x08, 0x5B}, 4)
 		if 4 == len(result) {
+			sku.data.Systems[0].SetSupported(models.FieldT1)
 			sku.data.Systems[0].T1 = ToFloat([4]byte{
 				result[3],
 				result[2],
 				result[1],
-				result[0]}) * factor
+				result[0]}) * factorG
 		} else {
 			sku.logger.Info("Не найдены байты для T1")
 		}
 	}
 
 	// T2
-	factor = float32(1.0)
 	result = grabber.GrabValueBytes([]byte{0x05, 0x5F}, 4)
 	if 4 == len(result) {
+		sku.data.Systems[0].SetSupported(models.FieldT2)
 		sku.data.Systems[0].T2 = ToFloat([4]byte{
 			result[3],
 			result[2],
@@ -403,11 +464,12 @@ func (sku *SKU02K) applyResponse(datum []byte, datumForDay []byte) {
 		// Данные могут лежать в суточных
 		result = grabberForDay.GrabValueBytes([]byte{0x85, 0x08, 0x5F}, 4)
 		if 4 == len(result) {
+			sku.data.Systems[0].SetSupported(models.FieldT2)
 			sku.data.Systems[0].T2 = ToFloat([4]byte{
 				result[3],
 				result[2],
 				result[1],
-				result[0]}) * factor
+				result[0]}) * factorG
 		} else {
 			sku.logger.Info("Не найдены байты для T2")
 		}
@@ -0,0 +1,67 @@
+package drivers
+
+import "qBox/models"
+
+/**
+Расшифровка байта состояния (Status) из заголовка длинного кадра M-Bus, ответ RSP_UD:
+68h L L 68h C A CI ID[4] Man[2] Ver Med Acc Status Sig[2], т.е. байт с индексом 16 от начала кадра.
+Биты 0-1 - ошибка приложения (01 занят, 10 ошибка, 11 - нештатная ситуация), бит 2 - низкий заряд батареи,
+бит 3 - постоянная ошибка, бит 4 - временная ошибка, биты 5-7 - определяются производителем и не расшифровываются.
+*/
+func MBusStatus(status byte) *models.MeterStatus {
+	result := models.MeterStatus{Code: uint32(status)}
+	if status&0x1B != 0 {
+		result.Flags |= models.StatusError
+	}
+	if status&0x04 != 0 {
+		result.Flags |= models.StatusLowBattery
+	}
+	return &result
+}
+
+// Индекс байта состояния в длинном кадре M-Bus
+const MBusStatusIndex = 16
+
+/**
+Расшифровка ошибок по системе приборов ТЭМ-104М за текущий час. Протокол ТЭМ-104М, раздел 5.1.5: в карте интеграторов
+tekerr C[4] (0110h, по байту на систему) и teherr I[4] (0114h, по 2 байта на систему, little-endian); раздел 5.3: те же
+tekerr (70h) и teherr (71h) в структуре SysPar системы в оперативной памяти. Биты расшифрованы в разделе 5.7.7:
+tekerr: биты 0-2 - G1-G3 < min, биты 3-5 - G1-G3 > max, биты 6-7 - dt1, dt2 < min.
+teherr: биты 0-2 - тех. неисправность каналов расхода, биты 3-5 - каналов температуры, биты 6-8 - каналов давления,
+биты 9-11 - отсутствие теплоносителя, биты 12-13 - ошибка возбуждения, бит 15 - выключение питания.
+В коде состояния tekerr занимает младший байт, teherr - следующие два.
+*/
+func TemErrors(tekerr byte, teherr uint16) *models.MeterStatus {
+	result := models.MeterStatus{Code: uint32(teherr)<<8 | uint32(tekerr)}
+	if tekerr&0x07 != 0 {
+		result.Flags |= models.StatusGMin
+	}
+	if tekerr&0x38 != 0 {
+		result.Flags |= models.StatusGMax
+	}
+	if tekerr&0xC0 != 0 {
+		result.Flags |= models.StatusDeltaTMin
+	}
+	if teherr&0x01F8 != 0 {
+		result.Flags |= models.StatusSensorBreak
+	}
+	if teherr&0x3E07 != 0 {
+		result.Flags |= models.StatusError
+	}
+	if teherr&0x8000 != 0 {
+		result.Flags |= models.StatusPowerFailure
+	}
+	return &result
+}
+
+// Смещения ошибок по системам в карте интеграторов ТЭМ-104М (раздел 5.1.5), массивы индексируются номером системы
+const (
+	TemTekErrAddress = 0x110
+	TemTehErrAddress = 0x114
+)
+
+// Смещения ошибок в структуре SysPar системы (раздел 5.3 протокола ТЭМ-104М)
+const (
+	TemSysParTekErrAddress = 0x70
+	TemSysParTehErrAddress = 0x71
+)
@@ -14,6 +14,7 @@ type TEM05OLD struct {
 	data    models.DataDevice
 	network *net.Network
 	logger  *log.LoggerService
+	events  []models.Event
 }
 
 // Реализация интерфейса IDeviceDriver::Init
@@ -21,8 +22,12 @@ func (tem05 *TEM05OLD) Init(counterNumber byte, network *net.Network, logger *lo
 	tem05.logger = logger
 	tem05.network = network
 	tem05.data.UnitQ = models.MWh // в других не измеряет
+	tem05.data.Model = "ТЭМ-05М"
+	tem05.data.Manufacturer = TemManufacturer
 	tem05.data.AddNewSystem(0)
 	tem05.data.Systems[0].Status = true
+	tem05.data.Systems[0].SetSupported(models.FieldQ1 | models.FieldQ2 | models.FieldV1 | models.FieldV2 | models.FieldM1 |
+		models.FieldM2 | models.FieldGV1 | models.FieldGV2 | models.FieldT1 | models.FieldT2 | models.FieldT3)
 	return nil
 }
 
@@ -36,6 +41,7 @@ func (tem05 *TEM05OLD) Read() (*models.DataDevice, error) {
 	tem05.logger.Info("Чтение текущих")
 	request := net.PrepareRequest([]byte{0x33, 0x81, 0x7e, 0x32})
 	request.SecondsReadTimeout = 8
+	request.ControlFunction = tem05.checkResponse
 	response, err := tem05.network.RunIO(request)
 	for err != nil {
 		return &tem05.data, err
@@ -47,19 +53,46 @@ func (tem05 *TEM05OLD) Read() (*models.DataDevice, error) {
 	return &tem05.data, nil
 }
 
+// Реализация интерфейса IClockDriver::ReadTime
+// Время прибора передаётся только в составе текущих данных
+func (tem05 *TEM05OLD) ReadTime() (time.Time, error) {
+	device, err := tem05.Read()
+	return device.Time, err
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Прибор отвечает только на запрос интерфейсного адаптера (33h 81h 7Eh 32h) блоком текущих данных, других команд,
+// в том числе записи, у него нет.
+func (tem05 *TEM05OLD) WriteTime(time.Time) error {
+	return models.ErrClockReadOnly
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+// Секунды в блоке текущих данных не передаются
+func (tem05 *TEM05OLD) TimeResolution() time.Duration {
+	return time.Minute
+}
+
+// Реализация интерфейса IEventDriver::ReadEvents
+// Прибор не ведёт журнал событий, передаются только счётчики ошибок, полученные при чтении текущих данных.
+func (tem05 *TEM05OLD) ReadEvents() ([]models.Event, error) {
+	return tem05.events, nil
+}
+
 func (tem05 *TEM05OLD) populate(response []byte) {
 
 	//=======================================ВРЕМЯ======================================================================
 	year := 2000 + int(ByteFromBDC(response[9]))
 	month := time.Month(int(ByteFromBDC(response[8])))
 	day := int(ByteFromBDC(response[7]))
-	hour := int(ByteFromBDC(response[5])<<8 + ByteFromBDC(response[4]))
-	min := int(ByteFromBDC(response[3])<<8 + ByteFromBDC(response[2]))
+	hour := int(ByteFromBDC(response[4]))
+	min := int(ByteFromBDC(response[2]))
 	tem05.data.Time = time.Date(year, month, day, hour, min, 0, 0, time.Local)
 	//=====================================НОМЕР ПРИБОРА================================================================
 	tem05.data.Serial = strconv.Itoa(int(toWord([2]byte{response[15], response[14]})))
 	tem05.logger.Debug("Серийный номер прибора: " + tem05.data.Serial) //OK
-	tem05.logger.Debug("Версия ПО:" + strconv.Itoa(int(response[16]))) //OK
+	tem05.data.Firmware = strconv.Itoa(int(response[16]))
+	tem05.logger.Debug("Версия ПО:" + tem05.data.Firmware) //OK
 	tem05.logger.Debug("Схема установки:" + strconv.Itoa(int(response[17])))
 	//==========================НАРАБОТКА===============================================================================
 	tem05.logger.Debug("Минуты наработки:" + strconv.Itoa(int(response[24])))
@@ -101,25 +134,25 @@ func (tem05 *TEM05OLD) populate(response []byte) {
 	tem05.data.Systems[0].GV1 = float32(ToLong([4]byte{0, response[34], response[33], response[32]})) / (factor1 * 100)
 	tem05.logger.Debug("Расход по 1 каналу м3/ч (as float32): %4f", tem05.data.Systems[0].GV1)
 
-	tem05.data.Systems[0].Q1 = float64(float32(ToLong([4]byte{0, response[40], response[39], response[38]})) / (factor1 * 10))
+	tem05.data.Systems[0].Q1 = float64(ToLong([4]byte{0, response[40], response[39], response[38]})) / (float64(factor1) * 10)
 	tem05.logger.Debug("Энергия по 1 каналу, МВт*ч :%f", tem05.data.Systems[0].Q1)
 
-	tem05.data.Systems[0].V1 = float64(float32(ToLong([4]byte{0, response[43], response[42], response[41]})) / factor1)
+	tem05.data.Systems[0].V1 = float64(ToLong([4]byte{0, response[43], response[42], response[41]})) / float64(factor1)
 	tem05.logger.Debug("Объем по 1 каналу, м3 :%f", tem05.data.Systems[0].V1)
 
-	tem05.data.Systems[0].M1 = float64(float32(ToLong([4]byte{0, response[46], response[45], response[44]})) / factor1)
+	tem05.data.Systems[0].M1 = float64(ToLong([4]byte{0, response[46], response[45], response[44]})) / float64(factor1)
 	tem05.logger.Debug("Масса по 1 каналу, т  :%f", tem05.data.Systems[0].M1)
 	//============================ 2-й канал ===========================================================================
 	tem05.data.Systems[0].GV2 = float32(ToLong([4]byte{0, response[49], response[48], response[47]})) / (factor2 * 100)
 	tem05.logger.Debug("Расход по 2 каналу м3/ч (as float32): %4f", tem05.data.Systems[0].GV2)
 
-	tem05.data.Systems[0].Q2 = float64(float32(ToLong([4]byte{0, response[55], response[54], response[53]})) / (factor2 * 10))
+	tem05.data.Systems[0].Q2 = float64(ToLong([4]byte{0, response[55], response[54], response[53]})) / (float64(factor2) * 10)
 	tem05.logger.Debug("Энергия по 2 каналу, МВт*ч :%f", tem05.data.Systems[0].Q2)
 
-	tem05.data.Systems[0].V2 = float64(float32(ToLong([4]byte{0, response[58], response[57], response[56]})) / factor2)
+	tem05.data.Systems[0].V2 = float64(ToLong([4]byte{0, response[58], response[57], response[56]})) / float64(factor2)
 	tem05.logger.Debug("Объем по 2 каналу, м3 :%f", tem05.data.Systems[0].V2)
 
-	tem05.data.Systems[0].M2 = float64(float32(ToLong([4]byte{0, response[61], response[60], response[59]})) / factor2)
+	tem05.data.Systems[0].M2 = float64(ToLong([4]byte{0, response[61], response[60], response[59]})) / float64(factor2)
 	tem05.logger.Debug("Масса по 2 каналу, т  :%f", tem05.data.Systems[0].M2)
 
 	//======================Температуры всякие==========================================================================
@@ -136,6 +169,19 @@ func (tem05 *TEM05OLD) populate(response []byte) {
 	tem05.logger.Debug("Ошибки (байт 62 - общее количество: %d", response[62])
 	tem05.logger.Debug("Ошибка 1 (203-202): %d", toWord([2]byte{response[203], response[202]}))
 	tem05.logger.Debug("Ошибка 2 (205-204): %d", toWord([2]byte{response[205], response[204]}))
+	tem05.events = []models.Event{
+		{Type: models.EventError, Description: "общее количество", System: 1, Count: uint32(response[62])},
+		{Type: models.EventError, Description: "ошибка 1", System: 1, Count: uint32(toWord([2]byte{response[203], response[202]}))},
+		{Type: models.EventError, Description: "ошибка 2", System: 1, Count: uint32(toWord([2]byte{response[205], response[204]}))},
+	}
+	// Слова ошибок передаются в состоянии прибора как есть: ошибка 1 - старшее слово, ошибка 2 - младшее.
+	// Значения отдельных битов в паспорте не описаны, поэтому расшифровывается только наличие ошибки.
+	tem05.data.MeterStatus = &models.MeterStatus{
+		Code: uint32(toWord([2]byte{response[203], response[202]}))<<16 | uint32(toWord([2]byte{response[205], response[204]})),
+	}
+	if tem05.data.MeterStatus.Code != 0 {
+		tem05.data.MeterStatus.Flags = models.StatusError
+	}
 	//==================================================================================================================
 }
 
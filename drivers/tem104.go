@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"fmt"
 	"qBox/models"
 	"qBox/services/log"
 	"qBox/services/net"
@@ -27,7 +28,8 @@ type Tem104 struct {
 	network       *net.Network
 	logger        *log.LoggerService
 	counterNumber byte
-	systemCount   int // количество активных систем
+	systemCount   int                   // количество активных систем
+	systems       []models.SystemConfig // конфигурация активных систем из SysCon
 }
 
 // Реализация интерфейса IDeviceDriver::Init
@@ -66,19 +68,55 @@ func (tem *Tem104) Init(counterNumber byte, network *net.Network, logger *log.Lo
 	tem.data.AddNewSystem(tem.systemCount)
 	for i := 0; i <= tem.systemCount-1; i++ {
 		tem.data.Systems[i].Status = true
+		// Объёмы, массы, расходы, температуры и давления отмечаются при чтении по назначенным системе каналам
+		tem.data.Systems[i].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ)
 	}
 	logger.Debug("Активировано систем - %d", tem.systemCount)
 
 	// Ед. измерения tem.data.UnitQ
 	// В ТЭМ-10statX показываются ГКал и цифра, эта же цифра получается и здесь, но по протоколу она указана как МВт.
 	tem.data.UnitQ = models.Gcal // Этот случай перепроверен на ОДК, действительно с прибора приходят сразу ГКал
+	tem.data.Model = "ТЭМ-104"
+	tem.data.Manufacturer = TemManufacturer
 
 	tem.data.Serial = strconv.FormatUint(uint64(tem.readLongFrom(response, 6+0x7C)), 10)
 	logger.Debug("Байты заводского номера (%s) - %X", tem.data.Serial, response[6+0x7C:6+0x7C+4])
 
+	// Каналы прибора распределяются по системам согласно SysCon
+	tem.systems = nil
+	for i := 0; i < tem.systemCount; i++ {
+		sysCon, err := tem.readSysCon(i)
+		if err != nil {
+			logger.Info("Конфигурация системы %d не прочитана, каналы распределяются по умолчанию. %s", i+1, err.Error())
+			tem.systems = nil
+			break
+		}
+		tem.systems = append(tem.systems, DecodeTemSysCon(i+1, sysCon, nil))
+		logger.Debug("Система %d: схема %X, каналы расхода %v", i+1, sysCon[0], tem.flowChannels(i))
+	}
+
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// ТЭМ-104 хранит в начале памяти 2К число систем (1-4), а с адреса 7Ch - заводской номер.
+// Проверка не отличает ТЭМ-104 от других приборов ТЭМ, поэтому выполняется после драйверов с идентификацией по
+// наименованию.
+func (tem *Tem104) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	data, err := probeTem2K(counterNumber, network, logger, 0x0000, 0x7C+0x04)
+	for err != nil {
+		return false, "память 2К не прочитана. " + err.Error()
+	}
+	if data[0] < 1 || data[0] > 4 {
+		return false, fmt.Sprintf("некорректное число систем - %X", data[0])
+	}
+	serial := tem.readLongFrom(data, 0x7C)
+	if serial == 0 || serial == 0xFFFFFFFF {
+		return false, fmt.Sprintf("некорректный заводской номер - %X", data[0x7C:0x7C+4])
+	}
+	return true, fmt.Sprintf("число систем %d, заводской номер %d", data[0], serial)
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (tem *Tem104) Read() (*models.DataDevice, error) {
 
@@ -86,31 +124,14 @@ func (tem *Tem104) Read() (*models.DataDevice, error) {
 
 	var command []byte
 	var response []byte
+	var request net.Request
 	var err error
 
-	tem.logger.Info("Получение даты времени на теплосчётчике")
-	command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x10, 0x10}
-	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
-	request.ControlFunction = func(response []byte) bool {
-		if len(response) < 15 {
-			tem.logger.Info("Полученные данные меньше 15 байт")
-			return false
-		}
-		return true
-	}
-	response, err = tem.network.RunIO(request)
+	tem.data.Time, err = tem.ReadTime()
 	for err != nil {
 		return &tem.data, err
 	}
 
-	year := 2000 + DecodeBcd([]byte{response[11]})
-	month := time.Month(DecodeBcd([]byte{response[10]}))
-	day := DecodeBcd([]byte{response[9]})
-	hour := DecodeBcd([]byte{response[8]})
-	min := DecodeBcd([]byte{response[7]})
-	sek := DecodeBcd([]byte{response[6]})
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
-
 	tem.logger.Info("Чтение оперативной памяти")
 
 	command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0C, 0x01, 0x03}
@@ -121,13 +142,13 @@ func (tem *Tem104) Read() (*models.DataDevice, error) {
 		}
 		// Текущие данные в оперативной памяти начинаются с 2200h = 8704(dec),
 		// по 92h = 146(dec) байт на стркутуру по одной системе
-		startBytes := intToBigEndian(uint16(8704 + 146*i))
+		startBytes := intToBigEndian(uint16(8704 + temSysParSize*i))
 
 		command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0C, 0x01, 0x03}
 
 		command = append(command, startBytes...) // Добавляем адрес оперативной памяти
 
-		command = append(command, 0x60) // Длина считываемого блока. Данные следующие за мощностью(0x60) не нужны.
+		command = append(command, temSysParSize) // Длина считываемого блока - вся структура SysPar системы
 
 		request = net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
 		request.ControlFunction = tem.checkFrame
@@ -136,20 +157,7 @@ func (tem *Tem104) Read() (*models.DataDevice, error) {
 			return &tem.data, err
 		}
 
-		// TODO Есть ещё T4/P4/GV3/GM3/GV4/GM4 - необходимо эксперементировать с теплосчётчиком, меняяя его настройки.
-		tem.data.Systems[i].T1 = tem.readFloatFrom(response, 0x06+0x00)
-		tem.data.Systems[i].T2 = tem.readFloatFrom(response, 0x06+0x04)
-		tem.data.Systems[i].T3 = tem.readFloatFrom(response, 0x06+0x08)
-
-		tem.data.Systems[i].P1 = tem.readFloatFrom(response, 0x06+0x10)
-		tem.data.Systems[i].P2 = tem.readFloatFrom(response, 0x06+0x14)
-		tem.data.Systems[i].P3 = tem.readFloatFrom(response, 0x06+0x18)
-
-		tem.data.Systems[i].GV1 = tem.readFloatFrom(response, 0x06+0x40)
-		tem.data.Systems[i].GV2 = tem.readFloatFrom(response, 0x06+0x44)
-
-		tem.data.Systems[i].GM1 = tem.readFloatFrom(response, 0x06+0x50)
-		tem.data.Systems[i].GM2 = tem.readFloatFrom(response, 0x06+0x54)
+		tem.populateSysPar(i, response[6:6+temSysParSize])
 	}
 
 	tem.logger.Info("Читаем 2K память")
@@ -201,20 +209,33 @@ func (tem *Tem104) Read() (*models.DataDevice, error) {
 		if system.Status == false {
 			continue
 		}
-		tem.data.Systems[i].SigmaQ = float64(float32(tem.readLongFrom(memoryResponse2K, 0x06+0x58+0x04*i)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x28+0x04*i))
+		tem.data.Systems[i].SigmaQ = ToIntegrator(tem.readLongFrom(memoryResponse2K, 0x06+0x58+0x04*i), tem.readFloatFrom(memoryResponse2K, 0x06+0x28+0x04*i))
 	}
 
-	// есть V1,V2, V3 и V4 по каналам . В какие системы их помещать непонятно. Для первой системы, чаще всего V1 и V2 имеется
-	tem.data.Systems[0].V1 = float64(float32(tem.readLongFrom(memoryResponse2K, 0x06+0x38)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x08))
-	tem.data.Systems[0].V2 = float64(float32(tem.readLongFrom(memoryResponse2K, 0x06+0x3C)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x0C))
-	//V3 = float32(tem.readLongFrom(memoryResponse2K, 0x06+0x40)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x16)
-	//V4 = float32(tem.readLongFrom(memoryResponse2K, 0x06+0x44)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x1A)
-
-	// тоже самое, что и с V
-	tem.data.Systems[0].M1 = float64(float32(tem.readLongFrom(memoryResponse2K, 0x06+0x48)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x18))
-	tem.data.Systems[0].M2 = float64(float32(tem.readLongFrom(memoryResponse2K, 0x06+0x4C)) + tem.readFloatFrom(memoryResponse2K, 0x06+0x1C))
-	//M3 = float32(tem.readLongFrom(memoryResponse2K, 0x56)) + tem.readFloatFrom(memoryResponse2K, 0x26)
-	//M4 = float32(tem.readLongFrom(memoryResponse2K, 0x5A)) + tem.readFloatFrom(memoryResponse2K, 0x2A)
+	// Объёмы и массы в SysInt хранятся по каналам: V1-V4 и M1-M4, в системы они распределяются согласно SysCon.
+	// Первый канал расхода системы попадает в V1/M1, второй - в V2/M2, третий - в каналы системы.
+	for i, system := range tem.data.Systems {
+		if system.Status == false {
+			continue
+		}
+		for n, channel := range tem.flowChannels(i) {
+			c := channel - 1
+			v := ToIntegrator(tem.readLongFrom(memoryResponse2K, 0x06+0x38+0x04*c), tem.readFloatFrom(memoryResponse2K, 0x06+0x08+0x04*c))
+			m := ToIntegrator(tem.readLongFrom(memoryResponse2K, 0x06+0x48+0x04*c), tem.readFloatFrom(memoryResponse2K, 0x06+0x18+0x04*c))
+			switch n {
+			case 0:
+				tem.data.Systems[i].V1, tem.data.Systems[i].M1 = v, m
+				tem.data.Systems[i].SetSupported(models.FieldV1 | models.FieldM1)
+			case 1:
+				tem.data.Systems[i].V2, tem.data.Systems[i].M2 = v, m
+				tem.data.Systems[i].SetSupported(models.FieldV2 | models.FieldM2)
+			default:
+				role := tem.slotRole(i, models.QuantityFlow, n)
+				tem.data.Systems[i].AddChannel(channel, role, models.QuantityVolume, v)
+				tem.data.Systems[i].AddChannel(channel, role, models.QuantityMass, m)
+			}
+		}
+	}
 
 	tem.data.TimeOn = tem.readLongFrom(memoryResponse2K, 0x6E)
 
@@ -242,6 +263,203 @@ func (tem *Tem104) Read() (*models.DataDevice, error) {
 	return &tem.data, nil
 }
 
+// Размер структуры SysPar одной системы в оперативной памяти
+const temSysParSize = 0x92
+
+/**
+Заполнение текущих значений системы из SysPar. system - индекс системы, начиная с 0
+В SysPar значения лежат по слотам каналов системы: tmp[4] с 00h, prs[4] с 10h, расходы V[4] с 40h и M[4] с 50h.
+n-й слот относится к n-му каналу этой величины в SysCon, первые слоты попадают в поля SystemDevice, остальные - в
+каналы системы. Раскладка совпадает с SysPar ТЭМ-104М (раздел 5.3 протокола ТЭМ-104М), за ней следуют ошибки
+системы tekerr (70h) и teherr (71h), числа у ТЭМ-104 передаются старшим байтом вперёд.
+*/
+func (tem *Tem104) populateSysPar(system int, sysPar []byte) {
+	device := &tem.data.Systems[system]
+	device.MeterStatus = TemErrors(sysPar[TemSysParTekErrAddress],
+		toWord([2]byte{sysPar[TemSysParTehErrAddress], sysPar[TemSysParTehErrAddress+1]}))
+
+	for n, channel := range tem.slotChannels(system, models.QuantityTemperature) {
+		value := tem.readFloatFrom(sysPar, 0x00+0x04*n)
+		switch n {
+		case 0:
+			device.T1 = value
+			device.SetSupported(models.FieldT1)
+		case 1:
+			device.T2 = value
+			device.SetSupported(models.FieldT2)
+		case 2:
+			device.T3 = value
+			device.SetSupported(models.FieldT3)
+		default:
+			device.AddChannel(channel, tem.slotRole(system, models.QuantityTemperature, n), models.QuantityTemperature, float64(value))
+		}
+	}
+
+	for n, channel := range tem.slotChannels(system, models.QuantityPressure) {
+		value := tem.readFloatFrom(sysPar, 0x10+0x04*n)
+		switch n {
+		case 0:
+			device.P1 = value
+			device.SetSupported(models.FieldP1)
+		case 1:
+			device.P2 = value
+			device.SetSupported(models.FieldP2)
+		case 2:
+			device.P3 = value
+			device.SetSupported(models.FieldP3)
+		default:
+			device.AddChannel(channel, tem.slotRole(system, models.QuantityPressure, n), models.QuantityPressure, float64(value))
+		}
+	}
+
+	for n, channel := range tem.slotChannels(system, models.QuantityFlow) {
+		gv := tem.readFloatFrom(sysPar, 0x40+0x04*n)
+		gm := tem.readFloatFrom(sysPar, 0x50+0x04*n)
+		switch n {
+		case 0:
+			device.GV1, device.GM1 = gv, gm
+			device.SetSupported(models.FieldGV1 | models.FieldGM1)
+		case 1:
+			device.GV2, device.GM2 = gv, gm
+			device.SetSupported(models.FieldGV2 | models.FieldGM2)
+		default:
+			role := tem.slotRole(system, models.QuantityFlow, n)
+			device.AddChannel(channel, role, models.QuantityFlow, float64(gv))
+			device.AddChannel(channel, role, models.QuantityMassFlow, float64(gm))
+		}
+	}
+}
+
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *Tem104) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (tem *Tem104) ReadTime() (time.Time, error) {
+	tem.logger.Info("Получение даты времени на теплосчётчике")
+	command := []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x10, 0x10}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = func(response []byte) bool {
+		if len(response) < 15 {
+			tem.logger.Info("Полученные данные меньше 15 байт")
+			return false
+		}
+		return true
+	}
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return time.Time{}, err
+	}
+
+	year := 2000 + DecodeBcd([]byte{response[11]})
+	month := time.Month(DecodeBcd([]byte{response[10]}))
+	day := DecodeBcd([]byte{response[9]})
+	hour := DecodeBcd([]byte{response[8]})
+	min := DecodeBcd([]byte{response[7]})
+	sek := DecodeBcd([]byte{response[6]})
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Часы хранятся в памяти таймера с адреса 10h в двоично-десятичном виде: секунды, минуты, часы, день, месяц, год.
+func (tem *Tem104) WriteTime(t time.Time) error {
+	tem.logger.Info("Запись даты времени на теплосчётчик")
+	data := []byte{0x10,
+		EncodeBcd(t.Second()), EncodeBcd(t.Minute()), EncodeBcd(t.Hour()),
+		EncodeBcd(t.Day()), EncodeBcd(int(t.Month())), EncodeBcd(t.Year() - 2000)}
+	command := append([]byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x01, 0x82, byte(len(data))}, data...)
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *Tem104) TimeResolution() time.Duration {
+	return time.Second
+}
+
+// Реализация интерфейса IConfigDriver::ReadConfig
+// Конфигурация систем читается из структур SysCon памяти 2К. Настройки каналов расхода по протоколу неизвестны,
+// поэтому программируемый расход не пересчитывается в м3/ч.
+func (tem *Tem104) ReadConfig() (*models.DeviceConfig, error) {
+	config := models.DeviceConfig{
+		Serial: tem.data.Serial,
+		UnitQ:  tem.data.UnitQ,
+		UnitP:  "МПа",
+	}
+
+	for i := 0; i < tem.systemCount; i++ {
+		sysCon, err := tem.readSysCon(i)
+		for err != nil {
+			return nil, err
+		}
+		tem.logger.Debug("Байты SysCon системы %d - %X", i+1, sysCon)
+		config.Systems = append(config.Systems, DecodeTemSysCon(i+1, sysCon, nil))
+	}
+
+	return &config, nil
+}
+
+// Чтение структуры SysCon системы из памяти 2К. system - индекс системы, начиная с 0
+func (tem *Tem104) readSysCon(system int) ([]byte, error) {
+	tem.logger.Info("Чтение конфигурации системы %d", system+1)
+	startBytes := intToBigEndian(uint16(0x0600 + TemSysConSize*system))
+	command := append([]byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03}, startBytes...)
+	command = append(command, TemSysConSize)
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return nil, err
+	}
+	return response[6 : 6+TemSysConSize], nil
+}
+
+// Номера каналов расхода системы, начиная с 1. system - индекс системы, начиная с 0
+// Если SysCon не прочитан, то первой системе отдаются 1-й и 2-й каналы, как было до чтения конфигурации.
+func (tem *Tem104) flowChannels(system int) []int {
+	if system >= len(tem.systems) {
+		if system == 0 {
+			return []int{1, 2}
+		}
+		return nil
+	}
+	var channels []int
+	for _, channel := range tem.systems[system].Channels {
+		if channel.Quantity == models.QuantityFlow && channel.Channel >= 1 && channel.Channel <= 4 {
+			channels = append(channels, channel.Channel)
+		}
+	}
+	return channels
+}
+
+// Номера каналов прибора по слотам SysPar системы для величины quantity. system - индекс системы, начиная с 0
+// Если SysCon не прочитан, то читаются все 4 слота, номера каналов прибора неизвестны (0).
+func (tem *Tem104) slotChannels(system int, quantity models.QuantityEnum) []int {
+	if system >= len(tem.systems) {
+		return []int{0, 0, 0, 0}
+	}
+	var channels []int
+	for _, channel := range tem.systems[system].Channels {
+		if channel.Quantity == quantity {
+			channels = append(channels, channel.Channel)
+		}
+	}
+	return channels
+}
+
+// Назначение трубопровода по слоту канала системы. Если SysCon не прочитан, то схема и назначение неизвестны.
+func (tem *Tem104) slotRole(system int, quantity models.QuantityEnum, slot int) models.ChannelRoleEnum {
+	if system >= len(tem.systems) {
+		return models.RoleUnknown
+	}
+	return TemChannelRole(tem.systems[system].SchemeCode, quantity, slot)
+}
+
 /**
 * Проверка контрольной суммы
  */
@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"fmt"
 	"qBox/models"
 	"qBox/services/log"
 	"qBox/services/net"
@@ -49,7 +50,11 @@ func (tem *Tem104s1) Init(counterNumber byte, network *net.Network, logger *log.
 
 	tem.data.AddNewSystem(1)
 	tem.data.Systems[0].Status = true
+	tem.data.Systems[0].SetSupported(models.FieldSigmaQ | models.FieldV1 | models.FieldM1 | models.FieldGV1 | models.FieldGM1 |
+		models.FieldT1 | models.FieldT2 | models.FieldP1 | models.FieldP2)
 	tem.data.UnitQ = models.Gcal
+	tem.data.Model = "ТЭМ-104-1"
+	tem.data.Manufacturer = TemManufacturer
 
 	tem.data.Serial = string(response[6:13])
 	tem.logger.Debug("Заводской номер - %s", tem.data.Serial)
@@ -57,30 +62,36 @@ func (tem *Tem104s1) Init(counterNumber byte, network *net.Network, logger *log.
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// ТЭМ-104-1 хранит заводской номер в начале памяти 2К текстовой строкой из 7 цифр.
+func (tem *Tem104s1) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	data, err := probeTem2K(counterNumber, network, logger, 0x0000, 0x07)
+	for err != nil {
+		return false, "память 2К не прочитана. " + err.Error()
+	}
+	for _, b := range data {
+		if b < '0' || b > '9' {
+			return false, fmt.Sprintf("в начале памяти 2К не заводской номер - %X", data)
+		}
+	}
+	return true, "в начале памяти 2К заводской номер " + string(data)
+}
+
+// Реализация интерфейса IDeviceDriver::Read
+// Состояние прибора не заполняется: в протоколе ТЭМ-104-1, по которому написан драйвер, нет адресов ошибок.
 func (tem *Tem104s1) Read() (*models.DataDevice, error) {
 
 	tem.data.TimeRequest = time.Now()
 
 	var command []byte
 	var response []byte
+	var request net.Request
 	var err error
 
-	tem.logger.Info("Получение даты времени на теплосчётчике")
-	command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x07}
-	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
-	request.ControlFunction = tem.checkFrame
-	request.SecondsReadTimeout = 5
-	response, err = tem.network.RunIO(request)
+	tem.data.Time, err = tem.ReadTime()
 	for err != nil {
 		return &tem.data, err
 	}
-	year := 2000 + DecodeBcd([]byte{response[12]})
-	month := time.Month(DecodeBcd([]byte{response[11]}))
-	day := DecodeBcd([]byte{response[10]})
-	hour := DecodeBcd([]byte{response[8]})
-	min := DecodeBcd([]byte{response[7]})
-	sek := DecodeBcd([]byte{response[6]})
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
 
 	tem.logger.Info("Чтение оперативной памяти")
 
@@ -111,9 +122,9 @@ func (tem *Tem104s1) Read() (*models.DataDevice, error) {
 		return &tem.data, err
 	}
 
-	tem.data.Systems[0].SigmaQ = float64(float32(calculateLongByPointer(response, 0x06+0x10)) + calculateFloatByPointer(response, 0x06+0x14))
-	tem.data.Systems[0].V1 = float64(float32(calculateLongByPointer(response, 0x06)) + calculateFloatByPointer(response, 0x06+0x04))
-	tem.data.Systems[0].M1 = float64(float32(calculateLongByPointer(response, 0x06+0x08)) + calculateFloatByPointer(response, 0x06+0x0C))
+	tem.data.Systems[0].SigmaQ = ToIntegrator(calculateLongByPointer(response, 0x06+0x10), calculateFloatByPointer(response, 0x06+0x14))
+	tem.data.Systems[0].V1 = ToIntegrator(calculateLongByPointer(response, 0x06), calculateFloatByPointer(response, 0x06+0x04))
+	tem.data.Systems[0].M1 = ToIntegrator(calculateLongByPointer(response, 0x06+0x08), calculateFloatByPointer(response, 0x06+0x0C))
 
 	tem.data.TimeOn = calculateLongByPointer(response, 0x06+0x18)
 	tem.data.TimeRunCommon = calculateLongByPointer(response, 0x06+0x1C)
@@ -121,6 +132,53 @@ func (tem *Tem104s1) Read() (*models.DataDevice, error) {
 	return &tem.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *Tem104s1) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (tem *Tem104s1) ReadTime() (time.Time, error) {
+	tem.logger.Info("Получение даты времени на теплосчётчике")
+	command := []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x07}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return time.Time{}, err
+	}
+	year := 2000 + DecodeBcd([]byte{response[12]})
+	month := time.Month(DecodeBcd([]byte{response[11]}))
+	day := DecodeBcd([]byte{response[10]})
+	hour := DecodeBcd([]byte{response[8]})
+	min := DecodeBcd([]byte{response[7]})
+	sek := DecodeBcd([]byte{response[6]})
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Часы хранятся в памяти таймера с адреса 00h в двоично-десятичном виде: секунды, минуты, часы, день недели (1-7),
+// число, месяц, год.
+func (tem *Tem104s1) WriteTime(t time.Time) error {
+	tem.logger.Info("Запись даты времени на теплосчётчик")
+	data := []byte{0x00,
+		EncodeBcd(t.Second()), EncodeBcd(t.Minute()), EncodeBcd(t.Hour()), EncodeBcd(IsoWeekday(t)),
+		EncodeBcd(t.Day()), EncodeBcd(int(t.Month())), EncodeBcd(t.Year() - 2000)}
+	command := append([]byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x01, 0x82, byte(len(data))}, data...)
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *Tem104s1) TimeResolution() time.Duration {
+	return time.Second
+}
+
 /**
 * Проверка контрольной суммы
  */
@@ -42,13 +42,21 @@ func (tem *TEM104M1) Init(counterNumber byte, network *net.Network, logger *log.
 
 	tem.data.AddNewSystem(1)
 	tem.data.Systems[0].Status = true
+	tem.data.Systems[0].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ | models.FieldV1 | models.FieldM1 |
+		models.FieldGV1 | models.FieldGM1 | models.FieldT1 | models.FieldT2 | models.FieldP1 | models.FieldP2)
 	tem.data.UnitQ = models.Gcal
+	PopulateTemIdentity(tem.counterNumber, tem.network, tem.logger, &tem.data, "ТЭМ-104М-1")
 
 	tem.data.Serial = strconv.FormatUint(uint64(calculateLongByPointerLittleEndian(response, 0x06)), 10)
 	tem.logger.Debug("Байты заводского номера (%s) - %X", tem.data.Serial, response[6:6+4])
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+func (tem *TEM104M1) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	return DetectTem104M(counterNumber, network, logger)
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (tem *TEM104M1) Read() (*models.DataDevice, error) {
 
@@ -56,26 +64,14 @@ func (tem *TEM104M1) Read() (*models.DataDevice, error) {
 
 	var command []byte
 	var response []byte
+	var request net.Request
 	var err error
 
-	tem.logger.Info("Получение даты времени на теплосчётчике")
-	command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x06}
-	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
-	request.ControlFunction = tem.checkFrame
-	request.SecondsReadTimeout = 5
-	response, err = tem.network.RunIO(request)
+	tem.data.Time, err = tem.ReadTime()
 	for err != nil {
 		return &tem.data, err
 	}
 
-	year := 2000 + int(response[11])
-	month := time.Month(int(response[10]))
-	day := int(response[9])
-	hour := int(response[8])
-	min := int(response[7])
-	sek := int(response[6])
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
-
 	tem.logger.Info("Чтение оперативной памяти")
 
 	command = []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0C, 0x01, 0x03, 0x00, 0x00, 0x28}
@@ -101,9 +97,9 @@ func (tem *TEM104M1) Read() (*models.DataDevice, error) {
 		return &tem.data, err
 	}
 
-	tem.data.Systems[0].SigmaQ = float64(float32(calculateLongByPointerLittleEndian(response, 0x06+0x10)) + calculateFloatByPointerLittleEndian(response, 0x06+0x20))
-	tem.data.Systems[0].V1 = float64(float32(calculateLongByPointerLittleEndian(response, 0x06+0x08)) + calculateFloatByPointerLittleEndian(response, 0x06+0x18))
-	tem.data.Systems[0].M1 = float64(float32(calculateLongByPointerLittleEndian(response, 0x06+0x0C)) + calculateFloatByPointerLittleEndian(response, 0x06+0x1C))
+	tem.data.Systems[0].SigmaQ = ToIntegrator(calculateLongByPointerLittleEndian(response, 0x06+0x10), calculateFloatByPointerLittleEndian(response, 0x06+0x20))
+	tem.data.Systems[0].V1 = ToIntegrator(calculateLongByPointerLittleEndian(response, 0x06+0x08), calculateFloatByPointerLittleEndian(response, 0x06+0x18))
+	tem.data.Systems[0].M1 = ToIntegrator(calculateLongByPointerLittleEndian(response, 0x06+0x0C), calculateFloatByPointerLittleEndian(response, 0x06+0x1C))
 	tem.data.Systems[0].T1 = float32(toWord([2]byte{response[0x06+0x4B+0x01], response[0x06+0x4B]})) / 100
 	tem.data.Systems[0].T2 = float32(toWord([2]byte{response[0x06+0x4B+0x03], response[0x06+0x4B+0x02]})) / 100
 	tem.data.Systems[0].P1 = float32(response[0x06+0x4F]) / 100
@@ -112,9 +108,62 @@ func (tem *TEM104M1) Read() (*models.DataDevice, error) {
 	tem.data.TimeOn = calculateLongByPointerLittleEndian(response, 0x06+0x28)
 	tem.data.Systems[0].TimeRunSys = calculateLongByPointerLittleEndian(response, 0x06+0x30)
 
+	// Карта интеграторов одной системы устроена как у ТЭМ-104М (раздел 5.1.5 протокола ТЭМ-104М): перед температурами
+	// t (4Bh) лежат ошибки системы tekerr (48h) и teherr (49h, little-endian)
+	tem.data.Systems[0].MeterStatus = TemErrors(response[0x06+0x48],
+		toWord([2]byte{response[0x06+0x4A], response[0x06+0x49]}))
+
 	return &tem.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *TEM104M1) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (tem *TEM104M1) ReadTime() (time.Time, error) {
+	tem.logger.Info("Получение даты времени на теплосчётчике")
+	command := []byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x06}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return time.Time{}, err
+	}
+
+	year := 2000 + int(response[11])
+	month := time.Month(int(response[10]))
+	day := int(response[9])
+	hour := int(response[8])
+	min := int(response[7])
+	sek := int(response[6])
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Часы хранятся в памяти таймера с адреса 00h в двоичном виде: секунды, минуты, часы, число, месяц, год,
+// день недели (0 - воскресенье).
+func (tem *TEM104M1) WriteTime(t time.Time) error {
+	tem.logger.Info("Запись даты времени на теплосчётчик")
+	data := []byte{0x00,
+		byte(t.Second()), byte(t.Minute()), byte(t.Hour()),
+		byte(t.Day()), byte(t.Month()), byte(t.Year() - 2000), byte(t.Weekday())}
+	command := append([]byte{0x55, tem.counterNumber, ToNotByte(tem.counterNumber), 0x01, 0x82, byte(len(data))}, data...)
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *TEM104M1) TimeResolution() time.Duration {
+	return time.Second
+}
+
 /**
 * Проверка контрольной суммы
  */
@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"errors"
 	"qBox/models"
 	"qBox/services/convert"
 	"qBox/services/log"
@@ -51,12 +52,18 @@ func (tem *TEM104M2) Init(counterNumber byte, network *net.Network, logger *log.
 	}
 
 	tem.data.UnitQ = models.Gcal
+	PopulateTemIdentity(tem.counterNumber, tem.network, tem.logger, &tem.data, "ТЭМ-104М")
 
 	tem.data.Serial = strconv.FormatUint(uint64(convert.LongLittleEndianByPointer(response, 0x06)), 10)
 	tem.logger.Debug("Байты заводского номера (%s) - %X", tem.data.Serial, response[6:6+4])
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+func (tem *TEM104M2) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	return DetectTem104M(counterNumber, network, logger)
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (tem *TEM104M2) Read() (*models.DataDevice, error) {
 	var command []byte
@@ -80,6 +87,8 @@ func (tem *TEM104M2) Read() (*models.DataDevice, error) {
 	tem.data.Systems[0].GM1 = convert.FloatLittleEndianByPointer(response, 0x06+0x50)
 	tem.data.Systems[0].GV2 = convert.FloatLittleEndianByPointer(response, 0x06+0x44)
 	tem.data.Systems[0].GM2 = convert.FloatLittleEndianByPointer(response, 0x06+0x54)
+	// Расходы читаются только для первой системы
+	tem.data.Systems[0].SetSupported(models.FieldGV1 | models.FieldGM1 | models.FieldGV2 | models.FieldGM2)
 
 	integratorsData := tem.integratorsData()
 	tem.logger.Info("Расшифровка интеграторов %X", integratorsData)
@@ -87,11 +96,13 @@ func (tem *TEM104M2) Read() (*models.DataDevice, error) {
 	for i, _ := range tem.data.Systems {
 		tem.logger.Info("Чтение интеграторов системы %d", i+1)
 		tem.data.Systems[i].Status = true
-		tem.data.Systems[i].SigmaQ = float64(float32(convert.LongLittleEndianByPointer(integratorsData, uint8(0x28+i))) + convert.FloatLittleEndianByPointer(integratorsData, uint8(0x68+i)))
-		tem.data.Systems[i].V1 = float64(float32(convert.LongLittleEndianByPointer(integratorsData, 0x08)) + convert.FloatLittleEndianByPointer(integratorsData, 0x48))
-		tem.data.Systems[i].V2 = float64(float32(convert.LongLittleEndianByPointer(integratorsData, 0x04+0x08)) + convert.FloatLittleEndianByPointer(integratorsData, 0x04+0x48))
-		tem.data.Systems[i].M1 = float64(float32(convert.LongLittleEndianByPointer(integratorsData, 0x18)) + convert.FloatLittleEndianByPointer(integratorsData, 0x58))
-		tem.data.Systems[i].M2 = float64(float32(convert.LongLittleEndianByPointer(integratorsData, 0x04+0x18)) + convert.FloatLittleEndianByPointer(integratorsData, 0x04+0x58))
+		tem.data.Systems[i].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ | models.FieldV1 | models.FieldV2 |
+			models.FieldM1 | models.FieldM2 | models.FieldT1 | models.FieldT2 | models.FieldT3 | models.FieldP1 | models.FieldP2)
+		tem.data.Systems[i].SigmaQ = ToIntegrator(convert.LongLittleEndianByPointer(integratorsData, uint8(0x28+i)), convert.FloatLittleEndianByPointer(integratorsData, uint8(0x68+i)))
+		tem.data.Systems[i].V1 = ToIntegrator(convert.LongLittleEndianByPointer(integratorsData, 0x08), convert.FloatLittleEndianByPointer(integratorsData, 0x48))
+		tem.data.Systems[i].V2 = ToIntegrator(convert.LongLittleEndianByPointer(integratorsData, 0x04+0x08), convert.FloatLittleEndianByPointer(integratorsData, 0x04+0x48))
+		tem.data.Systems[i].M1 = ToIntegrator(convert.LongLittleEndianByPointer(integratorsData, 0x18), convert.FloatLittleEndianByPointer(integratorsData, 0x58))
+		tem.data.Systems[i].M2 = ToIntegrator(convert.LongLittleEndianByPointer(integratorsData, 0x04+0x18), convert.FloatLittleEndianByPointer(integratorsData, 0x04+0x58))
 		tem.data.TimeOn = convert.LongLittleEndianByPointer(integratorsData, 0x98)
 		tem.data.Systems[i].TimeRunSys = convert.LongLittleEndianByPointer(integratorsData, uint8(0xA0+i))
 		tem.data.Systems[i].T1 = float32(convert.ToWord([2]byte{integratorsData[285], integratorsData[284]})) / 100
@@ -99,17 +110,37 @@ func (tem *TEM104M2) Read() (*models.DataDevice, error) {
 		tem.data.Systems[i].T3 = float32(convert.ToWord([2]byte{integratorsData[289], integratorsData[288]})) / 100
 		tem.data.Systems[i].P1 = float32(integratorsData[308]) / 100
 		tem.data.Systems[i].P2 = float32(integratorsData[309]) / 100
+		if len(integratorsData) > TemTehErrAddress+2*i+1 {
+			tekerr := integratorsData[TemTekErrAddress+i]
+			teherr := convert.ToWord([2]byte{integratorsData[TemTehErrAddress+2*i+1], integratorsData[TemTehErrAddress+2*i]})
+			tem.data.Systems[i].MeterStatus = TemErrors(tekerr, teherr)
+		}
 	}
 
 	return &tem.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *TEM104M2) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
 func (tem *TEM104M2) prepareCommand(commandBytes []byte) []byte {
 	command := append([]byte{0x55, tem.counterNumber, convert.ToNotByte(tem.counterNumber)}, commandBytes...)
 	return append(command, tem.calculateCheckSum(command))
 }
 
 func (tem *TEM104M2) populateDatetime() {
+	datetime, err := tem.ReadTime()
+	for err != nil {
+		tem.logger.Info("Ошибка получения даты времени на теплосчётчике. " + err.Error())
+		return
+	}
+	tem.data.Time = datetime
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (tem *TEM104M2) ReadTime() (time.Time, error) {
 	tem.logger.Info("Получение даты времени на теплосчётчике")
 	command := []byte{0x55, tem.counterNumber, convert.ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x06}
 	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
@@ -117,8 +148,7 @@ func (tem *TEM104M2) populateDatetime() {
 	request.SecondsReadTimeout = 5
 	response, err := tem.network.RunIO(request)
 	for err != nil {
-		tem.logger.Info("Ошибка получения даты времени на теплосчётчике. " + err.Error())
-		return
+		return time.Time{}, err
 	}
 
 	year := 2000 + int(response[11])
@@ -127,7 +157,72 @@ func (tem *TEM104M2) populateDatetime() {
 	hour := int(response[8])
 	min := int(response[7])
 	sek := int(response[6])
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Часы хранятся в памяти таймера с адреса 00h в двоичном виде: секунды, минуты, часы, число, месяц, год,
+// день недели (0 - воскресенье).
+func (tem *TEM104M2) WriteTime(t time.Time) error {
+	tem.logger.Info("Запись даты времени на теплосчётчик")
+	data := []byte{0x00,
+		byte(t.Second()), byte(t.Minute()), byte(t.Hour()),
+		byte(t.Day()), byte(t.Month()), byte(t.Year() - 2000), byte(t.Weekday())}
+	request := net.PrepareRequest(tem.prepareCommand(append([]byte{0x01, 0x82, byte(len(data))}, data...)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *TEM104M2) TimeResolution() time.Duration {
+	return time.Second
+}
+
+// Реализация интерфейса IEventDriver::ReadEvents
+// Времена работы в нештатных режимах хранятся в карте интеграторов: время отсутствия питания Toffline (009Ch) и таймеры
+// по системам Tmin (00B0h), Tmax (00C0h), Tdt (00D0h), Ttn (00E0h), Trev (00F0h), Tpt (0100h), в секундах.
+func (tem *TEM104M2) ReadEvents() ([]models.Event, error) {
+	integratorsData := tem.integratorsData()
+	if len(integratorsData) < 0x110 {
+		return nil, errors.New("карта интеграторов прочитана не полностью")
+	}
+
+	var events []models.Event
+	if offline := convert.LongWordLittleEndianByPointer(integratorsData, 0x9C); offline > 0 {
+		events = append(events, models.Event{Type: models.EventPowerOff, Duration: offline})
+	}
+
+	timers := []struct {
+		eventType models.EventType
+		address   uint32
+	}{
+		{models.EventGMin, 0xB0},
+		{models.EventGMax, 0xC0},
+		{models.EventDeltaT, 0xD0},
+		{models.EventFault, 0xE0},
+		{models.EventReverse, 0xF0},
+		{models.EventNoCoolant, 0x100},
+	}
+
+	for i := range tem.data.Systems {
+		for _, timer := range timers {
+			duration := convert.LongWordLittleEndianByPointer(integratorsData, timer.address+uint32(i*4))
+			if duration == 0 {
+				continue
+			}
+			events = append(events, models.Event{Type: timer.eventType, System: i + 1, Duration: duration})
+		}
+	}
+
+	return events, nil
+}
+
+// Реализация интерфейса IConfigDriver::ReadConfig
+func (tem *TEM104M2) ReadConfig() (*models.DeviceConfig, error) {
+	return ReadTem104MConfig(tem.counterNumber, tem.network, tem.logger, tem.data.Serial)
 }
 
 func (tem *TEM104M2) integratorsData() []byte {
@@ -135,8 +230,8 @@ func (tem *TEM104M2) integratorsData() []byte {
 	step := 0
 	var integratorsData []byte
 	for {
-		// Текущие данные в оперативной памяти начинаются с 0800h = 2048(dec),
-		// по 0160h(015Fh+1) = 352(dec) байт на структуру по одной системе
+		// Карта интеграторов (раздел 5.1.5 протокола) начинается с 0800h = 2048(dec) памяти 2К и занимает
+		// 0160h(015Fh+1) = 352(dec) байт по всем системам сразу, читается блоками по 40h
 		startBytes := convert.IntToBigEndianBytes(uint16(2048 + 0x40*step))
 		// начнётся с 0x0F, 0x01, 0x03, 0x08, 0x00, 0x40
 		request := net.PrepareRequest(tem.prepareCommand(append(append([]byte{0x0F, 0x01, 0x03}, startBytes...), 0x40)))
@@ -0,0 +1,46 @@
+package drivers
+
+import (
+	"encoding/binary"
+	"math"
+	"qBox/models"
+	"testing"
+)
+
+func TestTem104PopulateSysPar(t *testing.T) {
+	// Схема "Источник": три канала расхода, температуры и давления, каналы прибора 2, 3 и 4
+	sysCon := make([]byte, TemSysConSize)
+	sysCon[0x00] = 13
+	copy(sysCon[0x05:], []byte{1, 2, 3})
+	copy(sysCon[0x0D:], []byte{1, 2, 3})
+	copy(sysCon[0x15:], []byte{1, 2, 3})
+
+	sysPar := make([]byte, temSysParSize)
+	for n := 0; n < 4; n++ {
+		binary.BigEndian.PutUint32(sysPar[0x00+0x04*n:], math.Float32bits(float32(10+n)))
+		binary.BigEndian.PutUint32(sysPar[0x10+0x04*n:], math.Float32bits(float32(20+n)))
+		binary.BigEndian.PutUint32(sysPar[0x40+0x04*n:], math.Float32bits(float32(30+n)))
+		binary.BigEndian.PutUint32(sysPar[0x50+0x04*n:], math.Float32bits(float32(40+n)))
+	}
+
+	tem := Tem104{systems: []models.SystemConfig{DecodeTemSysCon(1, sysCon, nil)}}
+	tem.data.AddNewSystem(1)
+	tem.populateSysPar(0, sysPar)
+
+	system := tem.data.Systems[0]
+	if system.T1 != 10 || system.T3 != 12 || system.P2 != 21 || system.GV2 != 31 || system.GM1 != 40 {
+		t.Errorf("поля системы: T1 %v, T3 %v, P2 %v, GV2 %v, GM1 %v", system.T1, system.T3, system.P2, system.GV2, system.GM1)
+	}
+	if len(system.Channels) != 2 {
+		t.Fatalf("каналов %d, ожидалось 2: %v", len(system.Channels), system.Channels)
+	}
+	for _, channel := range system.Channels {
+		if channel.Number != 4 || channel.Role != models.RoleMakeup {
+			t.Errorf("канал %d (%v), ожидался 4 (подпитка)", channel.Number, channel.Role)
+		}
+		if channel.Quantity == models.QuantityFlow && channel.Value != 32 ||
+			channel.Quantity == models.QuantityMassFlow && channel.Value != 42 {
+			t.Errorf("третий канал расхода: %v", channel)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tem104k
+
+import (
+	"qBox/drivers"
+	"qBox/models"
+	"qBox/services/net"
+)
+
+/**
+Конфигурация прибора хранится в EEPROM 512 (формат Motorola, старшим байтом вперёд):
+0008h diam     - диаметр канала расхода, 0 - 15 мм, 1 - 20 мм
+0009h g_max    - максимальное значение расхода, м3/ч
+000Fh g_cut    - расход отсечки, м3/ч
+0018h sys_type - тип системы
+0019h tsp_type - тип ТСП
+0026h P1, 002Ah P2 - запрограммированные давления в каналах 1 и 2, МПа
+0034h T_rev    - запрограммированная температура в канале 2 для системы "Тупиковая ГВС"
+*/
+const configSize = 0x38
+
+var schemeNames = map[byte]string{
+	0x01: "Подача",
+	0x02: "Обратка",
+	0x03: "Тупиковая ГВС",
+}
+
+var tspNames = map[byte]string{
+	0x01: "Pt100",
+	0x02: "Pt100'",
+	0x03: "Pt500",
+	0x04: "Pt500'",
+}
+
+// Реализация интерфейса IConfigDriver::ReadConfig
+func (tem *Tem104K) ReadConfig() (*models.DeviceConfig, error) {
+	tem.logger.Info("Чтение конфигурации из памяти EEPROM 512 байт")
+	command := []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03, 0x00, 0x00, configSize}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return nil, err
+	}
+	data := response[6:]
+
+	config := models.DeviceConfig{
+		Serial: tem.data.Serial,
+		UnitQ:  models.Gcal,
+		UnitP:  "МПа",
+	}
+
+	flow := models.SensorConfig{
+		Quantity: models.QuantityFlow,
+		Channel:  1,
+		Diameter: 15,
+		GMax:     tem.readFloatFrom(data, 0x09),
+		GCut:     tem.readFloatFrom(data, 0x0F),
+	}
+	if data[0x08] == 0x01 {
+		flow.Diameter = 20
+	}
+	config.Sensors = append(config.Sensors, flow)
+
+	tspType := data[0x19]
+	tem.logger.Debug("Тип ТСП - %X", tspType)
+	for channel := 1; channel <= 2; channel++ {
+		config.Sensors = append(config.Sensors, models.SensorConfig{
+			Quantity: models.QuantityTemperature,
+			Channel:  channel,
+			Type:     tspNames[tspType],
+		})
+	}
+
+	schemeCode := data[0x18]
+	tem.logger.Debug("Тип системы - %X", schemeCode)
+	system := models.SystemConfig{
+		Number:     1,
+		SchemeCode: int(schemeCode),
+		Scheme:     schemeNames[schemeCode],
+		Enabled:    true,
+		Channels: []models.ChannelAssignment{
+			{Quantity: models.QuantityFlow, Channel: 1},
+			{Quantity: models.QuantityTemperature, Channel: 1},
+			{Quantity: models.QuantityTemperature, Channel: 2},
+			{Quantity: models.QuantityPressure, Channel: 1, Programmed: true, Value: tem.readFloatFrom(data, 0x26)},
+		},
+	}
+	if schemeCode == 0x03 {
+		system.Channels[2].Programmed = true
+		system.Channels[2].Value = tem.readFloatFrom(data, 0x34)
+	} else {
+		system.Channels = append(system.Channels,
+			models.ChannelAssignment{Quantity: models.QuantityPressure, Channel: 2, Programmed: true, Value: tem.readFloatFrom(data, 0x2A)})
+	}
+	config.Systems = append(config.Systems, system)
+
+	return &config, nil
+}
@@ -0,0 +1,224 @@
+package tem104k
+
+import (
+	"errors"
+	"qBox/drivers"
+	"qBox/models"
+	"qBox/services/net"
+	"time"
+)
+
+/**
+Архив событий хранится в EEPROM 64К с адреса E340h, 460 записей по 10 байт:
+минуты, часы, день, месяц, год (BCD), предыдущее состояние прибора (I), текущее состояние прибора (I), контрольная сумма.
+Адреса первой и последней записи лежат в EEPROM 512 по адресам 0098h и 009Ah.
+*/
+const (
+	eventsBegin      = 0xE340
+	eventsEnd        = eventsBegin + 460*eventRecordSize
+	eventRecordSize  = 10
+	eventsDepth      = 30 // Количество последних записей архива, которые читаются за один опрос
+	recordsInRequest = 6  // Записей в одном запросе, не более 64 байт
+)
+
+// Расшифровка битовой маски состояния прибора
+var eventBits = [12]struct {
+	eventType   models.EventType
+	description string
+	system      int
+}{
+	{models.EventPowerOff, "", 0},
+	{models.EventFault, "обрыв/КЗ цепи возбуждения", 1},
+	{models.EventSensor, "обрыв/КЗ цепи ТСП1", 1},
+	{models.EventSensor, "обрыв/КЗ цепи ТСП2", 1},
+	{models.EventDeltaT, "", 1},
+	{models.EventNoCoolant, "", 1},
+	{models.EventSettings, "редактирование с клавиатуры", 0},
+	{models.EventLowVoltage, "", 0},
+	{models.EventGMin, "", 1},
+	{models.EventGMax, "", 1},
+	{models.EventClock, "", 0},
+	{models.EventArchiveReset, "", 0},
+}
+
+// Реализация интерфейса IEventDriver::ReadEvents
+// Читаются последние eventsDepth записей архива. По изменению битов состояния прибора определяются начало и окончание
+// событий. Если окончание события не попало в прочитанные записи, то событие считается продолжающимся.
+func (tem *Tem104K) ReadEvents() ([]models.Event, error) {
+	tem.logger.Info("Чтение адресов архива событий")
+	command := []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03, 0x00, 0x98, 0x04}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return nil, err
+	}
+
+	first := int(response[6])<<8 | int(response[7])
+	last := int(response[8])<<8 | int(response[9])
+	tem.logger.Debug("Адрес первой записи событий - %X, последней - %X", first, last)
+	if !tem.isEventAddress(first) || !tem.isEventAddress(last) {
+		tem.logger.Info("Архив событий пуст")
+		return nil, nil
+	}
+
+	// Записи читаются от последней к первой блоками, блок не переходит через границу кольцевого буфера
+	var records [][]byte
+	address := last
+	for len(records) < eventsDepth {
+		count := recordsInRequest
+		if available := (address-eventsBegin)/eventRecordSize + 1; count > available {
+			count = available
+		}
+		if address >= first && count > (address-first)/eventRecordSize+1 {
+			count = (address-first)/eventRecordSize + 1
+		}
+		if count > eventsDepth-len(records) {
+			count = eventsDepth - len(records)
+		}
+
+		start := address - (count-1)*eventRecordSize
+		tem.logger.Info("Чтение записей архива событий с адреса %X", start)
+		command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x03, 0x03,
+			byte(start >> 8), byte(start), byte(count * eventRecordSize)}
+		request = net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+		request.ControlFunction = tem.checkFrame
+		response, err = tem.network.RunIO(request)
+		for err != nil {
+			return nil, err
+		}
+
+		for i := count - 1; i >= 0; i-- {
+			records = append(records, response[6+i*eventRecordSize:6+(i+1)*eventRecordSize])
+		}
+
+		if start == first {
+			break
+		}
+		address = start - eventRecordSize
+		if address < eventsBegin {
+			address = eventsEnd - eventRecordSize
+		}
+	}
+
+	// Записи в хронологическом порядке
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	return tem.decodeEvents(records), nil
+}
+
+func (tem *Tem104K) isEventAddress(address int) bool {
+	return address >= eventsBegin && address < eventsEnd && (address-eventsBegin)%eventRecordSize == 0
+}
+
+func (tem *Tem104K) decodeEvents(records [][]byte) []models.Event {
+	var events []models.Event
+	var opened [len(eventBits)]int // индекс незавершённого события в events + 1
+
+	for _, record := range records {
+		if tem.calculateCheckSum(record[:eventRecordSize-1]) != record[eventRecordSize-1] {
+			tem.logger.Debug("Запись архива событий пропущена, контрольная сумма не совпадает - %X", record)
+			continue
+		}
+
+		moment := time.Date(
+			2000+drivers.DecodeBcd([]byte{record[4]}),
+			time.Month(drivers.DecodeBcd([]byte{record[3]})),
+			drivers.DecodeBcd([]byte{record[2]}),
+			drivers.DecodeBcd([]byte{record[1]}),
+			drivers.DecodeBcd([]byte{record[0]}),
+			0, 0, time.Local)
+		previous := uint16(record[5])<<8 | uint16(record[6])
+		current := uint16(record[7])<<8 | uint16(record[8])
+
+		for bit, info := range eventBits {
+			mask := uint16(1) << uint(bit)
+			switch {
+			case current&mask != 0 && previous&mask == 0:
+				events = append(events, models.Event{
+					Type:        info.eventType,
+					Description: info.description,
+					System:      info.system,
+					Start:       moment})
+				opened[bit] = len(events)
+			case current&mask == 0 && previous&mask != 0:
+				if opened[bit] > 0 {
+					events[opened[bit]-1].End = moment
+					opened[bit] = 0
+					continue
+				}
+				events = append(events, models.Event{
+					Type:        info.eventType,
+					Description: info.description,
+					System:      info.system,
+					End:         moment})
+			}
+		}
+	}
+
+	return events
+}
+
+/**
+Текущее состояние прибора - битовая маска Events последней записи архива событий: запись добавляется при каждом
+изменении состояния. Расшифровка битов приведена в описании структуры записи архива событий протокола ТЭМ-104К.
+*/
+func (tem *Tem104K) readStatus() (*models.MeterStatus, error) {
+	tem.logger.Info("Чтение адреса последней записи событий")
+	command := []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03, 0x00, 0x9A, 0x02}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return nil, err
+	}
+
+	last := int(response[6])<<8 | int(response[7])
+	if !tem.isEventAddress(last) {
+		tem.logger.Info("Архив событий пуст, состояние прибора неизвестно")
+		return nil, nil
+	}
+
+	tem.logger.Info("Чтение последней записи архива событий с адреса %X", last)
+	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x03, 0x03,
+		byte(last >> 8), byte(last), eventRecordSize}
+	request = net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	response, err = tem.network.RunIO(request)
+	for err != nil {
+		return nil, err
+	}
+
+	record := response[6 : 6+eventRecordSize]
+	if tem.calculateCheckSum(record[:eventRecordSize-1]) != record[eventRecordSize-1] {
+		return nil, errors.New("контрольная сумма последней записи архива событий не совпадает")
+	}
+	return stateStatus(uint16(record[7])<<8 | uint16(record[8])), nil
+}
+
+// Флаги состояния по битовой маске состояния прибора. Биты включения питания, редактирования настроек и сброса
+// архива ошибкой не считаются.
+func stateStatus(state uint16) *models.MeterStatus {
+	result := models.MeterStatus{Code: uint32(state)}
+	if state&0x000C != 0 {
+		result.Flags |= models.StatusSensorBreak
+	}
+	if state&0x0010 != 0 {
+		result.Flags |= models.StatusDeltaTMin
+	}
+	if state&0x0080 != 0 {
+		result.Flags |= models.StatusLowBattery
+	}
+	if state&0x0100 != 0 {
+		result.Flags |= models.StatusGMin
+	}
+	if state&0x0200 != 0 {
+		result.Flags |= models.StatusGMax
+	}
+	if state&0x0422 != 0 {
+		result.Flags |= models.StatusError
+	}
+	return &result
+}
@@ -7,6 +7,7 @@ import (
 	"qBox/models"
 	"qBox/services/log"
 	"qBox/services/net"
+	"strings"
 	"time"
 )
 
@@ -39,15 +40,18 @@ func (tem *Tem104K) Init(counterNumber byte, network *net.Network, logger *log.L
 	for err != nil {
 		return err
 	}
+	tem.data.Model = "ТЭМ-101"
+	tem.data.Manufacturer = drivers.TemManufacturer
 
 	tem.logger.Info("Получение версии ПО устройства")
 	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x00, 0x01, 0x00}
 	request = net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
 	request.ControlFunction = tem.checkSoftVersion
-	_, err = tem.network.RunIO(request)
+	response, err = tem.network.RunIO(request)
 	for err != nil {
 		return err
 	}
+	tem.data.Firmware = strings.TrimRight(string(response[6:len(response)-1]), "\x00 ")
 
 	tem.logger.Info("Чтение памяти EEPROM 512 байт")
 	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03, 0x00, 0x00, 0x08}
@@ -59,6 +63,8 @@ func (tem *Tem104K) Init(counterNumber byte, network *net.Network, logger *log.L
 	}
 	tem.data.AddNewSystem(0)
 	tem.data.Systems[0].Status = true
+	tem.data.Systems[0].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ | models.FieldV1 | models.FieldM1 |
+		models.FieldGV1 | models.FieldT1 | models.FieldT2)
 
 	//
 	//// Ед. измерения tem.data.UnitQ
@@ -71,6 +77,34 @@ func (tem *Tem104K) Init(counterNumber byte, network *net.Network, logger *log.L
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// Используются те же проверки, что и при инициализации: наименование ТЭМ-101 и версия ПО v2.
+func (tem *Tem104K) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	tem.logger = logger
+	tem.network = network
+	tem.counterNumber = counterNumber
+
+	command := []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x00, 0x00, 0x00}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkDevice
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	for err != nil {
+		return false, "прибор не ответил наименованием ТЭМ-101. " + err.Error()
+	}
+
+	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x00, 0x01, 0x00}
+	request = net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkSoftVersion
+	request.Attempts = 0
+	_, err = tem.network.RunIO(request)
+	for err != nil {
+		return false, "версия ПО прибора ТЭМ-101 не v2. " + err.Error()
+	}
+
+	return true, "прибор ответил наименованием ТЭМ-101 и версией ПО v2"
+}
+
 // Реализация интерфейса IDeviceDriver::Read
 func (tem *Tem104K) Read() (*models.DataDevice, error) {
 
@@ -78,25 +112,14 @@ func (tem *Tem104K) Read() (*models.DataDevice, error) {
 
 	var command []byte
 	var response []byte
+	var request net.Request
 	var err error
 
-	tem.logger.Info("Получение даты времени на теплосчётчике")
-	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x07}
-	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
-	request.ControlFunction = tem.checkFrame
-	response, err = tem.network.RunIO(request)
+	tem.data.Time, err = tem.ReadTime()
 	for err != nil {
 		return &tem.data, err
 	}
 
-	year := 2000 + drivers.DecodeBcd([]byte{response[12]})
-	month := time.Month(drivers.DecodeBcd([]byte{response[11]}))
-	day := drivers.DecodeBcd([]byte{response[10]})
-	hour := drivers.DecodeBcd([]byte{response[8]})
-	min := drivers.DecodeBcd([]byte{response[7]})
-	sek := drivers.DecodeBcd([]byte{response[6]})
-	tem.data.Time = time.Date(year, month, day, hour, min, sek, 0, time.Local)
-
 	tem.logger.Info("Чтение интеграторов")
 
 	command = []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x01, 0x03, 0x01, 0x40, 0x30}
@@ -108,9 +131,9 @@ func (tem *Tem104K) Read() (*models.DataDevice, error) {
 		return &tem.data, err
 	}
 
-	tem.data.Systems[0].V1 = float64(float32(tem.readLongFrom(response, 0x06)) + tem.readFloatFrom(response, 0x06+0x04))
-	tem.data.Systems[0].M1 = float64(float32(tem.readLongFrom(response, 0x06+0x08)) + tem.readFloatFrom(response, 0x06+0x08+0x04))
-	tem.data.Systems[0].SigmaQ = float64(float32(tem.readLongFrom(response, 0x06+0x08+0x08)) + tem.readFloatFrom(response, 0x06+0x08+0x08+0x04))
+	tem.data.Systems[0].V1 = drivers.ToIntegrator(tem.readLongFrom(response, 0x06), tem.readFloatFrom(response, 0x06+0x04))
+	tem.data.Systems[0].M1 = drivers.ToIntegrator(tem.readLongFrom(response, 0x06+0x08), tem.readFloatFrom(response, 0x06+0x08+0x04))
+	tem.data.Systems[0].SigmaQ = drivers.ToIntegrator(tem.readLongFrom(response, 0x06+0x08+0x08), tem.readFloatFrom(response, 0x06+0x08+0x08+0x04))
 	tem.data.UnitQ = models.Gcal
 
 	tem.data.TimeRunCommon = tem.readLongFrom(response, 0x06+0x08+0x08+0x08+0x10)
@@ -144,9 +167,61 @@ func (tem *Tem104K) Read() (*models.DataDevice, error) {
 
 	tem.data.Systems[0].GV1 = tem.readFloatFrom(response, 0x06)
 
+	tem.data.MeterStatus, err = tem.readStatus()
+	if err != nil {
+		tem.logger.Info("Состояние прибора не прочитано. " + err.Error())
+	}
+
 	return &tem.data, nil
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tem *Tem104K) Protocol() models.ProtocolEnum {
+	return models.ProtocolTem
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+func (tem *Tem104K) ReadTime() (time.Time, error) {
+	tem.logger.Info("Получение даты времени на теплосчётчике")
+	command := []byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x0F, 0x02, 0x02, 0x00, 0x07}
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	response, err := tem.network.RunIO(request)
+	for err != nil {
+		return time.Time{}, err
+	}
+
+	year := 2000 + drivers.DecodeBcd([]byte{response[12]})
+	month := time.Month(drivers.DecodeBcd([]byte{response[11]}))
+	day := drivers.DecodeBcd([]byte{response[10]})
+	hour := drivers.DecodeBcd([]byte{response[8]})
+	min := drivers.DecodeBcd([]byte{response[7]})
+	sek := drivers.DecodeBcd([]byte{response[6]})
+	return time.Date(year, month, day, hour, min, sek, 0, time.Local), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Часы хранятся в памяти таймера с адреса 00h в двоично-десятичном виде: секунды, минуты, часы, день недели (1-7),
+// число, месяц, год.
+func (tem *Tem104K) WriteTime(t time.Time) error {
+	tem.logger.Info("Запись даты времени на теплосчётчик")
+	data := []byte{0x00,
+		drivers.EncodeBcd(t.Second()), drivers.EncodeBcd(t.Minute()), drivers.EncodeBcd(t.Hour()), drivers.EncodeBcd(drivers.IsoWeekday(t)),
+		drivers.EncodeBcd(t.Day()), drivers.EncodeBcd(int(t.Month())), drivers.EncodeBcd(t.Year() - 2000)}
+	command := append([]byte{0x55, tem.counterNumber, drivers.ToNotByte(tem.counterNumber), 0x01, 0x82, byte(len(data))}, data...)
+	request := net.PrepareRequest(append(command, tem.calculateCheckSum(command)))
+	request.ControlFunction = tem.checkFrame
+	request.SecondsReadTimeout = 5
+	request.Attempts = 0
+	_, err := tem.network.RunIO(request)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tem *Tem104K) TimeResolution() time.Duration {
+	return time.Second
+}
+
 /**
 * Проверка контрольной суммы
  */
@@ -0,0 +1,78 @@
+package drivers
+
+import (
+	"qBox/models"
+	"qBox/services/convert"
+	"qBox/services/log"
+	"qBox/services/net"
+)
+
+/**
+Чтение конфигурации приборов ТЭМ-104М и ТЭМ-104М-2, у которых одинаковая разметка памяти 2К таймера:
+настройки прибора с 0000h, структуры SysCon по 4Dh байт на систему с 0080h, настройки каналов расхода с 0480h.
+Данные в формате little-endian. serial - заводской номер, прочитанный драйвером при инициализации.
+*/
+func ReadTem104MConfig(counterNumber byte, network *net.Network, logger *log.LoggerService,
+	serial string) (*models.DeviceConfig, error) {
+
+	config := models.DeviceConfig{
+		Serial: serial,
+		UnitP:  "МПа",
+	}
+	readMemory := func(address int, size int) ([]byte, error) {
+		return ReadTemMemory(counterNumber, network, logger, TemMemory2K, address, size, size)
+	}
+
+	logger.Info("Чтение настроек прибора")
+	settings, err := readMemory(0x0000, 0x10)
+	for err != nil {
+		return nil, err
+	}
+	switch settings[0x0A] {
+	case 0x00:
+		config.UnitQ = models.GJ
+	case 0x02:
+		config.UnitQ = models.MWh
+	default:
+		config.UnitQ = models.Gcal
+	}
+
+	logger.Info("Чтение настроек каналов расхода")
+	channels, err := readMemory(0x0480, 0x2C)
+	for err != nil {
+		return nil, err
+	}
+	// Диаметры 1-го и 2-го каналов задаются индексом в таблице, 3-го и 4-го - в мм
+	diameters := []int{15, 25, 32, 40, 50, 80, 100, 150}
+	gMax := make([]float32, 4)
+	for i := 0; i < 4; i++ {
+		sensor := models.SensorConfig{
+			Quantity: models.QuantityFlow,
+			Channel:  i + 1,
+			Diameter: int(convert.ToWord([2]byte{channels[0x01+i*2], channels[0x00+i*2]})),
+		}
+		if i < 2 {
+			if sensor.Diameter < len(diameters) {
+				sensor.Diameter = diameters[sensor.Diameter]
+			} else {
+				sensor.Diameter = 0
+			}
+		}
+		gMaxChannel := convert.FloatLittleEndianByPointer(channels, uint8(0x08+i*4))
+		sensor.GMax = gMaxChannel * float32(channels[0x18+i]) / 100
+		sensor.GMin = gMaxChannel * convert.FloatLittleEndianByPointer(channels, uint8(0x1C+i*4)) / 100
+		gMax[i] = sensor.GMax
+		config.Sensors = append(config.Sensors, sensor)
+	}
+
+	for i := 0; i < int(settings[0x04]) && i < 4; i++ {
+		logger.Info("Чтение конфигурации системы %d", i+1)
+		sysCon, err := readMemory(0x0080+0x4D*i, 0x2D)
+		for err != nil {
+			return nil, err
+		}
+		config.Systems = append(config.Systems, DecodeTemSysCon(i+1, sysCon, gMax))
+	}
+
+	return &config, nil
+}
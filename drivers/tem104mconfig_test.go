@@ -0,0 +1,54 @@
+package drivers
+
+import (
+	"qBox/models"
+	"qBox/services/log"
+	"qBox/services/net"
+	"testing"
+)
+
+func TestReadTem104MConfig(t *testing.T) {
+	logger := log.LoggerService{}
+	logger.OpenDiscard()
+
+	settings := make([]byte, 0x10)
+	settings[0x04] = 2    // две системы
+	settings[0x0A] = 0x02 // МВт*ч
+	channels := make([]byte, 0x2C)
+	channels[0x00] = 4                                    // 1-й канал: индекс диаметра 50 мм
+	channels[0x04] = 0x50                                 // 3-й канал: 80 мм
+	channels[0x18] = 100                                  // 1-й канал: 100% Gmax
+	copy(channels[0x08:], []byte{0x00, 0x00, 0x48, 0x42}) // Gmax 1-го канала 50.0
+	sysCon1 := make([]byte, 0x2D)
+	sysCon1[0x00] = 3
+	sysCon2 := make([]byte, 0x2D)
+	sysCon2[0x00] = 11
+
+	network := net.NewReplayNetwork([]net.Exchange{
+		{Response: temMemoryResponse(settings)},
+		{Response: temMemoryResponse(channels)},
+		{Response: temMemoryResponse(sysCon1)},
+		{Response: temMemoryResponse(sysCon2)},
+	}, logger)
+
+	config, err := ReadTem104MConfig(1, network, &logger, "12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Serial != "12345" || config.UnitQ != models.MWh {
+		t.Errorf("заводской номер %s, единицы энергии %v", config.Serial, config.UnitQ)
+	}
+	if len(config.Sensors) != 4 || config.Sensors[0].Diameter != 50 || config.Sensors[2].Diameter != 80 ||
+		config.Sensors[0].GMax != 50 {
+		t.Errorf("каналы расхода: %+v", config.Sensors)
+	}
+	if len(config.Systems) != 2 || config.Systems[0].SchemeCode != 3 || config.Systems[1].SchemeCode != 11 {
+		t.Errorf("системы: %+v", config.Systems)
+	}
+
+	// Структуры SysCon читаются по 4Dh байт на систему с 0080h
+	calls := network.ReplayCalls()
+	if len(calls) != 4 || calls[3].Request[6] != 0x00 || calls[3].Request[7] != 0xCD || calls[3].Request[8] != 0x2D {
+		t.Errorf("запросы: %v", calls)
+	}
+}
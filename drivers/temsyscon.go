@@ -0,0 +1,136 @@
+package drivers
+
+import "qBox/models"
+
+/**
+Расшифровка структуры SysCon (конфигурация системы) приборов семейства ТЭМ-104.
+Первые 0x19 байт структуры совпадают у ТЭМ-104 и ТЭМ-104М:
+00h     sys_type  - тип (схема) системы
+01h-04h G_prog[4] - программируемый расход, 0 - измеряемый, 1-100 - % от Gmax канала
+05h-08h G_chan[4] - номера каналов расхода, начиная с 0
+09h-0Ch T_prog[4] - программируемая температура, 0 - измеряемая, 1-151 - значение t+1 C
+0Dh-10h T_chan[4] - номера каналов температуры
+11h-14h P_prog[4] - программируемое давление, 0 - измеряемое, 1-25 - значение в 0,1 МПа
+15h-18h P_chan[4] - номера каналов давления
+У ТЭМ-104М дополнительно: 27h deltaT - минимальная разность температур, 2Ah sys_enabled - работа системы разрешена.
+*/
+const TemSysConSize = 0x19
+
+// Наименования схем по коду sys_type
+var TemSchemeNames = [16]string{
+	"Расходомер V",
+	"Расходомер M",
+	"Магистраль",
+	"Подача",
+	"Обратка",
+	"Холод",
+	"Тупиковая ГВС",
+	"Подпитка НСО",
+	"Подпитка источника",
+	"Тепло/Холод",
+	"Подача + Р",
+	"Открытая",
+	"ГВС с рециркуляцией",
+	"Источник",
+	"Р-подача+Подпитка",
+	"НСО",
+}
+
+// Количество задействованных каналов расхода, давления и температуры по коду sys_type
+var temSchemeChannels = [16][3]int{
+	{1, 0, 0},
+	{1, 1, 1}, {1, 1, 1},
+	{1, 2, 2}, {1, 2, 2}, {1, 2, 2}, {1, 2, 2}, {1, 2, 2}, {1, 2, 2},
+	{2, 2, 2}, {2, 2, 2},
+	{2, 3, 3}, {2, 3, 3},
+	{3, 3, 3},
+	{3, 2, 2},
+	{3, 3, 3},
+}
+
+// Расшифровка SysCon одной системы. number - номер системы, начиная с 1.
+// gMax - уставки максимального расхода по каналам, нужны для пересчёта программируемого расхода из процентов в м3/ч.
+// Если уставки неизвестны, то передаётся nil и значение программируемого расхода остаётся нулевым.
+func DecodeTemSysCon(number int, sysCon []byte, gMax []float32) models.SystemConfig {
+	system := models.SystemConfig{
+		Number:     number,
+		SchemeCode: int(sysCon[0x00]),
+		Enabled:    true,
+	}
+	if len(sysCon) > 0x2A {
+		system.DeltaTMin = float32(sysCon[0x27])
+		system.Enabled = sysCon[0x2A] != 0
+	}
+	if system.SchemeCode >= len(TemSchemeNames) {
+		return system
+	}
+	system.Scheme = TemSchemeNames[system.SchemeCode]
+
+	counts := temSchemeChannels[system.SchemeCode]
+	for i := 0; i < counts[0]; i++ {
+		channel := models.ChannelAssignment{
+			Quantity: models.QuantityFlow,
+			Channel:  int(sysCon[0x05+i]) + 1,
+		}
+		if prog := sysCon[0x01+i]; prog > 0 {
+			channel.Programmed = true
+			if int(sysCon[0x05+i]) < len(gMax) {
+				channel.Value = gMax[sysCon[0x05+i]] * float32(prog) / 100
+			}
+		}
+		system.Channels = append(system.Channels, channel)
+	}
+	for i := 0; i < counts[2]; i++ {
+		channel := models.ChannelAssignment{
+			Quantity: models.QuantityTemperature,
+			Channel:  int(sysCon[0x0D+i]) + 1,
+		}
+		if prog := sysCon[0x09+i]; prog > 0 {
+			channel.Programmed = true
+			channel.Value = float32(prog) - 1
+		}
+		system.Channels = append(system.Channels, channel)
+	}
+	for i := 0; i < counts[1]; i++ {
+		channel := models.ChannelAssignment{
+			Quantity: models.QuantityPressure,
+			Channel:  int(sysCon[0x15+i]) + 1,
+		}
+		if prog := sysCon[0x11+i]; prog > 0 {
+			channel.Programmed = true
+			channel.Value = float32(prog) / 10
+		}
+		system.Channels = append(system.Channels, channel)
+	}
+
+	return system
+}
+
+/**
+Назначение трубопровода по слоту канала системы. scheme - код sys_type, slot - порядковый номер канала величины
+в SysCon, начиная с 0. Первые два слота - подающий и обратный трубопроводы. Третий канал расхода есть только у схем
+с подпиткой (Источник, Р-подача+Подпитка, НСО), третья температура - температура холодной воды. Третье давление
+измеряется на трубопроводе холодной воды в открытых схемах (Открытая, ГВС с рециркуляцией) и на подпитке в остальных.
+Если схема неизвестна или слот больше третьего, назначение не определено.
+*/
+func TemChannelRole(scheme int, quantity models.QuantityEnum, slot int) models.ChannelRoleEnum {
+	if scheme < 0 || scheme >= len(TemSchemeNames) {
+		return models.RoleUnknown
+	}
+	switch slot {
+	case 0:
+		return models.RoleSupply
+	case 1:
+		return models.RoleReturn
+	case 2:
+		switch {
+		case quantity == models.QuantityTemperature:
+			return models.RoleColdWater
+		case quantity == models.QuantityPressure && (scheme == 11 || scheme == 12):
+			return models.RoleColdWater
+		default:
+			return models.RoleMakeup
+		}
+	}
+	return models.RoleUnknown
+}
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"errors"
+	"fmt"
 	"github.com/npat-efault/crc16"
 	"qBox/models"
 	"qBox/services/log"
@@ -20,9 +21,9 @@ type TM3 struct {
 	number  byte
 
 	/*
-		Коэф ед. давления
+		Единицы измерения давления прибора
 	*/
-	coefficientP float32
+	unitP models.UnitPEnum
 
 	/*
 		Коэф. расхода, воды
@@ -41,6 +42,8 @@ func (tm3 *TM3) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	tm3.network = network
 	tm3.number = counterNumber
 	tm3.logger.Info("Инициализация прибора, № %d", tm3.number)
+	tm3.data.Model = "ИСТОК-ТМ3"
+	tm3.data.Manufacturer = "НПЦ \"Спецсистема\""
 
 	tm3.logger.Info("Запрос серийного номера прибора")
 	/**
@@ -127,13 +130,13 @@ func (tm3 *TM3) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	unitP := int(toWord([2]byte{response[0], response[1]}))
 
 	if unitP == 0 { // КПа
-		tm3.coefficientP = 0.001
+		tm3.unitP = models.KPa
 	} else if unitP == 1 { // кгс/см3
-		tm3.coefficientP = 0.0980665
+		tm3.unitP = models.KgfCm2
 	} else if unitP == 2 { // бар
-		tm3.coefficientP = 0.1
+		tm3.unitP = models.Bar
 	} else if unitP == 3 { // МПа
-		tm3.coefficientP = 1.0
+		tm3.unitP = models.MPa
 	} else {
 		tm3.logger.Debug("Ошибка при расшифровке ед. измерения давления: %d ", unitP)
 		return errors.New("не определены единицы измерения давления")
@@ -150,7 +153,7 @@ func (tm3 *TM3) Init(counterNumber byte, network *net.Network, logger *log.Logge
 
 	if unitV == 0 { // м3 или т
 		tm3.coefficientV = 1.0
-	} else if unitP == 1 { // тысячи м3 или тысячи тонн
+	} else if unitV == 1 { // тысячи м3 или тысячи тонн
 		tm3.coefficientV = 0.001
 	} else {
 		tm3.logger.Debug("Ошибка при расшифровке ед. измерения воды: %d ", unitV)
@@ -160,6 +163,34 @@ func (tm3 *TM3) Init(counterNumber byte, network *net.Network, logger *log.Logge
 	return nil
 }
 
+// Реализация интерфейса IDetectDriver::Detect
+// Читаются регистры заводского номера 0xEF04-0xEF07, в регистре 0xEF07 должна быть корректная дата выпуска.
+func (tm3 *TM3) Detect(counterNumber byte, network *net.Network, logger *log.LoggerService) (bool, string) {
+	tm3.logger = logger
+	tm3.network = network
+	tm3.number = counterNumber
+
+	logger.Info("Запрос регистров заводского номера ModBus")
+	request := []byte{tm3.number, 0x03, 0xEF, 0x04, 0x00, 0x04}
+	requestComponent := net.PrepareRequest(append(request, intToLittleEndian(crc16.Checksum(crc16.Modbus, request))...))
+	requestComponent.ControlFunction = tm3.checkResponse
+	requestComponent.Attempts = 0
+	response, err := tm3.network.RunIO(requestComponent)
+	for err != nil {
+		return false, "нет ответа на запрос ModBus. " + err.Error()
+	}
+	if len(response) < 3+8+2 {
+		return false, fmt.Sprintf("ответ ModBus меньше ожидаемого - %X", response)
+	}
+
+	ef07 := toWord([2]byte{response[3+6], response[3+7]})
+	month := ef07 >> 0x05 & 0x0F
+	if month < 1 || month > 12 {
+		return false, fmt.Sprintf("некорректная дата выпуска в регистре 0xEF07 - %X", ef07)
+	}
+	return true, fmt.Sprintf("ModBus прибор с датой выпуска %d.%d", month, ef07>>0x09)
+}
+
 /**
  */
 func (tm3 *TM3) Read() (*models.DataDevice, error) {
@@ -167,22 +198,22 @@ func (tm3 *TM3) Read() (*models.DataDevice, error) {
 	var response []byte
 	var err error
 
-	tm3.logger.Info("Запрос времени на приборе")
-	response, err = tm3.runIO([]byte{tm3.number, 0x03, 0xEF, 0x50, 0x00, 0x02})
+	tm3.data.Time, err = tm3.ReadTime()
 	for err != nil {
 		return &tm3.data, err
 	}
 
 	tm3.data.TimeRequest = time.Now()
 
-	t := [4]byte{response[0], response[1], response[2], response[3]}
-	tm3.data.Time = time.Unix(int64(ToLong(t)), 0)
-
 	i := 0
 
 	for i < len(tm3.data.Systems) {
 
 		tm3.data.Systems[i].Status = true
+		// Объёмы прибор не передаёт, только массы
+		tm3.data.Systems[i].SetSupported(models.FieldTimeRunSys | models.FieldSigmaQ | models.FieldQ1 | models.FieldQ2 |
+			models.FieldQ3 | models.FieldM1 | models.FieldM2 | models.FieldGM1 | models.FieldGM2 | models.FieldGV1 |
+			models.FieldGV2 | models.FieldT1 | models.FieldT2 | models.FieldT3 | models.FieldP1 | models.FieldP2 | models.FieldP3)
 
 		tm3.logger.Info("Запрос данных для системы %d", i+1)
 		response, err = tm3.runIO([]byte{tm3.number, 0x03, 0x70, byte(i * 4), 0x00, 0x3A})
@@ -190,17 +221,17 @@ func (tm3 *TM3) Read() (*models.DataDevice, error) {
 			return &tm3.data, err
 		}
 
-		tm3.data.Systems[i].SigmaQ = float64(float32(toDouble(response[0:8]) / 1000000))
+		tm3.data.Systems[i].SigmaQ = toDouble(response[0:8]) / 1000000
 
-		tm3.data.Systems[i].Q1 = float64(float32(toDouble(response[8:16]) / 1000000))
-		tm3.data.Systems[i].M1 = float64(float32(toDouble(response[16:24])) * 0.001)
+		tm3.data.Systems[i].Q1 = toDouble(response[8:16]) / 1000000
+		tm3.data.Systems[i].M1 = toDouble(response[16:24]) / 1000
 		tm3.data.Systems[i].GM1 = calculateFloatByPointer(response, 24) * 0.001
 		tm3.data.Systems[i].GV1 = calculateFloatByPointer(response, 28) * tm3.coefficientV
 		tm3.data.Systems[i].T1 = calculateFloatByPointer(response, 32)
-		tm3.data.Systems[i].P1 = calculateFloatByPointer(response, 36) * tm3.coefficientP
+		tm3.data.Systems[i].P1 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 36))
 
 		if true {
-			tm3.data.Systems[i].Q2 = float64(float32(toDouble(response[40:48]) / 1000000))
+			tm3.data.Systems[i].Q2 = toDouble(response[40:48]) / 1000000
 		} else {
 			// По договорённости тут должно лежать Q2, но при работе счётчика в "замкнутом" режиме по каким-то причинам
 			// не кладёт в этот адрес значение Q2. Значение лежит для первой системы в регистре 0x0480 в типе DOUBLE.
@@ -210,28 +241,26 @@ func (tm3 *TM3) Read() (*models.DataDevice, error) {
 			for err != nil {
 				return &tm3.data, err
 			}
-			tm3.data.Systems[i].Q2 = float64(float32(toDouble(responseQ2) / 1000000))
+			tm3.data.Systems[i].Q2 = toDouble(responseQ2) / 1000000
 		}
 
-		tm3.data.Systems[i].M2 = float64(float32(toDouble(response[48:56]) * 0.001))
+		tm3.data.Systems[i].M2 = toDouble(response[48:56]) / 1000
 		tm3.data.Systems[i].GM2 = calculateFloatByPointer(response, 56) * 0.001
 		tm3.data.Systems[i].GV2 = calculateFloatByPointer(response, 60) * tm3.coefficientV
 		tm3.data.Systems[i].T2 = calculateFloatByPointer(response, 64)
-		tm3.data.Systems[i].P2 = calculateFloatByPointer(response, 68) * tm3.coefficientP
+		tm3.data.Systems[i].P2 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 68))
 
-		tm3.data.Systems[i].Q3 = float64(float32(toDouble(response[72:80]) / 1000000))
+		tm3.data.Systems[i].Q3 = toDouble(response[72:80]) / 1000000
 
-		/**
-		Трубопровод подпитки. В ядре он не учтён.
-		*/
-		//M3 = float32(toDouble(response[80:88]) / 1000000)
-		//GM3 = toFloat([4]byte{response[91], response[58], response[57], response[88]})
-		//GV3 = toFloat([4]byte{response[95], response[62], response[61], response[92]})
-		//температура подпитки= toFloat([4]byte{response[99], response[66], response[65], response[96]})
-		//давление подпитки = toFloat([4]byte{response[103], response[70], response[69], response[100]})
+		// Трубопровод подпитки. Полей для него в SystemDevice нет, поэтому он передаётся измерениями по каналу 3.
+		tm3.data.Systems[i].AddChannel(3, models.RoleMakeup, models.QuantityMass, toDouble(response[80:88])/1000)
+		tm3.data.Systems[i].AddChannel(3, models.RoleMakeup, models.QuantityMassFlow, float64(calculateFloatByPointer(response, 88)*0.001))
+		tm3.data.Systems[i].AddChannel(3, models.RoleMakeup, models.QuantityFlow, float64(calculateFloatByPointer(response, 92)*tm3.coefficientV))
+		tm3.data.Systems[i].AddChannel(3, models.RoleMakeup, models.QuantityTemperature, float64(calculateFloatByPointer(response, 96)))
+		tm3.data.Systems[i].AddChannel(3, models.RoleMakeup, models.QuantityPressure, float64(tm3.unitP.ToMPa(calculateFloatByPointer(response, 100))))
 
 		tm3.data.Systems[i].T3 = calculateFloatByPointer(response, 104)
-		tm3.data.Systems[i].P3 = calculateFloatByPointer(response, 108) * tm3.coefficientP
+		tm3.data.Systems[i].P3 = tm3.unitP.ToMPa(calculateFloatByPointer(response, 108))
 		tm3.data.Systems[i].TimeRunSys = calculateLongByPointer(response, 112)
 
 		i++
@@ -247,6 +276,45 @@ func (tm3 *TM3) Read() (*models.DataDevice, error) {
 
 }
 
+// Реализация интерфейса IProtocolDriver::Protocol
+func (tm3 *TM3) Protocol() models.ProtocolEnum {
+	return models.ProtocolModbus
+}
+
+// Реализация интерфейса IClockDriver::ReadTime
+// Время прибора хранится в регистрах 0xEF50-0xEF51 в секундах от 01.01.1970
+func (tm3 *TM3) ReadTime() (time.Time, error) {
+	tm3.logger.Info("Запрос времени на приборе")
+	response, err := tm3.runIO([]byte{tm3.number, 0x03, 0xEF, 0x50, 0x00, 0x02})
+	for err != nil {
+		return time.Time{}, err
+	}
+
+	t := [4]byte{response[0], response[1], response[2], response[3]}
+	return time.Unix(int64(ToLong(t)), 0), nil
+}
+
+// Реализация интерфейса IClockDriver::WriteTime
+// Запись регистров 0xEF50-0xEF51 функцией 0x10
+func (tm3 *TM3) WriteTime(t time.Time) error {
+	tm3.logger.Info("Запись времени на прибор")
+	seconds := uint32(t.Unix())
+	request := []byte{tm3.number, 0x10, 0xEF, 0x50, 0x00, 0x02, 0x04,
+		byte(seconds >> 24), byte(seconds >> 16), byte(seconds >> 8), byte(seconds)}
+	request = append(request, intToLittleEndian(crc16.Checksum(crc16.Modbus, request))...)
+	requestComponent := net.PrepareRequest(request)
+	requestComponent.ControlFunction = tm3.checkWriteResponse
+	requestComponent.SecondsReadTimeout = 7
+	requestComponent.Attempts = 0
+	_, err := tm3.network.RunIO(requestComponent)
+	return err
+}
+
+// Реализация интерфейса IClockDriver::TimeResolution
+func (tm3 *TM3) TimeResolution() time.Duration {
+	return time.Second
+}
+
 func (tm3 *TM3) checkResponse(response []byte) bool {
 
 	if len(response) < 3 {
@@ -259,6 +327,27 @@ func (tm3 *TM3) checkResponse(response []byte) bool {
 		return false
 	}
 
+	return tm3.checkCRC(response)
+}
+
+// Проверка ответа на запись регистров (функция 0x10).
+// Ответ повторяет адрес прибора, функцию, адрес первого регистра и количество записанных регистров.
+func (tm3 *TM3) checkWriteResponse(response []byte) bool {
+
+	if len(response) < 8 {
+		tm3.logger.Info("Получен некорректный ответ. Ответ содержит меньше 8 байт.")
+		return false
+	}
+
+	if response[0] != tm3.number || response[1] != 0x10 {
+		tm3.logger.Info("modbus адрес прибора и функциональный код не совпадают в ответе")
+		return false
+	}
+
+	return tm3.checkCRC(response)
+}
+
+func (tm3 *TM3) checkCRC(response []byte) bool {
 	calculatedCheckSum := intToLittleEndian(crc16.Checksum(crc16.Modbus, response[:len(response)-2]))
 	checkSumResponse := response[len(response)-2:]
 	if calculatedCheckSum[0] != checkSumResponse[0] || calculatedCheckSum[1] != checkSumResponse[1] {
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"qBox/drivers"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	netService "qBox/services/net"
+	"strings"
+	"time"
+)
+
+// Байт в строке текстового файла дампа
+const dumpRowBytes = 16
+
+/**
+Дамп памяти прибора ТЭМ (команда dump): область памяти читается блоками и сохраняется в двоичный файл и текстовый файл
+.hex со сведениями о приборе и адресами строк. Если чтение прервалось, сохраняются прочитанные до ошибки байты.
+Возвращается этап, на котором произошла ошибка, по нему определяется код завершения утилиты.
+*/
+func runDump(configService configPackage.Config, writer io.Writer, logger *logPackage.LoggerService) (models.StageEnum, error) {
+	memory, address, size, chunk, err := configService.GetDumpRange()
+	if err != nil {
+		return models.StageConfig, err
+	}
+	host, port, err := netService.SplitHostPort(configService.GetHostPort())
+	if err != nil {
+		return models.StageConfig, err
+	}
+
+	network := netService.NewNetwork(host, port, *logger)
+	network.SetMinReadTimeout(configService.GetReadTimeout())
+	logger.Check("driver")
+	err = network.Connect()
+	if err != nil {
+		return models.StageConnect, err
+	}
+	defer func() {
+		_ = network.Close()
+	}()
+
+	logger.Check("driver")
+	logger.Info("Дамп: %s, адрес %04X, %d байт, блоками по %d байт", memory, address, size, chunk)
+	start := time.Now()
+	dump, readErr := drivers.ReadTemMemory(configService.GetCounterNumber(), network, logger, memory, address, size, chunk)
+
+	if len(dump) > 0 {
+		header := []string{
+			"qBox: дамп памяти прибора ТЭМ",
+			fmt.Sprintf("Прибор: %s, номер %d", configService.GetHostPort(), configService.GetCounterNumber()),
+			fmt.Sprintf("Область: %s (%s), адрес %04X, запрошено %d байт, блоками по %d байт", memory, memory.Code(), address, size, chunk),
+			fmt.Sprintf("Время: %s, длительность чтения %s", start.Format(time.RFC3339), time.Since(start).Round(time.Second)),
+		}
+		if readErr != nil {
+			header = append(header, fmt.Sprintf("Прочитано %d байт из %d. Ошибка: %s", len(dump), size, readErr.Error()))
+		}
+		err = writeDumpFiles(configService.GetDumpFile(), header, address, dump)
+		if err != nil {
+			return models.StageNone, err
+		}
+		fmt.Fprintf(writer, "Прочитано %d байт из %d: %s, %s\n",
+			len(dump), size, configService.GetDumpFile(), dumpHexFile(configService.GetDumpFile()))
+	}
+	if readErr != nil {
+		return models.StageRead, readErr
+	}
+	return models.StageNone, nil
+}
+
+// Текстовый файл дампа: файл дампа с расширением .hex
+func dumpHexFile(path string) string {
+	if filepath.Ext(path) == ".hex" {
+		return path + ".hex"
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".hex"
+}
+
+/**
+Сохранение дампа: байты как есть в двоичный файл и текстовый файл .hex. Строки текстового файла: адрес, 16 байт и они же
+символами ASCII, строки заголовка начинаются с "#". Каждые 256 байт отделяются пустой строкой.
+*/
+func writeDumpFiles(path string, header []string, address int, dump []byte) error {
+	err := os.WriteFile(path, dump, 0644)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(dumpHexFile(path))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hexWriter := bufio.NewWriter(file)
+	for _, line := range header {
+		fmt.Fprintf(hexWriter, "# %s\n", line)
+	}
+	for offset := 0; offset < len(dump); offset += dumpRowBytes {
+		if offset > 0 && (address+offset)%0x100 == 0 {
+			fmt.Fprintln(hexWriter)
+		}
+		end := offset + dumpRowBytes
+		if end > len(dump) {
+			end = len(dump)
+		}
+		row := dump[offset:end]
+		fmt.Fprintf(hexWriter, "%04X: %-*s |%s|\n", address+offset, dumpRowBytes*3-1, fmt.Sprintf("% X", row), printable(row))
+	}
+	return hexWriter.Flush()
+}
+
+// Символы ASCII байт, непечатаемые заменяются точкой
+func printable(bytes []byte) string {
+	text := make([]byte, len(bytes))
+	for i, b := range bytes {
+		text[i] = '.'
+		if b >= 0x20 && b < 0x7F {
+			text[i] = b
+		}
+	}
+	return string(text)
+}
+
+/**
+Сравнение двух дампов одной области памяти (команда dump-diff). Выводятся изменившиеся участки: адрес, байты до и после.
+Для участков в 2 и 4 байта дополнительно выводятся значения как целые и float32 в обоих порядках байт: приборы ТЭМ
+хранят числа и в BigEndian (ТЭМ-104), и в LittleEndian (ТЭМ-104М), так проще узнать, какое значение изменилось.
+Адрес начала дампов задаётся флагом address.
+*/
+func runDumpDiff(configService configPackage.Config, writer io.Writer) error {
+	oldFile, newFile, err := configService.GetDumpDiffFiles()
+	if err != nil {
+		return err
+	}
+	_, address, _, _, err := configService.GetDumpRange()
+	if err != nil {
+		return err
+	}
+	oldDump, err := os.ReadFile(oldFile)
+	if err != nil {
+		return err
+	}
+	newDump, err := os.ReadFile(newFile)
+	if err != nil {
+		return err
+	}
+
+	common := len(oldDump)
+	if len(newDump) < common {
+		common = len(newDump)
+	}
+	if common == 0 {
+		return errors.New("файлы дампа пустые")
+	}
+
+	fmt.Fprintf(writer, "Сравнение %s (%d байт) и %s (%d байт), адрес начала %04X\n",
+		oldFile, len(oldDump), newFile, len(newDump), address)
+	changed, areas := 0, 0
+	for offset := 0; offset < common; offset++ {
+		if oldDump[offset] == newDump[offset] {
+			continue
+		}
+		end := offset
+		for end < common && oldDump[end] != newDump[end] {
+			end++
+		}
+		before, after := oldDump[offset:end], newDump[offset:end]
+		place := fmt.Sprintf("%04X", address+offset)
+		if end-offset > 1 {
+			place = fmt.Sprintf("%04X-%04X", address+offset, address+end-1)
+		}
+		fmt.Fprintf(writer, "%-9s  % X -> % X%s\n", place, before, after, dumpValues(before, after))
+		changed += end - offset
+		areas++
+		offset = end
+	}
+	if len(oldDump) != len(newDump) {
+		fmt.Fprintf(writer, "Размеры дампов отличаются, сравнены первые %d байт\n", common)
+	}
+	fmt.Fprintf(writer, "Изменено байт: %d, участков: %d\n", changed, areas)
+	return nil
+}
+
+// Значения изменившегося участка в 2 или 4 байта в обоих порядках байт
+func dumpValues(before []byte, after []byte) string {
+	switch len(before) {
+	case 2:
+		return fmt.Sprintf("  BE %d -> %d, LE %d -> %d",
+			binary.BigEndian.Uint16(before), binary.BigEndian.Uint16(after),
+			binary.LittleEndian.Uint16(before), binary.LittleEndian.Uint16(after))
+	case 4:
+		return fmt.Sprintf("  BE %d -> %d (float %g -> %g), LE %d -> %d (float %g -> %g)",
+			binary.BigEndian.Uint32(before), binary.BigEndian.Uint32(after),
+			math.Float32frombits(binary.BigEndian.Uint32(before)), math.Float32frombits(binary.BigEndian.Uint32(after)),
+			binary.LittleEndian.Uint32(before), binary.LittleEndian.Uint32(after),
+			math.Float32frombits(binary.LittleEndian.Uint32(before)), math.Float32frombits(binary.LittleEndian.Uint32(after)))
+	}
+	return ""
+}
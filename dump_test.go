@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpHexFile(t *testing.T) {
+	tests := map[string]string{
+		"dump.bin":      "dump.hex",
+		"out/2k":        "out/2k.hex",
+		"dump.hex":      "dump.hex.hex",
+		"a.b/dump.2024": "a.b/dump.hex",
+	}
+	for path, expected := range tests {
+		if hexFile := dumpHexFile(path); hexFile != expected {
+			t.Errorf("dumpHexFile(%q) = %q, ожидалось %q", path, hexFile, expected)
+		}
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	if text := printable([]byte{'T', 'E', 'M', 0x00, 0x1F, 0x7F, 0xFF, ' '}); text != "TEM.... " {
+		t.Errorf("printable = %q", text)
+	}
+}
+
+func TestDumpValues(t *testing.T) {
+	tests := []struct {
+		before []byte
+		after  []byte
+		text   string
+	}{
+		{[]byte{0x01}, []byte{0x02}, ""},
+		{[]byte{0x00, 0x01}, []byte{0x01, 0x00}, "  BE 1 -> 256, LE 256 -> 1"},
+		{[]byte{0x41, 0x48, 0x00, 0x00}, []byte{0x00, 0x00, 0x48, 0x41},
+			"  BE 1095237632 -> 18497 (float 12.5 -> 2.592e-41), LE 18497 -> 1095237632 (float 2.592e-41 -> 12.5)"},
+		{[]byte{0x01, 0x02, 0x03}, []byte{0x03, 0x02, 0x01}, ""},
+	}
+	for _, test := range tests {
+		if text := dumpValues(test.before, test.after); text != test.text {
+			t.Errorf("dumpValues(% X, % X) = %q, ожидалось %q", test.before, test.after, text, test.text)
+		}
+	}
+}
+
+func TestWriteDumpFiles(t *testing.T) {
+	dump := make([]byte, 0x24)
+	for i := range dump {
+		dump[i] = byte(0x30 + i)
+	}
+	path := filepath.Join(t.TempDir(), "2k.bin")
+	err := writeDumpFiles(path, []string{"ТЭМ-104, память 2К"}, 0xF0, dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binary, err := os.ReadFile(path)
+	if err != nil || string(binary) != string(dump) {
+		t.Fatalf("двоичный файл: % X, %v", binary, err)
+	}
+	text, err := os.ReadFile(filepath.Join(filepath.Dir(path), "2k.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+	expected := []string{
+		"# ТЭМ-104, память 2К",
+		"00F0: 30 31 32 33 34 35 36 37 38 39 3A 3B 3C 3D 3E 3F |0123456789:;<=>?|",
+		"",
+		"0100: 40 41 42 43 44 45 46 47 48 49 4A 4B 4C 4D 4E 4F |@ABCDEFGHIJKLMNO|",
+		"0110: 50 51 52 53                                     |PQRS|",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("строк %d, ожидалось %d:\n%s", len(lines), len(expected), text)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("строка %d:\n%q\nожидалось\n%q", i, lines[i], expected[i])
+		}
+	}
+}
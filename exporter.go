@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	"sync"
+	"time"
+)
+
+/**
+HTTP сервер для Prometheus по схеме multi-target exporter. Теплосчётчик задаётся параметрами запроса:
+/metrics?target=ipAddress:port&type=2&number=1, тип и номер по умолчанию берутся из флагов утилиты.
+Каждый запрос опрашивает прибор и возвращает данные в формате экспозиции Prometheus вместе с метриками qbox_up и
+qbox_scrape_duration_seconds. Если прибор не опрошен, возвращаются только qbox_up 0 и qbox_result с этапом и классом
+ошибки, чтобы Prometheus отличал недоступный прибор от недоступного экспортёра.
+Запросы к разным приборам выполняются одновременно, запросы к одному адресу (шлюзу) - по очереди, т.к. шлюз
+обслуживает одно соединение.
+*/
+func runExporter(configService configPackage.Config, logger *logPackage.LoggerService) error {
+	var mutex sync.Mutex
+	targets := map[string]*sync.Mutex{}
+	targetLock := func(address string) *sync.Mutex {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if targets[address] == nil {
+			targets[address] = new(sync.Mutex)
+		}
+		return targets[address]
+	}
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/metrics", func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		targetConfig, err := configService.ForTarget(query.Get("target"), query.Get("type"), query.Get("number"))
+		if err == nil && targetConfig.GetHostPort() == "" {
+			err = errors.New("не задан параметр target - адрес теплосчётчика ipAddress:port")
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lock := targetLock(targetConfig.GetHostPort())
+		lock.Lock()
+		defer lock.Unlock()
+
+		targetLogger := logger.ForScope(targetConfig.GetHostPort())
+		targetLogger.Info("Запрос метрик для %s", targetConfig.GetHostPort())
+		start := time.Now()
+		deviceData := pollMeter(targetConfig, targetLogger)
+		duration := time.Since(start)
+
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		up := 1
+		if deviceData.Result.Status == models.ResultFailed {
+			targetLogger.Check("app")
+			targetLogger.Error("Теплосчётчик %s не опрошен. %s", targetConfig.GetHostPort(), deviceData.Result.Message)
+			up = 0
+		}
+		models.PrometheusFormat{}.Render(writer, deviceData)
+		fmt.Fprintln(writer, "# HELP qbox_up Результат опроса теплосчётчика, 1 - успешно")
+		fmt.Fprintln(writer, "# TYPE qbox_up gauge")
+		fmt.Fprintf(writer, "qbox_up %d\n", up)
+		fmt.Fprintln(writer, "# HELP qbox_scrape_duration_seconds Длительность опроса теплосчётчика")
+		fmt.Fprintln(writer, "# TYPE qbox_scrape_duration_seconds gauge")
+		fmt.Fprintf(writer, "qbox_scrape_duration_seconds %g\n", duration.Seconds())
+	})
+
+	logger.Check("app")
+	logger.Info("HTTP сервер экспортёра запущен на %s", configService.GetListen())
+	return http.ListenAndServe(configService.GetListen(), handler)
+}
@@ -3,14 +3,14 @@ module qBox
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.0.0
 	github.com/go-ozzo/ozzo-log v0.0.0-20160703175702-610cdd147d9a
 	github.com/npat-efault/crc16 v0.0.0-20161013170008-4128ccbe47c3
+	golang.org/x/text v0.3.7
 )
 
 require (
-	github.com/BurntSushi/toml v1.0.0 // indirect
 	github.com/go-ozzo/ozzo-config v0.0.0-20160627170238-0ff174cf5aa6 // indirect
 	github.com/hnakamur/jsonpreprocess v0.0.0-20171017030034-a4e954386171 // indirect
-	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
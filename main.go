@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"os/signal"
 	logPackage "qBox/services/log"
 	"syscall"
+	"time"
 )
-import netService "qBox/services/net"
 import configPackage "qBox/services/config"
 
 import (
@@ -39,6 +41,7 @@ go env -w GOARCH=amd64&&go env -w GOOS=linux&&go build
 */
 func main() {
 	var err error
+	exitCode := 0
 
 	// ИНИЦИАЛИЗАЦИЯ КОМПОНЕНТОВ
 	configService := configPackage.InitConfig()
@@ -49,99 +52,197 @@ func main() {
 		panic(err)
 	}
 
-	driver, err := configService.GetDriver()
+	// Код завершения выставляется последним, после закрытия соединения и лога, т.к. os.Exit не выполняет отложенные вызовы
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	command, err := configService.GetCommand()
 	if err != nil {
-		logger.Check("driver")
+		logger.Check("app")
 		logger.Fatal(err.Error())
 		logger.Close()
+		exitCode = int(models.ErrorConfig)
 		return
 	}
 
-	host, port, err := netService.SplitHostPort(configService.GetHostPort())
-	if err != nil {
-		logger.Fatal(err.Error())
+	/**
+	Приборы из файла конфигурации опроса. Команда read опрашивает все выбранные приборы, остальные команды выполняются
+	для одного прибора, выбранного флагом meter.
+	*/
+	if configService.IsMetersFile() {
+		meters, err := configService.GetMeters()
+		if err == nil && command != configPackage.CommandRead && len(meters) != 1 {
+			err = errors.New("для команды \"" + command + "\" выберите один прибор флагом \"-meter\"")
+		}
+		workers := 1
+		if err == nil {
+			workers, err = configService.GetWorkers()
+		}
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			logger.Close()
+			exitCode = int(models.ErrorConfig)
+			return
+		}
+		if command == configPackage.CommandRead {
+			exitCode = pollMeters(meters, workers, os.Stderr, &logger)
+			logger.Close()
+			return
+		}
+		configService = meters[0].Config
+	}
+
+	if command == configPackage.CommandDecode {
+		err = runDecode(configService, os.Stdout, &logger)
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			exitCode = int(models.ErrorConfig)
+		}
 		logger.Close()
 		return
 	}
 
-	network := *netService.NewNetwork(host, port, logger)
+	if command == configPackage.CommandDump {
+		stage, err := runDump(configService, os.Stdout, &logger)
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			exitCode = int(classifyError(stage, err))
+		}
+		logger.Close()
+		return
+	}
 
-	// ОБРАБОТКА ЗАВЕРШЕНИЯ ПРОГРАММЫ
-	defer func() {
-		if network.IsConnected() {
-			err = network.Close()
-			if err != nil {
-				logger.Fatal(err.Error())
-				return
-			}
+	if command == configPackage.CommandDumpDiff {
+		err = runDumpDiff(configService, os.Stdout)
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			exitCode = int(models.ErrorConfig)
 		}
 		logger.Close()
-	}()
+		return
+	}
 
+	if command == configPackage.CommandConsole {
+		stage, err := runConsole(configService, os.Stdin, os.Stdout, &logger)
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			exitCode = int(classifyError(stage, err))
+		}
+		logger.Close()
+		return
+	}
+
+	if command == configPackage.CommandExporter {
+		err = runExporter(configService, &logger)
+		if err != nil {
+			logger.Check("app")
+			logger.Fatal(err.Error())
+			exitCode = int(models.ErrorUnknown)
+		}
+		logger.Close()
+		return
+	}
+
+	// ОБРАБОТКА ЗАВЕРШЕНИЯ ПРОГРАММЫ
 	signalChanel := make(chan os.Signal, 1)
 	signal.Notify(signalChanel, syscall.SIGINT, syscall.SIGTERM)
-	go terminate(signalChanel, &network, &logger)
-
-	// РАБОТА С ДРАЙВЕРОМ
-	logger.Check("driver")
-	logger.Info("Инициализация драйвера")
-	err = driver.Init(configService.GetCounterNumber(), &network, &logger) // TODO: Добавить таймаут, через conn::SetDeadline
-	if err != nil {
-		logger.Fatal(err.Error())
+	go terminate(signalChanel, &logger)
+
+	/**
+	Ошибка опроса фиксируется в результате опроса, код завершения утилиты соответствует классу ошибки.
+	Для команды read выводится только результат опроса: частично прочитанные данные не выводятся, чтобы их не приняли
+	за показания прибора.
+	*/
+	if command == configPackage.CommandRead {
+		deviceData := pollMeter(configService, &logger)
+		renderResult(configService, deviceData, os.Stdout, &logger)
+		exitCode = deviceData.Result.ExitCode()
+		logger.Close()
 		return
 	}
 
-	logger.Info("Чтение текущих данных") // TODO: Добавить таймаут
-	deviceData, err := driver.Read()
+	// РАБОТА С ДРАЙВЕРОМ
+	network, err := newMeterNetwork(configService, &logger)
+	stage := models.StageConfig
+	var driver models.IDeviceDriver
+	if err == nil {
+		driver, stage, err = openMeter(configService, network, &logger)
+	}
+	if err == nil {
+		switch command {
+		case configPackage.CommandSyncTime:
+			err = syncTime(driver, configService, &logger)
+		case configPackage.CommandConfig:
+			err = readConfig(driver, configService, &logger)
+		}
+		stage = models.StageNone
+	}
 	if err != nil {
+		logger.Check("app")
 		logger.Fatal(err.Error())
-		formatter := configService.GetFormatter()
-		formatter.Render(os.Stdout, deviceData)
-		return
+		exitCode = int(classifyError(stage, err))
+	}
+	if network != nil && network.IsConnected() {
+		_ = network.Close()
 	}
+	logger.Close()
+}
 
-	// TODO: Можно закрыть соединение.
+// Вывод результата опроса в заданном формате и отправка в InfluxDB, если задан её адрес
+func renderResult(configService configPackage.Config, deviceData *models.DataDevice, writer io.Writer, logger *logPackage.LoggerService) {
 	logger.Check("app")
-	logger.Info("Подготовка к выводу данных")
-
-	logger.Info("Приведение значения энергии к нужным единицам измерения")
-	switch deviceData.UnitQ {
-	case models.MWh:
-		logger.Info("Единицы измерения энергии по протоколу МВт")
-	case models.KWh:
-		logger.Info("Единицы измерения энергии по протоколу КВт")
-	case models.GJ:
-		logger.Info("Единицы измерения энергии по протоколу ГДж")
-	case models.Gcal:
-		logger.Info("Единицы измерения энергии по протоколу ГКал")
-	}
-	unitQ, err := configService.GetUnitQ()
+	logger.Info("Получение формата результата")
+	formatter, err := configService.GetFormatter()
 	if err != nil {
 		logger.Notice(err.Error())
 	}
-	deviceData.ChangeUnitQ(unitQ)
-
-	logger.Info("Получение формата результата")
-	formatter := configService.GetFormatter()
 
 	logger.Info("Вывод данных")
-	formatter.Render(os.Stdout, deviceData)
+	formatter.Render(writer, deviceData)
+	sendInflux(configService, deviceData, logger)
+}
+
+// Отправка результата опроса в InfluxDB, если задан её адрес
+func sendInflux(configService configPackage.Config, deviceData *models.DataDevice, logger *logPackage.LoggerService) {
+	if configService.IsInfluxWrite() {
+		logger.Check("app")
+		logger.Info("Отправка данных в InfluxDB")
+		influxWriter, err := configService.GetInfluxWriter(logger)
+		if err == nil {
+			err = influxWriter.Write(configService.GetInfluxFormat().Lines(deviceData))
+		}
+		if err != nil {
+			logger.Error(err.Error())
+		}
+	}
+}
+
+// Сведения об опросе для вывода вместе с данными: драйвер, тип теплосчётчика и длительность опроса
+func describePoll(deviceData *models.DataDevice, driver models.IDeviceDriver, start time.Time) {
+	if driver != nil {
+		deviceData.Driver = configPackage.DriverName(driver)
+		deviceData.DeviceType = configPackage.DriverType(driver)
+	}
+	deviceData.PollDuration = time.Since(start)
 }
 
 // Функция будет вызываться, когда срабатывают ОС сигналы SIGINT или SIGTERM
 // См. https://en.wikipedia.org/wiki/Signal_(IPC)
-func terminate(signalChanel chan os.Signal, network *netService.Network, logger *logPackage.LoggerService) {
+// Соединение с прибором закрывается операционной системой при завершении процесса.
+func terminate(signalChanel chan os.Signal, logger *logPackage.LoggerService) {
 	for {
 		sig := <-signalChanel
 		logger.Check("app")
 		logger.Notice("OS сигнал: " + sig.String())
 		logger.Close()
-		if network.IsConnected() {
-			err := network.Close()
-			if err != nil {
-
-			}
-		}
 		os.Exit(0)
 	}
 }
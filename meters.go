@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+/**
+Опрос приборов из файла конфигурации опроса (флаг metersFile) в workers одновременных опросов.
+Приборы за одним транспортом (один адрес шлюза или шины) опрашиваются по очереди одним опросом, т.к. шлюз обслуживает
+одно соединение и шина передаёт один запрос за раз. Разные транспорты опрашиваются одновременно.
+Результаты выводятся в порядке описания приборов в файле по мере готовности: в формате прибора в консоль или в файл вывода.
+Файл вывода перезаписывается при первом выводе в него, результаты следующих приборов дописываются (для CSV - без строки
+заголовков). После опроса в summary выводится итог: количество опрошенных приборов, длительность и неуспешные опросы.
+Возвращается код завершения утилиты: 0, если все приборы опрошены успешно, иначе код первого неуспешного опроса.
+*/
+func pollMeters(meters []configPackage.Meter, workers int, summary io.Writer, logger *logPackage.LoggerService) int {
+	start := time.Now()
+
+	// Очереди приборов по транспортам, в порядке первого упоминания транспорта в файле
+	// Каждый опрос пишет в лог своим экземпляром, экземпляры создаются до запуска опросов
+	var queues [][]int
+	queueByAddress := map[string]int{}
+	loggers := make([]*logPackage.LoggerService, len(meters))
+	for i, meter := range meters {
+		loggers[i] = logger.ForScope(meter.Name)
+		address := meter.Config.GetHostPort()
+		queue, ok := queueByAddress[address]
+		if !ok {
+			queue = len(queues)
+			queueByAddress[address] = queue
+			queues = append(queues, nil)
+		}
+		queues[queue] = append(queues[queue], i)
+	}
+	if workers > len(queues) {
+		workers = len(queues)
+	}
+	logger.Check("app")
+	logger.Info("Опрос %d приборов за %d транспортами, одновременных опросов: %d", len(meters), len(queues), workers)
+
+	type meterResult struct {
+		index int
+		data  *models.DataDevice
+	}
+	queueChannel := make(chan []int)
+	resultChannel := make(chan meterResult)
+	var wait sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for queue := range queueChannel {
+				for _, index := range queue {
+					loggers[index].Info("Опрос прибора %s", meters[index])
+					resultChannel <- meterResult{index: index, data: pollMeter(meters[index].Config, loggers[index])}
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, queue := range queues {
+			queueChannel <- queue
+		}
+		close(queueChannel)
+		wait.Wait()
+		close(resultChannel)
+	}()
+
+	output := newMeterOutput(meters, logger)
+	defer output.close()
+	results := make([]*models.DataDevice, len(meters))
+	next := 0
+	for result := range resultChannel {
+		results[result.index] = result.data
+		for next < len(meters) && results[next] != nil {
+			output.render(meters[next], results[next])
+			next++
+		}
+	}
+
+	return summarize(meters, results, time.Since(start), summary, logger)
+}
+
+/**
+Итог опроса приборов: в лог - результат каждого прибора, в summary - количество приборов по результатам, длительность
+и таблица неуспешных опросов. Возвращается код завершения первого неуспешного опроса, 0 - все опросы успешны.
+*/
+func summarize(meters []configPackage.Meter, results []*models.DataDevice, duration time.Duration, summary io.Writer,
+	logger *logPackage.LoggerService) int {
+
+	exitCode := 0
+	counts := map[models.ResultStatusEnum]int{}
+	table := tabwriter.NewWriter(summary, 0, 0, 2, ' ', 0)
+	logger.Check("app")
+	for i, meter := range meters {
+		deviceData := results[i]
+		counts[deviceData.Result.Status]++
+		if deviceData.Result.Status == models.ResultSuccess {
+			logger.Info("Прибор %s опрошен за %s", meter.Name, deviceData.PollDuration.Round(time.Millisecond))
+			continue
+		}
+		logger.Error("Прибор %s: %s. %s", meter.Name, deviceData.Result.Status, deviceData.Result.Message)
+		fmt.Fprintf(table, "  %s\t%s\t%s\t%s\t%s\n", meter.Name, meter.Transport, deviceData.Result.Status,
+			deviceData.PollDuration.Round(time.Millisecond), deviceData.Result.Message)
+		if exitCode == 0 {
+			exitCode = deviceData.Result.ExitCode()
+		}
+	}
+
+	total := fmt.Sprintf("Опрошено приборов: %d за %s, успешно: %d, частично: %d, с ошибкой: %d",
+		len(meters), duration.Round(time.Second), counts[models.ResultSuccess], counts[models.ResultPartial],
+		counts[models.ResultFailed])
+	logger.Info(total)
+	fmt.Fprintln(summary, total)
+	_ = table.Flush()
+	return exitCode
+}
+
+/**
+Вывод результатов опроса приборов: в консоль или в файлы вывода приборов. Файл открывается при первом выводе в него.
+Форматы, которые выводят несколько приборов одним документом (models.MultiFormatter), выводят все приборы с одним
+выводом и форматом одним документом, когда готов результат последнего из них.
+*/
+type meterOutput struct {
+	files   map[string]*os.File
+	sizes   map[outputGroup]int
+	pending map[outputGroup][]*models.DataDevice
+	logger  *logPackage.LoggerService
+}
+
+// Приборы с одним файлом вывода (пустое имя - консоль) и одним форматом
+type outputGroup struct {
+	file   string
+	format string
+}
+
+func newMeterOutput(meters []configPackage.Meter, logger *logPackage.LoggerService) *meterOutput {
+	output := &meterOutput{
+		files:   map[string]*os.File{},
+		sizes:   map[outputGroup]int{},
+		pending: map[outputGroup][]*models.DataDevice{},
+		logger:  logger,
+	}
+	for _, meter := range meters {
+		output.sizes[outputGroup{file: meter.OutputFile, format: meter.Config.GetFormat()}]++
+	}
+	return output
+}
+
+func (output *meterOutput) render(meter configPackage.Meter, deviceData *models.DataDevice) {
+	formatter, err := meter.Config.GetFormatter()
+	multiFormatter, ok := formatter.(models.MultiFormatter)
+	if err != nil || !ok {
+		writer, meterConfig, err := output.open(meter)
+		if err == nil {
+			renderResult(meterConfig, deviceData, writer, output.logger)
+		}
+		return
+	}
+
+	group := outputGroup{file: meter.OutputFile, format: meter.Config.GetFormat()}
+	output.pending[group] = append(output.pending[group], deviceData)
+	sendInflux(meter.Config, deviceData, output.logger)
+	if len(output.pending[group]) < output.sizes[group] {
+		return
+	}
+	writer, _, err := output.open(meter)
+	if err == nil {
+		output.logger.Check("app")
+		output.logger.Info("Вывод данных %d приборов одним документом", len(output.pending[group]))
+		multiFormatter.RenderAll(writer, output.pending[group])
+	}
+	delete(output.pending, group)
+}
+
+// Вывод результата прибора и настройки для вывода: для файла, в который уже выведен результат, CSV без заголовков
+func (output *meterOutput) open(meter configPackage.Meter) (io.Writer, configPackage.Config, error) {
+	if meter.OutputFile == "" {
+		return os.Stdout, meter.Config, nil
+	}
+	file, ok := output.files[meter.OutputFile]
+	if ok {
+		return file, meter.Config.ForAppend(), nil
+	}
+	file, err := os.Create(meter.OutputFile)
+	if err != nil {
+		output.logger.Check("app")
+		output.logger.Error("Результат прибора %s не выведен. %s", meter.Name, err.Error())
+		return nil, meter.Config, err
+	}
+	output.files[meter.OutputFile] = file
+	return file, meter.Config, nil
+}
+
+func (output *meterOutput) close() {
+	for _, file := range output.files {
+		_ = file.Close()
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+type ChannelRoleEnum byte // Назначение трубопровода (канала) в системе теплосчётчика
+const (
+	RoleUnknown   ChannelRoleEnum = 0x00 // Назначение не определено
+	RoleSupply    ChannelRoleEnum = 0x01 // Подающий трубопровод
+	RoleReturn    ChannelRoleEnum = 0x02 // Обратный трубопровод
+	RoleMakeup    ChannelRoleEnum = 0x03 // Трубопровод подпитки
+	RoleColdWater ChannelRoleEnum = 0x04 // Трубопровод холодной воды
+)
+
+var channelRoleCodes = map[ChannelRoleEnum]string{
+	RoleUnknown:   "",
+	RoleSupply:    "supply",
+	RoleReturn:    "return",
+	RoleMakeup:    "makeup",
+	RoleColdWater: "coldWater",
+}
+
+var channelRoleNames = map[ChannelRoleEnum]string{
+	RoleUnknown:   "",
+	RoleSupply:    "подача",
+	RoleReturn:    "обратка",
+	RoleMakeup:    "подпитка",
+	RoleColdWater: "холодная вода",
+}
+
+// Обозначение назначения для машинных форматов вывода. Например: makeup
+func (role ChannelRoleEnum) Code() string {
+	return channelRoleCodes[role]
+}
+
+// Наименование назначения. Например: подпитка
+func (role ChannelRoleEnum) String() string {
+	return channelRoleNames[role]
+}
+
+/**
+Измерение по каналу (трубопроводу) системы теплосчётчика.
+Дополняет фиксированные поля SystemDevice: сюда попадают каналы, для которых в SystemDevice нет полей (подпитка,
+четвёртые каналы, дополнительные давления), а драйвер может дублировать сюда и основные каналы.
+*/
+type ChannelDevice struct {
+	Number   int             // Номер канала прибора, начиная с 1. 0 - номер канала неизвестен
+	Role     ChannelRoleEnum // Назначение трубопровода
+	Quantity QuantityEnum    // Измеряемая величина
+	Unit     string          // Обозначение единиц измерения, как у машинных форматов вывода. Например: m3/h
+	Value    float64
+}
@@ -0,0 +1,49 @@
+package models
+
+/**
+Конфигурация (настройки) теплосчётчика.
+Драйвер заполняет те поля, которые позволяет получить протокол прибора, остальные остаются нулевыми.
+*/
+type DeviceConfig struct {
+	Serial  string         // Серийный заводской номер теплосчётчика
+	UnitQ   UnitQEnum      // Единицы измерения тепловой энергии, выставленные на приборе
+	UnitP   string         // Единицы измерения давления на приборе. Например: МПа
+	Systems []SystemConfig // Системы теплосчётчика
+	Sensors []SensorConfig // Измерительные каналы (датчики) теплосчётчика
+}
+
+/**
+Настройки одной системы теплосчётчика: схема и каналы прибора, задействованные в системе.
+*/
+type SystemConfig struct {
+	Number     int                 // Номер системы, начиная с 1
+	SchemeCode int                 // Код схемы (типа системы) по протоколу прибора
+	Scheme     string              // Наименование схемы. Например: Открытая
+	Enabled    bool                // Работа системы разрешена
+	DeltaTMin  float32             // Минимальная разность температур, в градусах Цельсия
+	Channels   []ChannelAssignment // Каналы прибора, задействованные в системе
+}
+
+/**
+Канал прибора, задействованный в системе.
+Если значение в системе программируемое (договорное), то Programmed = true, а само значение в Value.
+*/
+type ChannelAssignment struct {
+	Quantity   QuantityEnum
+	Channel    int     // Номер канала прибора, начиная с 1. 0 - канал не задан
+	Programmed bool    // Значение программируемое, а не измеряемое
+	Value      float32 // Программируемое значение: расход, м3/ч; температура, C; давление, МПа
+}
+
+/**
+Настройки измерительного канала (датчика) прибора.
+*/
+type SensorConfig struct {
+	Quantity QuantityEnum
+	Channel  int     // Номер канала прибора, начиная с 1
+	Type     string  // Тип датчика. Например: Pt500
+	Diameter int     // Диаметр условного прохода, мм. Для каналов расхода
+	GMin     float32 // Уставка минимального расхода, м3/ч
+	GMax     float32 // Уставка максимального расхода, м3/ч
+	GCut     float32 // Расход отсечки, м3/ч
+}
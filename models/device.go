@@ -12,20 +12,46 @@ const (
 	KWh  UnitQEnum = 0x03 // Киловатты
 )
 
+var unitQCodes = map[UnitQEnum]string{MWh: "MWh", Gcal: "Gcal", GJ: "GJ", KWh: "kWh"}
+
+// Обозначение единиц энергии для машинных форматов вывода. Например: Gcal
+func (unit UnitQEnum) Code() string {
+	return unitQCodes[unit]
+}
+
 /**
 Структура данных теплосчётчика.
 */
 type DataDevice struct {
-	Serial         string         // Серийный заводской номер теплосчётчика
-	UnitQ          UnitQEnum      // Единицы измерения тепловой энергии
-	TimeRequest    time.Time      // Время запроса
-	Time           time.Time      // Время на приборе
-	TimeOn         uint32         // Время работы при включенном питании, в секундах
-	TimeRunCommon  uint32         // Время работы в нормальном режиме(без ошибок), общее по всем системам, в секундах
-	Systems        []SystemDevice // Системы теплосчётчика, нумерация с 0 (в реальности обычно с 1)
-	CoefficientGJ  float64        // переводной коэффициент ГДж в ГКал. См. dataDevice::getCoefficientGJ
-	CoefficientMWh float64        // переводной коэффициент МВт в ГКал. См. dataDevice::getCoefficientMWh
-	CoefficientKWh float64        // переводной коэффициент КВт в ГКал. См. dataDevice::getCoefficientKWh
+	Serial          string         // Серийный заводской номер теплосчётчика
+	Model           string         // Модель (наименование) прибора
+	Manufacturer    string         // Производитель прибора
+	Firmware        string         // Версия встроенного ПО прибора
+	ProtocolVersion string         // Версия протокола обмена
+	Address         string         // Сетевой адрес прибора, номер прибора в сети. Если драйвер не заполнил, берётся из флага number
+	Driver          string         // Драйвер, которым опрошен прибор. Заполняется ядром
+	DeviceType      string         // Тип теплосчётчика (значение флага type), которым опрошен прибор. Заполняется ядром
+	PollDuration    time.Duration  // Длительность опроса прибора. Заполняется ядром
+	Errors          []string       // Ошибки опроса, при которых данные получены не полностью. Заполняются ядром
+	Result          PollResult     // Результат опроса. Заполняется ядром
+	UnitQ           UnitQEnum      // Единицы измерения тепловой энергии
+	UnitP           UnitPEnum      // Единицы измерения давления. Драйверы заполняют данные в МПа
+	UnitV           UnitVEnum      // Единицы измерения объёма. Драйверы заполняют данные в м3
+	UnitM           UnitMEnum      // Единицы измерения массы. Драйверы заполняют данные в тоннах
+	UnitG           UnitGEnum      // Единицы измерения расхода. Драйверы заполняют данные в м3/ч и т/ч
+	UnitT           UnitTEnum      // Единицы измерения температуры. Драйверы заполняют данные в градусах Цельсия
+	TimeRequest     time.Time      // Время запроса
+	Time            time.Time      // Время на приборе
+	TimeOn          uint32         // Время работы при включенном питании, в секундах
+	TimeRunCommon   uint32         // Время работы в нормальном режиме(без ошибок), общее по всем системам, в секундах
+	Systems         []SystemDevice // Системы теплосчётчика, нумерация с 0 (в реальности обычно с 1)
+	Events          []Event        // События теплосчётчика. Заполняются, если драйвер реализует IEventDriver
+	CoefficientGJ   float64        // переводной коэффициент ГДж в ГКал производителя прибора. См. dataDevice::GetCoefficientsQ
+	CoefficientMWh  float64        // переводной коэффициент МВт в ГКал производителя прибора. См. dataDevice::GetCoefficientsQ
+	CoefficientKWh  float64        // переводной коэффициент КВт в ГКал производителя прибора. См. dataDevice::GetCoefficientsQ
+	StandardQ       StandardQEnum  // Стандарт пересчёта единиц энергии
+	OverrideQ       CoefficientsQ  // Переводные коэффициенты, заданные для прибора пользователем. Приоритетнее стандарта
+	MeterStatus     *MeterStatus   // Состояние прибора. nil, если драйвер не получает состояние прибора
 }
 
 /**
@@ -92,6 +118,11 @@ type SystemDevice struct {
 	P2 float32 // Давление 2, в МПа
 	P3 float32 // Давление 3, в МПа
 
+	Channels  []ChannelDevice // Измерения по каналам, дополнительно к полям выше. См. SystemDevice::AddChannel
+	Supported FieldEnum       // Поля, значения которых получены с прибора. См. SystemDevice::SetSupported
+
+	MeterStatus *MeterStatus // Состояние системы, если прибор передаёт ошибки по системам. Иначе nil
+
 	Status bool // Статус системы, активна или нет. Если нет, то не будет отображаться в результах опроса
 }
 
@@ -109,6 +140,17 @@ func (dataDevice *DataDevice) AddNewSystem(indexArray int) {
 	}
 }
 
+// Добавляет в систему измерение по каналу. Единицы измерения берутся по умолчанию для величины.
+func (system *SystemDevice) AddChannel(number int, role ChannelRoleEnum, quantity QuantityEnum, value float64) {
+	system.Channels = append(system.Channels, ChannelDevice{
+		Number:   number,
+		Role:     role,
+		Quantity: quantity,
+		Unit:     quantity.Unit(),
+		Value:    value,
+	})
+}
+
 // Изменение единиц измерения энергии
 func (dataDevice *DataDevice) ChangeUnitQ(u UnitQEnum) {
 	if dataDevice.UnitQ == u {
@@ -132,7 +174,7 @@ func (dataDevice *DataDevice) ChangeUnitQ(u UnitQEnum) {
 
 // Переводные коэффициенты единицы измерения энергии в ГКлал.
 // Примечание: Для конечного теплосчётчика эти коэффициенты могут отличаться от заданных,
-// поэтому их следует переопределить в драйвере для этого теплосчётчика.
+// поэтому их следует переопределить в драйвере для этого теплосчётчика. Применяются при стандарте StandardManufacturer.
 func (dataDevice *DataDevice) getCoefficient() float64 {
 	switch dataDevice.UnitQ {
 	case GJ:
@@ -194,22 +236,13 @@ func (dataDevice *DataDevice) toKiloWatts() {
 }
 
 func (dataDevice *DataDevice) getCoefficientGJ() float64 {
-	if dataDevice.CoefficientGJ > 0 {
-		return dataDevice.CoefficientGJ
-	}
-	return 0.239 // по ТКП 411-2012
+	return dataDevice.GetCoefficientsQ().GJ
 }
 
 func (dataDevice *DataDevice) getCoefficientMWh() float64 {
-	if dataDevice.CoefficientMWh > 0 {
-		return dataDevice.CoefficientMWh
-	}
-	return 0.86 // по ТКП 411-2012
+	return dataDevice.GetCoefficientsQ().MWh
 }
 
 func (dataDevice *DataDevice) getCoefficientKWh() float64 {
-	if dataDevice.CoefficientKWh > 0 {
-		return dataDevice.CoefficientKWh
-	}
-	return 0.00086 // по ТКП 411-2012
+	return dataDevice.GetCoefficientsQ().KWh
 }
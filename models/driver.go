@@ -1,8 +1,10 @@
 package models
 
 import (
+	"errors"
 	logService "qBox/services/log"
 	netService "qBox/services/net"
+	"time"
 )
 
 type IDeviceDriver interface {
@@ -23,3 +25,64 @@ type IDeviceDriver interface {
 	*/
 	Read() (*DataDevice, error)
 }
+
+// Драйверы, которые умеют работать с часами теплосчётчика, дополнительно реализуют этот интерфейс.
+// Методы вызываются ядром после Init().
+type IClockDriver interface {
+	/**
+	Чтение текущего времени на приборе
+	*/
+	ReadTime() (time.Time, error)
+
+	/**
+	Запись времени в часы прибора
+	*/
+	WriteTime(t time.Time) error
+
+	/**
+	Точность часов прибора. Расхождение часов меньше точности не корректируется
+	*/
+	TimeResolution() time.Duration
+}
+
+// Ошибка записи времени приборами, протокол обмена которых не позволяет установить часы.
+// Такие драйверы реализуют IClockDriver, чтобы команда sync-time могла показать расхождение часов (режим -dryRun).
+var ErrClockReadOnly = errors.New("протокол обмена с прибором не позволяет установить часы")
+
+// Драйверы, которые умеют читать журнал событий или таймеры ошибок теплосчётчика, дополнительно реализуют этот
+// интерфейс. Метод вызывается ядром после Read().
+type IEventDriver interface {
+	/**
+	Чтение событий (нештатных ситуаций) теплосчётчика
+	*/
+	ReadEvents() ([]Event, error)
+}
+
+// Драйверы, которые умеют читать конфигурацию (настройки) теплосчётчика, дополнительно реализуют этот интерфейс.
+// Метод вызывается ядром после Init().
+type IConfigDriver interface {
+	/**
+	Чтение конфигурации теплосчётчика
+	*/
+	ReadConfig() (*DeviceConfig, error)
+}
+
+// Драйверы, которые умеют распознать свой прибор, дополнительно реализуют этот интерфейс.
+// Используется при автоматическом определении типа теплосчётчика (-type=auto), метод вызывается до Init().
+// Запросы идентификации должны только читать данные прибора.
+type IDetectDriver interface {
+	/**
+	Возвращает признак того, что прибор обслуживается драйвером, и пояснение для лога
+	*/
+	Detect(counterNumber byte, network *netService.Network, logger *logService.LoggerService) (bool, string)
+}
+
+// Драйверы, которые сообщают протокол обмена с прибором, дополнительно реализуют этот интерфейс.
+// Протокол используется при разборе кадров (команда decode): по нему выделяются заголовок, адрес, команда
+// и проверяется контрольная сумма.
+type IProtocolDriver interface {
+	/**
+	Протокол обмена с прибором
+	*/
+	Protocol() ProtocolEnum
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+type EventType byte // Тип события (нештатной ситуации) теплосчётчика
+const (
+	EventError        EventType = 0x00 // Ошибка прибора, без уточнения
+	EventGMin         EventType = 0x01 // Расход меньше минимального
+	EventGMax         EventType = 0x02 // Расход больше максимального
+	EventDeltaT       EventType = 0x03 // Разность температур меньше минимальной
+	EventFault        EventType = 0x04 // Техническая неисправность
+	EventReverse      EventType = 0x05 // Реверс потока
+	EventNoCoolant    EventType = 0x06 // Отсутствие теплоносителя
+	EventPowerOff     EventType = 0x07 // Отсутствие питания
+	EventSensor       EventType = 0x08 // Обрыв, КЗ цепи датчика
+	EventLowVoltage   EventType = 0x09 // Напряжение питания ниже допустимого
+	EventSettings     EventType = 0x0A // Изменение настроек
+	EventClock        EventType = 0x0B // Ошибка часов
+	EventArchiveReset EventType = 0x0C // Сброс архива
+)
+
+var eventTypeCodes = map[EventType]string{
+	EventError:        "error",
+	EventGMin:         "gMin",
+	EventGMax:         "gMax",
+	EventDeltaT:       "dtMin",
+	EventFault:        "fault",
+	EventReverse:      "reverse",
+	EventNoCoolant:    "noCoolant",
+	EventPowerOff:     "powerOff",
+	EventSensor:       "sensor",
+	EventLowVoltage:   "lowVoltage",
+	EventSettings:     "settings",
+	EventClock:        "clock",
+	EventArchiveReset: "archiveReset",
+}
+
+var eventTypeNames = map[EventType]string{
+	EventError:        "Ошибка",
+	EventGMin:         "Расход меньше минимального",
+	EventGMax:         "Расход больше максимального",
+	EventDeltaT:       "Разность температур меньше минимальной",
+	EventFault:        "Техническая неисправность",
+	EventReverse:      "Реверс потока",
+	EventNoCoolant:    "Отсутствие теплоносителя",
+	EventPowerOff:     "Отсутствие питания",
+	EventSensor:       "Неисправность датчика",
+	EventLowVoltage:   "Напряжение питания ниже допустимого",
+	EventSettings:     "Изменение настроек",
+	EventClock:        "Ошибка часов",
+	EventArchiveReset: "Сброс архива",
+}
+
+// Код типа события для машинных форматов вывода. Например: gMin
+func (eventType EventType) Code() string {
+	return eventTypeCodes[eventType]
+}
+
+// Наименование типа события. Например: Расход меньше минимального
+func (eventType EventType) String() string {
+	return eventTypeNames[eventType]
+}
+
+/**
+Событие (нештатная ситуация) теплосчётчика.
+Одни теплосчётчики ведут журнал событий с временем начала и окончания, другие - только таймеры ошибок или счётчики
+ошибок. Драйвер заполняет те поля, которые позволяет получить протокол прибора, остальные остаются нулевыми.
+*/
+type Event struct {
+	Type        EventType
+	Description string    // Уточнение по протоколу прибора. Например: канал расхода 2
+	System      int       // Номер системы, начиная с 1. 0 - событие относится к прибору в целом
+	Start       time.Time // Начало события
+	End         time.Time // Окончание события. Не заполнено, если событие продолжается
+	Duration    uint32    // Суммарная длительность события, в секундах
+	Count       uint32    // Количество событий
+}
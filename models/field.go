@@ -0,0 +1,46 @@
+package models
+
+type FieldEnum uint32 // Поля SystemDevice, битовая маска. Используется для учёта поддерживаемых прибором значений
+const (
+	FieldTimeRunSys FieldEnum = 1 << iota
+	FieldSigmaQ
+	FieldQ1
+	FieldQ2
+	FieldQ3
+	FieldV1
+	FieldV2
+	FieldM1
+	FieldM2
+	FieldGM1
+	FieldGM2
+	FieldGV1
+	FieldGV2
+	FieldT1
+	FieldT2
+	FieldT3
+	FieldP1
+	FieldP2
+	FieldP3
+
+	// Признак того, что драйвер отмечает поддерживаемые поля. Без него считаются поддерживаемыми все поля.
+	fieldsDeclared FieldEnum = 1 << 31
+)
+
+/**
+Отмечает поля системы, значения которых получены с прибора. Поля можно объединять: FieldV1 | FieldM1.
+Если драйвер ни разу не вызвал SetSupported или ResetSupported, все поля системы считаются поддерживаемыми.
+Неотмеченные поля выводятся как отсутствующие, а не как нулевые показания.
+*/
+func (system *SystemDevice) SetSupported(fields FieldEnum) {
+	system.Supported |= fields | fieldsDeclared
+}
+
+// Сброс отметок перед разбором нового ответа прибора, когда набор полей зависит от содержимого ответа.
+func (system *SystemDevice) ResetSupported() {
+	system.Supported = fieldsDeclared
+}
+
+// Поддерживается ли поле системы прибором
+func (system *SystemDevice) IsSupported(field FieldEnum) bool {
+	return system.Supported&fieldsDeclared == 0 || system.Supported&field == field
+}
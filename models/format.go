@@ -5,3 +5,14 @@ import "io"
 type Formatter interface {
 	Render(writer io.Writer, device *DataDevice)
 }
+
+// Форматы, которые умеют выводить конфигурацию теплосчётчика, дополнительно реализуют этот интерфейс
+type ConfigFormatter interface {
+	RenderConfig(writer io.Writer, config *DeviceConfig)
+}
+
+// Форматы, которые умеют выводить данные нескольких приборов одним документом, дополнительно реализуют этот интерфейс.
+// При опросе приборов из файла конфигурации опроса приборы с одним выводом и форматом выводятся одним вызовом RenderAll
+type MultiFormatter interface {
+	RenderAll(writer io.Writer, devices []*DataDevice)
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func (format JsonFormat) RenderConfig(writer io.Writer, config *DeviceConfig) {
+	configForJson := deviceConfigJson{
+		Serial:  config.Serial,
+		UnitQ:   config.UnitQ,
+		UnitP:   config.UnitP,
+		Systems: []systemConfigJson{},
+		Sensors: []sensorConfigJson{},
+	}
+
+	for _, system := range config.Systems {
+		systemForJson := systemConfigJson{
+			Number:     system.Number,
+			SchemeCode: system.SchemeCode,
+			Scheme:     system.Scheme,
+			Enabled:    system.Enabled,
+			DeltaTMin:  system.DeltaTMin,
+			Channels:   []channelAssignmentJson{},
+		}
+		for _, channel := range system.Channels {
+			systemForJson.Channels = append(systemForJson.Channels, channelAssignmentJson{
+				Quantity:   channel.Quantity.Code(),
+				Channel:    channel.Channel,
+				Programmed: channel.Programmed,
+				Value:      channel.Value,
+			})
+		}
+		configForJson.Systems = append(configForJson.Systems, systemForJson)
+	}
+
+	for _, sensor := range config.Sensors {
+		configForJson.Sensors = append(configForJson.Sensors, sensorConfigJson{
+			Quantity: sensor.Quantity.Code(),
+			Channel:  sensor.Channel,
+			Type:     sensor.Type,
+			Diameter: sensor.Diameter,
+			GMin:     sensor.GMin,
+			GMax:     sensor.GMax,
+			GCut:     sensor.GCut,
+		})
+	}
+
+	bytesResponse, err := json.Marshal(configForJson)
+	if err != nil {
+		fmt.Fprintln(writer, "{}")
+		return
+	}
+	fmt.Fprintln(writer, string(bytesResponse))
+}
+
+type deviceConfigJson struct {
+	Serial  string             `json:"serial"`
+	UnitQ   UnitQEnum          `json:"unitQ"`
+	UnitP   string             `json:"unitP,omitempty"`
+	Systems []systemConfigJson `json:"system"`
+	Sensors []sensorConfigJson `json:"sensors"`
+}
+
+type systemConfigJson struct {
+	Number     int                     `json:"number"`
+	SchemeCode int                     `json:"schemeCode"`
+	Scheme     string                  `json:"scheme,omitempty"`
+	Enabled    bool                    `json:"enabled"`
+	DeltaTMin  float32                 `json:"deltaTMin,omitempty"`
+	Channels   []channelAssignmentJson `json:"channels"`
+}
+
+type channelAssignmentJson struct {
+	Quantity   string  `json:"quantity"`
+	Channel    int     `json:"channel"`
+	Programmed bool    `json:"programmed"`
+	Value      float32 `json:"value,omitempty"`
+}
+
+type sensorConfigJson struct {
+	Quantity string  `json:"quantity"`
+	Channel  int     `json:"channel"`
+	Type     string  `json:"type,omitempty"`
+	Diameter int     `json:"diameter,omitempty"`
+	GMin     float32 `json:"gMin,omitempty"`
+	GMax     float32 `json:"gMax,omitempty"`
+	GCut     float32 `json:"gCut,omitempty"`
+}
+
+func (format TextFormat) RenderConfig(writer io.Writer, config *DeviceConfig) {
+	fmt.Fprintf(writer, "Заводской номер прибора - %v\n", config.Serial)
+	fmt.Fprintf(writer, "Единицы измерения энергии - %s\n", unitQName(config.UnitQ))
+	if config.UnitP != "" {
+		fmt.Fprintf(writer, "Единицы измерения давления - %s\n", config.UnitP)
+	}
+
+	channelUnits := map[QuantityEnum]string{
+		QuantityFlow:        "м3/ч",
+		QuantityTemperature: "C",
+		QuantityPressure:    "МПа",
+	}
+
+	for _, system := range config.Systems {
+		fmt.Fprintln(writer, "")
+		fmt.Fprintf(writer, "Система %d:\n", system.Number)
+		if system.Scheme != "" {
+			fmt.Fprintf(writer, "Схема - %s (код %X)\n", system.Scheme, system.SchemeCode)
+		} else {
+			fmt.Fprintf(writer, "Схема - код %X\n", system.SchemeCode)
+		}
+		if system.Enabled {
+			fmt.Fprintln(writer, "Работа системы разрешена")
+		} else {
+			fmt.Fprintln(writer, "Работа системы запрещена")
+		}
+		if system.DeltaTMin > 0 {
+			fmt.Fprintf(writer, "Минимальная разность температур - %f C\n", system.DeltaTMin)
+		}
+		for _, channel := range system.Channels {
+			if channel.Programmed {
+				fmt.Fprintf(writer, "%s - программируемое значение %f %s\n",
+					channel.Quantity.String(), channel.Value, channelUnits[channel.Quantity])
+				continue
+			}
+			fmt.Fprintf(writer, "%s - канал %d\n", channel.Quantity.String(), channel.Channel)
+		}
+	}
+
+	if len(config.Sensors) > 0 {
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, "Измерительные каналы:")
+	}
+	for _, sensor := range config.Sensors {
+		fmt.Fprintf(writer, "%s, канал %d", sensor.Quantity.String(), sensor.Channel)
+		if sensor.Type != "" {
+			fmt.Fprintf(writer, ", датчик %s", sensor.Type)
+		}
+		if sensor.Diameter > 0 {
+			fmt.Fprintf(writer, ", Ду %d мм", sensor.Diameter)
+		}
+		if sensor.GMin > 0 {
+			fmt.Fprintf(writer, ", Gmin %f м3/ч", sensor.GMin)
+		}
+		if sensor.GMax > 0 {
+			fmt.Fprintf(writer, ", Gmax %f м3/ч", sensor.GMax)
+		}
+		if sensor.GCut > 0 {
+			fmt.Fprintf(writer, ", отсечка %f м3/ч", sensor.GCut)
+		}
+		fmt.Fprintln(writer, "")
+	}
+
+	fmt.Fprintln(writer, "")
+}
+
+// Наименование единиц измерения энергии для текстового вывода
+func unitQName(unitQ UnitQEnum) string {
+	switch unitQ {
+	case MWh:
+		return "МВт"
+	case KWh:
+		return "КВт"
+	case GJ:
+		return "ГДж"
+	}
+	return "ГКал"
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/**
+Вывод в формате CSV для загрузки в электронные таблицы: одна строка на каждую активную систему прибора.
+Состав и порядок колонок не меняются от прибора к прибору, значения, которые прибор не поддерживает, остаются пустыми.
+Для Excel с русской локалью используется разделитель колонок ";" и десятичный разделитель ",".
+*/
+type CsvFormat struct {
+	Delimiter rune   // Разделитель колонок
+	Decimal   string // Десятичный разделитель
+	NoHeader  bool   // Не выводить строку заголовков. Используется для дописывания результатов опросов в один файл
+}
+
+// Заголовки колонок CSV
+var csvHeader = []string{
+	"serial", "model", "address", "timeRequest", "timeDevice", "timeOn", "timeRunCommon",
+	"unitQ", "unitP", "unitV", "unitM", "unitG", "unitT",
+	"system", "timeRunSys", "SigmaQ", "Q1", "Q2", "Q3", "V1", "V2", "M1", "M2",
+	"GM1", "GM2", "GV1", "GV2", "T1", "T2", "T3", "P1", "P2", "P3",
+	"statusCode", "statusFlags", "systemStatusCode", "systemStatusFlags",
+	"resultStatus", "resultStage", "resultClass", "resultMessage",
+}
+
+const csvTimeLayout = "02.01.2006 15:04:05"
+
+func (format CsvFormat) Render(writer io.Writer, device *DataDevice) {
+	csvWriter := csv.NewWriter(writer)
+	if format.Delimiter != 0 {
+		csvWriter.Comma = format.Delimiter
+	}
+
+	if !format.NoHeader {
+		_ = csvWriter.Write(csvHeader)
+	}
+
+	result := []string{device.Result.Status.Code(), device.Result.Stage.Code(), device.Result.Class.Code(), device.Result.Message}
+
+	// При ошибке опроса выводится одна строка со сведениями о приборе и результатом, колонки показаний пустые
+	if device.Result.Status == ResultFailed {
+		record := make([]string, len(csvHeader)-len(result))
+		record[0] = device.Serial
+		record[1] = device.Model
+		record[2] = device.Address
+		record[3] = device.TimeRequest.Format(csvTimeLayout)
+		_ = csvWriter.Write(append(record, result...))
+		csvWriter.Flush()
+		return
+	}
+
+	for i, system := range device.Systems {
+		if system.Status == false {
+			continue
+		}
+		record := []string{
+			device.Serial,
+			device.Model,
+			device.Address,
+			device.TimeRequest.Format(csvTimeLayout),
+			device.Time.Format(csvTimeLayout),
+			strconv.FormatUint(uint64(device.TimeOn), 10),
+			strconv.FormatUint(uint64(device.TimeRunCommon), 10),
+			unitQName(device.UnitQ),
+			device.UnitP.String(),
+			device.UnitV.String(),
+			device.UnitM.String(),
+			device.UnitG.String(),
+			device.UnitT.String(),
+			strconv.Itoa(i + 1),
+		}
+		if system.IsSupported(FieldTimeRunSys) {
+			record = append(record, strconv.FormatUint(uint64(system.TimeRunSys), 10))
+		} else {
+			record = append(record, "")
+		}
+		record = append(record,
+			format.formatFloat64(system, FieldSigmaQ, system.SigmaQ),
+			format.formatFloat64(system, FieldQ1, system.Q1),
+			format.formatFloat64(system, FieldQ2, system.Q2),
+			format.formatFloat64(system, FieldQ3, system.Q3),
+			format.formatFloat64(system, FieldV1, system.V1),
+			format.formatFloat64(system, FieldV2, system.V2),
+			format.formatFloat64(system, FieldM1, system.M1),
+			format.formatFloat64(system, FieldM2, system.M2),
+			format.formatFloat32(system, FieldGM1, system.GM1),
+			format.formatFloat32(system, FieldGM2, system.GM2),
+			format.formatFloat32(system, FieldGV1, system.GV1),
+			format.formatFloat32(system, FieldGV2, system.GV2),
+			format.formatFloat32(system, FieldT1, system.T1),
+			format.formatFloat32(system, FieldT2, system.T2),
+			format.formatFloat32(system, FieldT3, system.T3),
+			format.formatFloat32(system, FieldP1, system.P1),
+			format.formatFloat32(system, FieldP2, system.P2),
+			format.formatFloat32(system, FieldP3, system.P3),
+		)
+		record = append(record, csvMeterStatus(device.MeterStatus)...)
+		record = append(record, csvMeterStatus(system.MeterStatus)...)
+		record = append(record, result...)
+		_ = csvWriter.Write(record)
+	}
+
+	csvWriter.Flush()
+}
+
+func (format CsvFormat) formatFloat64(system SystemDevice, field FieldEnum, value float64) string {
+	if !system.IsSupported(field) {
+		return ""
+	}
+	return format.decimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (format CsvFormat) formatFloat32(system SystemDevice, field FieldEnum, value float32) string {
+	if !system.IsSupported(field) {
+		return ""
+	}
+	return format.decimal(strconv.FormatFloat(float64(value), 'f', -1, 32))
+}
+
+func (format CsvFormat) decimal(value string) string {
+	if format.Decimal == "" || format.Decimal == "." {
+		return value
+	}
+	return strings.Replace(value, ".", format.Decimal, 1)
+}
+
+// Код состояния и коды флагов через пробел. Пустые колонки, если драйвер не получает состояние
+func csvMeterStatus(status *MeterStatus) []string {
+	if status == nil {
+		return []string{"", ""}
+	}
+	var flags []string
+	for _, flag := range status.List() {
+		flags = append(flags, flag.Code())
+	}
+	return []string{strconv.FormatUint(uint64(status.Code), 10), strings.Join(flags, " ")}
+}
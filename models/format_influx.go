@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+Вывод в формате InfluxDB line protocol: одна строка на каждую активную систему прибора.
+Теги: serial - заводской номер, driver - драйвер, которым опрошен прибор, site - объект учёта, system - номер системы,
+единицы измерения. Поля - все значения, которые прибор поддерживает, целые значения выводятся с суффиксом "i".
+Метка времени в наносекундах: время на приборе, если драйвер его получил, иначе время опроса.
+Результат опроса выводится полем result. При ошибке опроса выводится одна строка с результатом без показаний.
+*/
+type InfluxFormat struct {
+	Measurement string // Имя measurement. По умолчанию "heat"
+	Site        string // Объект учёта, тег site. Не выводится, если не задан
+}
+
+// Имя measurement по умолчанию
+const influxMeasurement = "heat"
+
+func (format InfluxFormat) Render(writer io.Writer, device *DataDevice) {
+	for _, line := range format.Lines(device) {
+		fmt.Fprintln(writer, line)
+	}
+}
+
+// Строки line protocol без перевода строки. Используются для отправки данных в InfluxDB
+func (format InfluxFormat) Lines(device *DataDevice) []string {
+	measurement := format.Measurement
+	if measurement == "" {
+		measurement = influxMeasurement
+	}
+	timestamp := device.TimeRequest
+	if !device.Time.IsZero() {
+		timestamp = device.Time
+	}
+
+	// При ошибке опроса выводится одна строка с результатом, чтобы частично прочитанные данные не приняли за показания
+	if device.Result.Status == ResultFailed {
+		tags := [][2]string{{"driver", device.Driver}, {"serial", device.Serial}, {"site", format.Site}}
+		fields := influxFields{}
+		fields.result(device.Result)
+		return []string{influxLine(measurement, tags, fields, timestamp)}
+	}
+
+	var lines []string
+	for i, system := range device.Systems {
+		if system.Status == false {
+			continue
+		}
+
+		tags := [][2]string{
+			{"serial", device.Serial},
+			{"driver", device.Driver},
+			{"site", format.Site},
+			{"system", strconv.Itoa(i + 1)},
+			{"unitQ", device.UnitQ.Code()},
+			{"unitP", device.UnitP.Code()},
+			{"unitV", device.UnitV.Code()},
+			{"unitM", device.UnitM.Code()},
+			{"unitG", device.UnitG.Code()},
+			{"unitT", device.UnitT.Code()},
+		}
+		// Теги выводятся в порядке сортировки ключей, как рекомендует документация InfluxDB
+		sort.Slice(tags, func(a, b int) bool { return tags[a][0] < tags[b][0] })
+
+		fields := influxFields{}
+		fields.integer("timeOn", uint64(device.TimeOn))
+		fields.integer("timeRunCommon", uint64(device.TimeRunCommon))
+		if system.IsSupported(FieldTimeRunSys) {
+			fields.integer("timeRunSys", uint64(system.TimeRunSys))
+		}
+		fields.system(system, FieldSigmaQ, "SigmaQ", system.SigmaQ)
+		fields.system(system, FieldQ1, "Q1", system.Q1)
+		fields.system(system, FieldQ2, "Q2", system.Q2)
+		fields.system(system, FieldQ3, "Q3", system.Q3)
+		fields.system(system, FieldV1, "V1", system.V1)
+		fields.system(system, FieldV2, "V2", system.V2)
+		fields.system(system, FieldM1, "M1", system.M1)
+		fields.system(system, FieldM2, "M2", system.M2)
+		fields.system(system, FieldGM1, "GM1", float64(system.GM1))
+		fields.system(system, FieldGM2, "GM2", float64(system.GM2))
+		fields.system(system, FieldGV1, "GV1", float64(system.GV1))
+		fields.system(system, FieldGV2, "GV2", float64(system.GV2))
+		fields.system(system, FieldT1, "T1", float64(system.T1))
+		fields.system(system, FieldT2, "T2", float64(system.T2))
+		fields.system(system, FieldT3, "T3", float64(system.T3))
+		fields.system(system, FieldP1, "P1", float64(system.P1))
+		fields.system(system, FieldP2, "P2", float64(system.P2))
+		fields.system(system, FieldP3, "P3", float64(system.P3))
+
+		// Поле канала - обозначение величины с номером канала и назначением трубопровода. Например: V3_makeup
+		for _, channel := range system.Channels {
+			key := channel.Quantity.Code()
+			if channel.Number > 0 {
+				key += strconv.Itoa(channel.Number)
+			}
+			if channel.Role != RoleUnknown {
+				key += "_" + channel.Role.Code()
+			}
+			fields.float(key, channel.Value)
+		}
+		fields.status("status", device.MeterStatus)
+		fields.status("systemStatus", system.MeterStatus)
+		fields.result(device.Result)
+		lines = append(lines, influxLine(measurement, tags, fields, timestamp))
+	}
+	return lines
+}
+
+func influxLine(measurement string, tags [][2]string, fields influxFields, timestamp time.Time) string {
+	var line strings.Builder
+	line.WriteString(influxEscape(measurement, ", "))
+	for _, tag := range tags {
+		// Пустые значения тегов InfluxDB не принимает
+		if tag[1] == "" {
+			continue
+		}
+		line.WriteString("," + influxEscape(tag[0], ",= ") + "=" + influxEscape(tag[1], ",= "))
+	}
+	line.WriteString(" " + strings.Join(fields, ","))
+	line.WriteString(" " + strconv.FormatInt(timestamp.UnixNano(), 10))
+	return line.String()
+}
+
+// Поля строки line protocol в виде key=value
+type influxFields []string
+
+// NaN и бесконечность в line protocol не представимы, такие поля не выводятся
+func (fields *influxFields) float(key string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	*fields = append(*fields, influxEscape(key, ",= ")+"="+strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (fields *influxFields) integer(key string, value uint64) {
+	*fields = append(*fields, influxEscape(key, ",= ")+"="+strconv.FormatUint(value, 10)+"i")
+}
+
+func (fields *influxFields) system(system SystemDevice, field FieldEnum, key string, value float64) {
+	if system.IsSupported(field) {
+		fields.float(key, value)
+	}
+}
+
+// Результат опроса, при ошибке - этап, класс ошибки и сообщение
+func (fields *influxFields) result(result PollResult) {
+	fields.text("result", result.Status.Code())
+	if result.Status == ResultSuccess {
+		return
+	}
+	fields.text("resultStage", result.Stage.Code())
+	fields.text("resultClass", result.Class.Code())
+	fields.text("resultMessage", result.Message)
+}
+
+func (fields *influxFields) text(key string, value string) {
+	value = strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value)
+	*fields = append(*fields, influxEscape(key, ",= ")+"=\""+value+"\"")
+}
+
+// Код состояния и коды флагов через пробел. Не выводится, если драйвер не получает состояние
+func (fields *influxFields) status(key string, status *MeterStatus) {
+	if status == nil {
+		return
+	}
+	var flags []string
+	for _, flag := range status.List() {
+		flags = append(flags, flag.Code())
+	}
+	fields.integer(key+"Code", uint64(status.Code))
+	fields.text(key+"Flags", strings.Join(flags, " "))
+}
+
+// Экранирование обратной косой чертой символов, которые в данной позиции строки имеют специальное значение
+func influxEscape(value string, special string) string {
+	var escaped strings.Builder
+	for _, char := range value {
+		if char == '\n' {
+			char = ' '
+		}
+		if strings.ContainsRune(special, char) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
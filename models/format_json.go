@@ -11,25 +11,53 @@ type JsonFormat struct {
 }
 
 func (format JsonFormat) Render(writer io.Writer, device *DataDevice) {
+	// При ошибке опроса выводится только результат, чтобы частично прочитанные данные не приняли за показания
+	if device.Result.Status == ResultFailed {
+		bytesResponse, _ := json.Marshal(struct {
+			Result resultJson `json:"result"`
+		}{newResultJson(device.Result)})
+		fmt.Fprintln(writer, string(bytesResponse))
+		return
+	}
+
 	deviceForJson := dataDeviceJson{
 		Serial:        device.Serial,
+		Model:         optionalString(device.Model),
+		Manufacturer:  optionalString(device.Manufacturer),
+		Firmware:      optionalString(device.Firmware),
+		Protocol:      optionalString(device.ProtocolVersion),
+		Address:       optionalString(device.Address),
 		UnitQ:         device.UnitQ,
+		UnitP:         device.UnitP.Code(),
+		UnitV:         device.UnitV.Code(),
+		UnitM:         device.UnitM.Code(),
+		UnitG:         device.UnitG.Code(),
+		UnitT:         device.UnitT.Code(),
+		StandardQ:     device.GetStandardQ().Code(),
+		CoefficientsQ: newCoefficientsQJson(device),
 		TimeRequest:   JSONTime(device.TimeRequest),
 		Time:          JSONTime(device.Time),
 		TimeOn:        device.TimeOn,
 		TimeRunCommon: device.TimeRunCommon,
+		MeterStatus:   newMeterStatusJson(device.MeterStatus),
+		Result:        newResultJson(device.Result),
 	}
 
 	for _, system := range device.Systems {
 		if system.Status == false {
 			continue
 		}
-		deviceForJson.Systems = append(deviceForJson.Systems, systemDeviceJson(system))
+		deviceForJson.Systems = append(deviceForJson.Systems, newSystemDeviceJson(system))
+	}
+
+	for _, event := range device.Events {
+		deviceForJson.Events = append(deviceForJson.Events, newEventJson(event))
 	}
 
 	bytesResponse, err := json.Marshal(deviceForJson)
 	if err != nil {
 		fmt.Fprintln(writer, "{}")
+		return
 	}
 	fmt.Fprintln(writer, string(bytesResponse))
 }
@@ -40,35 +68,198 @@ func (format JsonFormat) Render(writer io.Writer, device *DataDevice) {
 */
 type dataDeviceJson struct {
 	Serial        string             `json:"serial"`
+	Model         *string            `json:"model"`
+	Manufacturer  *string            `json:"manufacturer"`
+	Firmware      *string            `json:"firmware"`
+	Protocol      *string            `json:"protocolVersion"`
+	Address       *string            `json:"address"`
 	UnitQ         UnitQEnum          `json:"unitQ"`
+	UnitP         string             `json:"unitP"`
+	UnitV         string             `json:"unitV"`
+	UnitM         string             `json:"unitM"`
+	UnitG         string             `json:"unitG"`
+	UnitT         string             `json:"unitT"`
+	StandardQ     string             `json:"standardQ"`
+	CoefficientsQ coefficientsQJson  `json:"coefficientsQ"`
 	TimeRequest   JSONTime           `json:"timeRequest"`
 	Time          JSONTime           `json:"timeDevice"`
 	TimeOn        uint32             `json:"timeOn"`
 	TimeRunCommon uint32             `json:"timeRunCommon"`
+	MeterStatus   *meterStatusJson   `json:"status"`
 	Systems       []systemDeviceJson `json:"system"`
+	Events        []eventJson        `json:"events,omitempty"`
+	Result        resultJson         `json:"result"`
 }
 
+// Поля, которые прибор не поддерживает, выводятся как null. См. SystemDevice::SetSupported
 type systemDeviceJson struct {
-	TimeRunSys uint32 `json:"timeRunSys"`
-	SigmaQ     float64
-	Q1         float64
-	Q2         float64
-	Q3         float64
-	V1         float64
-	V2         float64
-	M1         float64
-	M2         float64
-	GM1        float32
-	GM2        float32
-	GV1        float32
-	GV2        float32
-	T1         float32
-	T2         float32
-	T3         float32
-	P1         float32
-	P2         float32
-	P3         float32
-	Status     bool `json:"-"`
+	TimeRunSys *uint32          `json:"timeRunSys"`
+	SigmaQ     *float64         `json:"SigmaQ"`
+	Q1         *float64         `json:"Q1"`
+	Q2         *float64         `json:"Q2"`
+	Q3         *float64         `json:"Q3"`
+	V1         *float64         `json:"V1"`
+	V2         *float64         `json:"V2"`
+	M1         *float64         `json:"M1"`
+	M2         *float64         `json:"M2"`
+	GM1        *float32         `json:"GM1"`
+	GM2        *float32         `json:"GM2"`
+	GV1        *float32         `json:"GV1"`
+	GV2        *float32         `json:"GV2"`
+	T1         *float32         `json:"T1"`
+	T2         *float32         `json:"T2"`
+	T3         *float32         `json:"T3"`
+	P1         *float32         `json:"P1"`
+	P2         *float32         `json:"P2"`
+	P3         *float32         `json:"P3"`
+	Channels   []ChannelDevice  `json:"channels,omitempty"`
+	Status     *meterStatusJson `json:"status,omitempty"`
+}
+
+func newSystemDeviceJson(system SystemDevice) systemDeviceJson {
+	result := systemDeviceJson{Channels: system.Channels, Status: newMeterStatusJson(system.MeterStatus)}
+	if system.IsSupported(FieldTimeRunSys) {
+		result.TimeRunSys = &system.TimeRunSys
+	}
+	result.SigmaQ = supportedFloat64(system, FieldSigmaQ, &system.SigmaQ)
+	result.Q1 = supportedFloat64(system, FieldQ1, &system.Q1)
+	result.Q2 = supportedFloat64(system, FieldQ2, &system.Q2)
+	result.Q3 = supportedFloat64(system, FieldQ3, &system.Q3)
+	result.V1 = supportedFloat64(system, FieldV1, &system.V1)
+	result.V2 = supportedFloat64(system, FieldV2, &system.V2)
+	result.M1 = supportedFloat64(system, FieldM1, &system.M1)
+	result.M2 = supportedFloat64(system, FieldM2, &system.M2)
+	result.GM1 = supportedFloat32(system, FieldGM1, &system.GM1)
+	result.GM2 = supportedFloat32(system, FieldGM2, &system.GM2)
+	result.GV1 = supportedFloat32(system, FieldGV1, &system.GV1)
+	result.GV2 = supportedFloat32(system, FieldGV2, &system.GV2)
+	result.T1 = supportedFloat32(system, FieldT1, &system.T1)
+	result.T2 = supportedFloat32(system, FieldT2, &system.T2)
+	result.T3 = supportedFloat32(system, FieldT3, &system.T3)
+	result.P1 = supportedFloat32(system, FieldP1, &system.P1)
+	result.P2 = supportedFloat32(system, FieldP2, &system.P2)
+	result.P3 = supportedFloat32(system, FieldP3, &system.P3)
+	return result
+}
+
+// Сведения, которые драйвер не получает, выводятся как null
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func supportedFloat64(system SystemDevice, field FieldEnum, value *float64) *float64 {
+	if !system.IsSupported(field) {
+		return nil
+	}
+	return value
+}
+
+func supportedFloat32(system SystemDevice, field FieldEnum, value *float32) *float32 {
+	if !system.IsSupported(field) {
+		return nil
+	}
+	return value
+}
+
+type coefficientsQJson struct {
+	GJ         float64 `json:"GJ"`
+	MWh        float64 `json:"MWh"`
+	KWh        float64 `json:"KWh"`
+	Overridden bool    `json:"overridden,omitempty"`
+}
+
+func newCoefficientsQJson(device *DataDevice) coefficientsQJson {
+	coefficients := device.GetCoefficientsQ()
+	return coefficientsQJson{
+		GJ:         coefficients.GJ,
+		MWh:        coefficients.MWh,
+		KWh:        coefficients.KWh,
+		Overridden: device.IsOverriddenQ(),
+	}
+}
+
+type channelDeviceJson struct {
+	Number   int     `json:"number,omitempty"`
+	Role     string  `json:"role,omitempty"`
+	Quantity string  `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Value    float64 `json:"value"`
+}
+
+func (channel ChannelDevice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(channelDeviceJson{
+		Number:   channel.Number,
+		Role:     channel.Role.Code(),
+		Quantity: channel.Quantity.Code(),
+		Unit:     channel.Unit,
+		Value:    channel.Value,
+	})
+}
+
+type eventJson struct {
+	Type        string    `json:"type"`
+	Description string    `json:"description,omitempty"`
+	System      int       `json:"system"`
+	Start       *JSONTime `json:"start,omitempty"`
+	End         *JSONTime `json:"end,omitempty"`
+	Duration    uint32    `json:"duration,omitempty"`
+	Count       uint32    `json:"count,omitempty"`
+}
+
+func newEventJson(event Event) eventJson {
+	result := eventJson{
+		Type:        event.Type.Code(),
+		Description: event.Description,
+		System:      event.System,
+		Duration:    event.Duration,
+		Count:       event.Count,
+	}
+	if !event.Start.IsZero() {
+		start := JSONTime(event.Start)
+		result.Start = &start
+	}
+	if !event.End.IsZero() {
+		end := JSONTime(event.End)
+		result.End = &end
+	}
+	return result
+}
+
+// Состояние прибора, которое драйвер не получает, выводится как null
+type meterStatusJson struct {
+	Code  uint32   `json:"code"`
+	Flags []string `json:"flags"`
+}
+
+func newMeterStatusJson(status *MeterStatus) *meterStatusJson {
+	if status == nil {
+		return nil
+	}
+	result := meterStatusJson{Code: status.Code, Flags: []string{}}
+	for _, flag := range status.List() {
+		result.Flags = append(result.Flags, flag.Code())
+	}
+	return &result
+}
+
+// Результат опроса. Этап, класс ошибки и сообщение выводятся, если опрос не успешен
+type resultJson struct {
+	Status  string `json:"status"`
+	Stage   string `json:"stage,omitempty"`
+	Class   string `json:"class,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func newResultJson(result PollResult) resultJson {
+	return resultJson{
+		Status:  result.Status.Code(),
+		Stage:   result.Stage.Code(),
+		Class:   result.Class.Code(),
+		Message: result.Message,
+	}
 }
 
 type JSONTime time.Time
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+/**
+Вывод в формате JSON версии 2, схема документа - schema/qbox-v2.schema.json.
+В отличие от первой версии имена полей в camelCase, время в формате ISO 8601 с часовым поясом, единицы измерения
+выводятся обозначениями, добавлены сведения о драйвере, длительность и ошибки опроса, результат опроса.
+При ошибке опроса показания не выводятся, документ содержит только результат, сведения о приборе и об опросе.
+Значения, которые прибор не поддерживает, а также нечисловые значения (NaN, бесконечность) выводятся как null.
+*/
+type JsonV2Format struct {
+}
+
+// Версия схемы документа, выводится в поле schemaVersion
+const jsonSchemaVersion = 2
+
+// При ошибке опроса выводятся только результат, сведения о приборе и об опросе
+type failedJsonV2 struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Result        resultJson       `json:"result"`
+	Device        deviceInfoJsonV2 `json:"device"`
+	Poll          pollJsonV2       `json:"poll"`
+}
+
+type dataDeviceJsonV2 struct {
+	SchemaVersion    int                    `json:"schemaVersion"`
+	Result           resultJson             `json:"result"`
+	Device           deviceInfoJsonV2       `json:"device"`
+	Poll             pollJsonV2             `json:"poll"`
+	Units            unitsJsonV2            `json:"units"`
+	EnergyConversion energyConversionJsonV2 `json:"energyConversion"`
+	TimeOn           uint32                 `json:"timeOn"`
+	TimeRunCommon    uint32                 `json:"timeRunCommon"`
+	Status           *meterStatusJson       `json:"status"`
+	Systems          []systemJsonV2         `json:"systems"`
+	Events           []eventJsonV2          `json:"events"`
+}
+
+type deviceInfoJsonV2 struct {
+	Serial          string  `json:"serial"`
+	Model           *string `json:"model"`
+	Manufacturer    *string `json:"manufacturer"`
+	Firmware        *string `json:"firmware"`
+	ProtocolVersion *string `json:"protocolVersion"`
+	Address         *string `json:"address"`
+	Driver          *string `json:"driver"`
+	Type            *string `json:"type"`
+}
+
+type pollJsonV2 struct {
+	RequestedAt     *string  `json:"requestedAt"`
+	DeviceTime      *string  `json:"deviceTime"`
+	DurationSeconds float64  `json:"durationSeconds"`
+	Errors          []string `json:"errors"`
+}
+
+type unitsJsonV2 struct {
+	Energy      string `json:"energy"`
+	Pressure    string `json:"pressure"`
+	Volume      string `json:"volume"`
+	Mass        string `json:"mass"`
+	Flow        string `json:"flow"`
+	MassFlow    string `json:"massFlow"`
+	Temperature string `json:"temperature"`
+}
+
+type energyConversionJsonV2 struct {
+	Standard     string             `json:"standard"`
+	Coefficients coefficientsJsonV2 `json:"coefficients"`
+	Overridden   bool               `json:"overridden"`
+}
+
+type coefficientsJsonV2 struct {
+	GJ  float64 `json:"gj"`
+	MWh float64 `json:"mwh"`
+	KWh float64 `json:"kwh"`
+}
+
+type systemJsonV2 struct {
+	Number     int              `json:"number"`
+	TimeRunSys *uint32          `json:"timeRunSys"`
+	SigmaQ     *float64         `json:"sigmaQ"`
+	Q1         *float64         `json:"q1"`
+	Q2         *float64         `json:"q2"`
+	Q3         *float64         `json:"q3"`
+	V1         *float64         `json:"v1"`
+	V2         *float64         `json:"v2"`
+	M1         *float64         `json:"m1"`
+	M2         *float64         `json:"m2"`
+	GM1        *float64         `json:"gm1"`
+	GM2        *float64         `json:"gm2"`
+	GV1        *float64         `json:"gv1"`
+	GV2        *float64         `json:"gv2"`
+	T1         *float64         `json:"t1"`
+	T2         *float64         `json:"t2"`
+	T3         *float64         `json:"t3"`
+	P1         *float64         `json:"p1"`
+	P2         *float64         `json:"p2"`
+	P3         *float64         `json:"p3"`
+	Channels   []channelJsonV2  `json:"channels"`
+	Status     *meterStatusJson `json:"status"`
+}
+
+type channelJsonV2 struct {
+	Number   *int     `json:"number"`
+	Role     *string  `json:"role"`
+	Quantity string   `json:"quantity"`
+	Unit     string   `json:"unit"`
+	Value    *float64 `json:"value"`
+}
+
+type eventJsonV2 struct {
+	Type            string  `json:"type"`
+	Description     *string `json:"description"`
+	System          *int    `json:"system"`
+	Start           *string `json:"start"`
+	End             *string `json:"end"`
+	DurationSeconds *uint32 `json:"durationSeconds"`
+	Count           *uint32 `json:"count"`
+}
+
+func (format JsonV2Format) Render(writer io.Writer, device *DataDevice) {
+	coefficients := device.GetCoefficientsQ()
+	deviceForJson := dataDeviceJsonV2{
+		SchemaVersion: jsonSchemaVersion,
+		Device: deviceInfoJsonV2{
+			Serial:          device.Serial,
+			Model:           optionalString(device.Model),
+			Manufacturer:    optionalString(device.Manufacturer),
+			Firmware:        optionalString(device.Firmware),
+			ProtocolVersion: optionalString(device.ProtocolVersion),
+			Address:         optionalString(device.Address),
+			Driver:          optionalString(device.Driver),
+			Type:            optionalString(device.DeviceType),
+		},
+		Poll: pollJsonV2{
+			RequestedAt:     isoTime(device.TimeRequest),
+			DeviceTime:      isoTime(device.Time),
+			DurationSeconds: device.PollDuration.Seconds(),
+			Errors:          []string{},
+		},
+		Units: unitsJsonV2{
+			Energy:      device.UnitQ.Code(),
+			Pressure:    device.UnitP.Code(),
+			Volume:      device.UnitV.Code(),
+			Mass:        device.UnitM.Code(),
+			Flow:        device.UnitG.Code(),
+			MassFlow:    device.UnitG.MassCode(),
+			Temperature: device.UnitT.Code(),
+		},
+		EnergyConversion: energyConversionJsonV2{
+			Standard:     device.GetStandardQ().Code(),
+			Coefficients: coefficientsJsonV2{GJ: coefficients.GJ, MWh: coefficients.MWh, KWh: coefficients.KWh},
+			Overridden:   device.IsOverriddenQ(),
+		},
+		TimeOn:        device.TimeOn,
+		TimeRunCommon: device.TimeRunCommon,
+		Status:        newMeterStatusJson(device.MeterStatus),
+		Systems:       []systemJsonV2{},
+		Events:        []eventJsonV2{},
+	}
+	deviceForJson.Poll.Errors = append(deviceForJson.Poll.Errors, device.Errors...)
+	deviceForJson.Result = newResultJson(device.Result)
+
+	if device.Result.Status == ResultFailed {
+		bytesResponse, _ := json.Marshal(failedJsonV2{
+			SchemaVersion: deviceForJson.SchemaVersion,
+			Result:        deviceForJson.Result,
+			Device:        deviceForJson.Device,
+			Poll:          deviceForJson.Poll,
+		})
+		fmt.Fprintln(writer, string(bytesResponse))
+		return
+	}
+
+	for i, system := range device.Systems {
+		if system.Status == false {
+			continue
+		}
+		deviceForJson.Systems = append(deviceForJson.Systems, newSystemJsonV2(device, system, i+1))
+	}
+
+	for _, event := range device.Events {
+		deviceForJson.Events = append(deviceForJson.Events, newEventJsonV2(event))
+	}
+
+	bytesResponse, err := json.Marshal(deviceForJson)
+	if err != nil {
+		fmt.Fprintln(writer, "{}")
+		return
+	}
+	fmt.Fprintln(writer, string(bytesResponse))
+}
+
+func newSystemJsonV2(device *DataDevice, system SystemDevice, number int) systemJsonV2 {
+	result := systemJsonV2{Number: number, Channels: []channelJsonV2{}, Status: newMeterStatusJson(system.MeterStatus)}
+	if system.IsSupported(FieldTimeRunSys) {
+		result.TimeRunSys = &system.TimeRunSys
+	}
+	value := func(field FieldEnum, value float64) *float64 {
+		if !system.IsSupported(field) {
+			return nil
+		}
+		return finiteFloat(value)
+	}
+	result.SigmaQ = value(FieldSigmaQ, system.SigmaQ)
+	result.Q1 = value(FieldQ1, system.Q1)
+	result.Q2 = value(FieldQ2, system.Q2)
+	result.Q3 = value(FieldQ3, system.Q3)
+	result.V1 = value(FieldV1, system.V1)
+	result.V2 = value(FieldV2, system.V2)
+	result.M1 = value(FieldM1, system.M1)
+	result.M2 = value(FieldM2, system.M2)
+	result.GM1 = value(FieldGM1, float64(system.GM1))
+	result.GM2 = value(FieldGM2, float64(system.GM2))
+	result.GV1 = value(FieldGV1, float64(system.GV1))
+	result.GV2 = value(FieldGV2, float64(system.GV2))
+	result.T1 = value(FieldT1, float64(system.T1))
+	result.T2 = value(FieldT2, float64(system.T2))
+	result.T3 = value(FieldT3, float64(system.T3))
+	result.P1 = value(FieldP1, float64(system.P1))
+	result.P2 = value(FieldP2, float64(system.P2))
+	result.P3 = value(FieldP3, float64(system.P3))
+
+	for _, channel := range system.Channels {
+		channelJson := channelJsonV2{
+			Role:     optionalString(channel.Role.Code()),
+			Quantity: channel.Quantity.Code(),
+			Unit:     channel.Unit,
+			Value:    finiteFloat(channel.Value),
+		}
+		if channel.Number > 0 {
+			channelNumber := channel.Number
+			channelJson.Number = &channelNumber
+		}
+		result.Channels = append(result.Channels, channelJson)
+	}
+	return result
+}
+
+func newEventJsonV2(event Event) eventJsonV2 {
+	result := eventJsonV2{
+		Type:        event.Type.Code(),
+		Description: optionalString(event.Description),
+		Start:       isoTime(event.Start),
+		End:         isoTime(event.End),
+	}
+	if event.System > 0 {
+		result.System = &event.System
+	}
+	if event.Duration > 0 {
+		result.DurationSeconds = &event.Duration
+	}
+	if event.Count > 0 {
+		result.Count = &event.Count
+	}
+	return result
+}
+
+// Время в формате ISO 8601 с часовым поясом. Незаполненное время выводится как null
+func isoTime(value time.Time) *string {
+	if value.IsZero() {
+		return nil
+	}
+	formatted := value.Format(time.RFC3339)
+	return &formatted
+}
+
+// NaN и бесконечность в JSON не представимы, выводятся как null
+func finiteFloat(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/**
+Вывод в текстовом формате экспозиции Prometheus (совместим с OpenMetrics).
+Накопленные значения (энергия, объём, масса, время работы) выводятся как counter с суффиксом имени _total, текущие
+(расход, температура, давление) - как gauge. Метки: serial - заводской номер, system - номер системы, quantity - обозначение величины,
+unit - единицы измерения. Значения, которые прибор не поддерживает, не выводятся.
+Состояние прибора выводится метрикой qbox_status по одной строке на флаг, system="0" - состояние прибора в целом.
+Результат опроса выводится метрикой qbox_result, при ошибке опроса выводится только она.
+*/
+type PrometheusFormat struct {
+}
+
+// Префикс имён метрик
+const prometheusPrefix = "qbox_"
+
+type prometheusFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+func (family *prometheusFamily) add(labels [][2]string, value float64) {
+	var pairs []string
+	for _, label := range labels {
+		pairs = append(pairs, label[0]+"=\""+prometheusEscape(label[1])+"\"")
+	}
+	family.samples = append(family.samples,
+		fmt.Sprintf("%s%s{%s} %s", prometheusPrefix, family.name, strings.Join(pairs, ","), strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+func (format PrometheusFormat) Render(writer io.Writer, device *DataDevice) {
+	info := &prometheusFamily{name: "info", help: "Сведения о приборе", kind: "gauge"}
+	timeOn := &prometheusFamily{name: "time_on_seconds_total", help: "Время работы при включенном питании", kind: "counter"}
+	timeDevice := &prometheusFamily{name: "time_device_seconds", help: "Время на приборе, unix time", kind: "gauge"}
+	timeRun := &prometheusFamily{name: "time_run_seconds_total", help: "Время работы системы без ошибок", kind: "counter"}
+	energy := &prometheusFamily{name: "energy_total", help: "Тепловая энергия", kind: "counter"}
+	volume := &prometheusFamily{name: "volume_total", help: "Объём", kind: "counter"}
+	mass := &prometheusFamily{name: "mass_total", help: "Масса", kind: "counter"}
+	flow := &prometheusFamily{name: "flow", help: "Объёмный расход", kind: "gauge"}
+	massFlow := &prometheusFamily{name: "mass_flow", help: "Массовый расход", kind: "gauge"}
+	temperature := &prometheusFamily{name: "temperature", help: "Температура", kind: "gauge"}
+	pressure := &prometheusFamily{name: "pressure", help: "Давление", kind: "gauge"}
+	status := &prometheusFamily{name: "status", help: "Флаги состояния прибора и систем, 1 - флаг выставлен", kind: "gauge"}
+	result := &prometheusFamily{name: "result", help: "Результат опроса, при ошибке - этап и класс ошибки", kind: "gauge"}
+
+	serial := [2]string{"serial", device.Serial}
+	result.add([][2]string{serial, {"status", device.Result.Status.Code()}, {"stage", device.Result.Stage.Code()},
+		{"class", device.Result.Class.Code()}}, 1)
+	// При ошибке опроса выводится только результат, чтобы частично прочитанные данные не приняли за показания
+	if device.Result.Status == ResultFailed {
+		result.render(writer)
+		return
+	}
+	info.add([][2]string{serial, {"model", device.Model}, {"manufacturer", device.Manufacturer},
+		{"firmware", device.Firmware}, {"protocol", device.ProtocolVersion}, {"address", device.Address}}, 1)
+	timeOn.add([][2]string{serial}, float64(device.TimeOn))
+	if !device.Time.IsZero() {
+		timeDevice.add([][2]string{serial}, float64(device.Time.Unix()))
+	}
+	addPrometheusStatus(status, serial, "0", device.MeterStatus)
+
+	for i, system := range device.Systems {
+		if system.Status == false {
+			continue
+		}
+		number := strconv.Itoa(i + 1)
+		value := func(family *prometheusFamily, field FieldEnum, quantity string, unit string, value float64) {
+			if system.IsSupported(field) {
+				family.add([][2]string{serial, {"system", number}, {"quantity", quantity}, {"unit", unit}}, value)
+			}
+		}
+		if system.IsSupported(FieldTimeRunSys) {
+			timeRun.add([][2]string{serial, {"system", number}}, float64(system.TimeRunSys))
+		}
+		value(energy, FieldSigmaQ, "SigmaQ", device.UnitQ.Code(), system.SigmaQ)
+		value(energy, FieldQ1, "Q1", device.UnitQ.Code(), system.Q1)
+		value(energy, FieldQ2, "Q2", device.UnitQ.Code(), system.Q2)
+		value(energy, FieldQ3, "Q3", device.UnitQ.Code(), system.Q3)
+		value(volume, FieldV1, "V1", device.UnitV.Code(), system.V1)
+		value(volume, FieldV2, "V2", device.UnitV.Code(), system.V2)
+		value(mass, FieldM1, "M1", device.UnitM.Code(), system.M1)
+		value(mass, FieldM2, "M2", device.UnitM.Code(), system.M2)
+		value(massFlow, FieldGM1, "GM1", device.UnitG.MassCode(), float64(system.GM1))
+		value(massFlow, FieldGM2, "GM2", device.UnitG.MassCode(), float64(system.GM2))
+		value(flow, FieldGV1, "GV1", device.UnitG.Code(), float64(system.GV1))
+		value(flow, FieldGV2, "GV2", device.UnitG.Code(), float64(system.GV2))
+		value(temperature, FieldT1, "T1", device.UnitT.Code(), float64(system.T1))
+		value(temperature, FieldT2, "T2", device.UnitT.Code(), float64(system.T2))
+		value(temperature, FieldT3, "T3", device.UnitT.Code(), float64(system.T3))
+		value(pressure, FieldP1, "P1", device.UnitP.Code(), float64(system.P1))
+		value(pressure, FieldP2, "P2", device.UnitP.Code(), float64(system.P2))
+		value(pressure, FieldP3, "P3", device.UnitP.Code(), float64(system.P3))
+
+		// Измерения по каналам попадают в метрики своей величины, обозначение дополняется номером канала
+		for _, channel := range system.Channels {
+			quantity := channel.Quantity.Code()
+			if channel.Number > 0 {
+				quantity += strconv.Itoa(channel.Number)
+			}
+			labels := [][2]string{serial, {"system", number}, {"quantity", quantity}, {"role", channel.Role.Code()}}
+			switch channel.Quantity {
+			case QuantityVolume:
+				volume.add(append(labels, [2]string{"unit", device.UnitV.Code()}), channel.Value)
+			case QuantityMass:
+				mass.add(append(labels, [2]string{"unit", device.UnitM.Code()}), channel.Value)
+			case QuantityFlow:
+				flow.add(append(labels, [2]string{"unit", device.UnitG.Code()}), channel.Value)
+			case QuantityMassFlow:
+				massFlow.add(append(labels, [2]string{"unit", device.UnitG.MassCode()}), channel.Value)
+			case QuantityTemperature:
+				temperature.add(append(labels, [2]string{"unit", device.UnitT.Code()}), channel.Value)
+			case QuantityPressure:
+				pressure.add(append(labels, [2]string{"unit", device.UnitP.Code()}), channel.Value)
+			}
+		}
+		addPrometheusStatus(status, serial, number, system.MeterStatus)
+	}
+
+	for _, family := range []*prometheusFamily{result, info, timeOn, timeDevice, timeRun, energy, volume, mass, flow,
+		massFlow, temperature, pressure, status} {
+		family.render(writer)
+	}
+}
+
+// Вывод метрик с заголовками HELP и TYPE. Пустые семейства не выводятся
+func (family *prometheusFamily) render(writer io.Writer) {
+	if len(family.samples) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "# HELP %s%s %s\n", prometheusPrefix, family.name, family.help)
+	fmt.Fprintf(writer, "# TYPE %s%s %s\n", prometheusPrefix, family.name, family.kind)
+	for _, sample := range family.samples {
+		fmt.Fprintln(writer, sample)
+	}
+}
+
+// По строке на каждый флаг состояния, чтобы по метрике можно было настраивать оповещения. nil - состояние неизвестно
+func addPrometheusStatus(family *prometheusFamily, serial [2]string, system string, status *MeterStatus) {
+	if status == nil {
+		return
+	}
+	for _, flag := range statusFlags {
+		value := 0.0
+		if status.Has(flag) {
+			value = 1
+		}
+		family.add([][2]string{serial, {"system", system}, {"flag", flag.Code()}}, value)
+	}
+}
+
+// Экранирование значения метки: обратная косая черта, кавычка и перевод строки
+func prometheusEscape(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(value)
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+/**
+Вывод по пользовательскому шаблону text/template. Шаблону передаётся *DataDevice, поля доступны как {{.Serial}},
+системы - через функцию systems, которая пропускает неактивные системы: {{range systems .}}{{.Number}} {{.V1}}{{end}}.
+Функции шаблона описаны в templateFuncs, пример шаблона - в README.
+*/
+type TemplateFormat struct {
+	template *template.Template
+}
+
+// Разбор шаблона с функциями templateFuncs. Возвращает ошибку синтаксиса шаблона
+func NewTemplateFormat(name string, text string) (*TemplateFormat, error) {
+	parsed, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormat{template: parsed}, nil
+}
+
+// Имя шаблона для неуспешного опроса: {{define "failed"}}...{{end}}
+const templateFailed = "failed"
+
+/**
+Неуспешный опрос не выводится основным шаблоном, чтобы нулевые значения не приняли за показания: выводится шаблон
+"failed", если он задан, иначе результат опроса, как в текстовом формате.
+*/
+func (format TemplateFormat) Render(writer io.Writer, device *DataDevice) {
+	var err error
+	if device.Result.Status == ResultFailed {
+		if format.template.Lookup(templateFailed) == nil {
+			fprintResult(writer, device.Result)
+			return
+		}
+		err = format.template.ExecuteTemplate(writer, templateFailed, device)
+	} else {
+		err = format.template.Execute(writer, device)
+	}
+	if err != nil {
+		// Часть результата уже выведена, поэтому ошибка выводится отдельно, чтобы не смешивать её с данными
+		_, _ = fmt.Fprintf(os.Stderr, "Ошибка шаблона вывода: %s\n", err.Error())
+	}
+}
+
+// Система прибора с номером, нумерация с 1. Передаётся в шаблон функцией systems
+type templateSystem struct {
+	SystemDevice
+	Number int
+}
+
+// Поддерживает ли прибор поле системы. Имя поля как в SystemDevice: {{if .Supported "V1"}}
+func (system templateSystem) Supported(name string) bool {
+	field, ok := templateFields[name]
+	return ok && system.IsSupported(field)
+}
+
+// Поля SystemDevice, которые учитываются в SystemDevice.Supported
+var templateFields = map[string]FieldEnum{
+	"TimeRunSys": FieldTimeRunSys,
+	"SigmaQ":     FieldSigmaQ,
+	"Q1":         FieldQ1,
+	"Q2":         FieldQ2,
+	"Q3":         FieldQ3,
+	"V1":         FieldV1,
+	"V2":         FieldV2,
+	"M1":         FieldM1,
+	"M2":         FieldM2,
+	"GM1":        FieldGM1,
+	"GM2":        FieldGM2,
+	"GV1":        FieldGV1,
+	"GV2":        FieldGV2,
+	"T1":         FieldT1,
+	"T2":         FieldT2,
+	"T3":         FieldT3,
+	"P1":         FieldP1,
+	"P2":         FieldP2,
+	"P3":         FieldP3,
+}
+
+/**
+Функции шаблона:
+systems .                - активные системы прибора с номером .Number
+value system "V1" 3      - значение поля системы с 3 знаками после точки, пустая строка, если прибор его не поддерживает
+round 2 value            - округление до 2 знаков после точки
+fixed 2 value            - строка с 2 знаками после точки
+decimal "," value        - замена десятичной точки
+date "02.01.2006" .Time  - дата в формате Go
+hours .TimeOn            - секунды в часы
+unitQ .UnitQ             - единицы энергии: ГКал, ГДж, МВт, КВт. Остальные единицы выводятся как есть: {{.UnitV}}
+status .MeterStatus      - расшифровка состояния прибора
+padLeft 10 value         - дополнение пробелами слева до 10 символов, для выравнивания по правому краю
+padRight 10 value        - дополнение пробелами справа до 10 символов
+xml value                - экранирование для XML
+*/
+var templateFuncs = template.FuncMap{
+	"systems": func(device *DataDevice) []templateSystem {
+		var systems []templateSystem
+		for i, system := range device.Systems {
+			if system.Status {
+				systems = append(systems, templateSystem{SystemDevice: system, Number: i + 1})
+			}
+		}
+		return systems
+	},
+	"value": func(system templateSystem, name string, digits int) (string, error) {
+		if _, ok := templateFields[name]; !ok {
+			return "", fmt.Errorf("поле %s отсутствует в системе прибора", name)
+		}
+		if !system.Supported(name) {
+			return "", nil
+		}
+		return templateFixed(digits, reflect.ValueOf(system.SystemDevice).FieldByName(name).Interface())
+	},
+	"round": func(digits int, value interface{}) (float64, error) {
+		number, err := templateFloat(value)
+		pow := math.Pow(10, float64(digits))
+		return math.Round(number*pow) / pow, err
+	},
+	"fixed": templateFixed,
+	"decimal": func(separator string, value interface{}) string {
+		return strings.Replace(fmt.Sprint(value), ".", separator, 1)
+	},
+	"date": func(layout string, value time.Time) string {
+		return value.Format(layout)
+	},
+	"hours": func(value interface{}) (float64, error) {
+		seconds, err := templateFloat(value)
+		return seconds / 3600, err
+	},
+	"unitQ": unitQName,
+	"status": func(status *MeterStatus) string {
+		if status == nil {
+			return textNotSupported
+		}
+		var names []string
+		for _, flag := range status.List() {
+			names = append(names, flag.String())
+		}
+		if len(names) == 0 && status.Code == 0 {
+			return "нештатных ситуаций нет"
+		}
+		if len(names) == 0 {
+			return "код " + strconv.FormatUint(uint64(status.Code), 16)
+		}
+		return strings.Join(names, ", ")
+	},
+	"padLeft": func(width int, value interface{}) string {
+		text := fmt.Sprint(value)
+		return strings.Repeat(" ", templatePad(width, text)) + text
+	},
+	"padRight": func(width int, value interface{}) string {
+		text := fmt.Sprint(value)
+		return text + strings.Repeat(" ", templatePad(width, text))
+	},
+	"xml": func(value interface{}) (string, error) {
+		var escaped strings.Builder
+		err := xml.EscapeText(&escaped, []byte(fmt.Sprint(value)))
+		return escaped.String(), err
+	},
+}
+
+func templateFixed(digits int, value interface{}) (string, error) {
+	number, err := templateFloat(value)
+	return strconv.FormatFloat(number, 'f', digits, 64), err
+}
+
+// Число для функций шаблона: значения систем бывают float32, float64 и uint32
+func templateFloat(value interface{}) (float64, error) {
+	switch number := reflect.ValueOf(value); number.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return number.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(number.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(number.Uint()), nil
+	}
+	return 0, fmt.Errorf("значение типа %T не является числом", value)
+}
+
+// Количество пробелов для выравнивания, длина считается в символах, а не в байтах
+func templatePad(width int, text string) int {
+	pad := width - len([]rune(text))
+	if pad < 0 {
+		return 0
+	}
+	return pad
+}
@@ -8,12 +8,34 @@ import (
 type TextFormat struct {
 }
 
+// Вывод значения, которое прибор не поддерживает
+const textNotSupported = "—"
+
 func (format TextFormat) Render(writer io.Writer, device *DataDevice) {
+	fprintResult(writer, device.Result)
+	if device.Result.Status == ResultFailed {
+		fmt.Fprintln(writer, "")
+		return
+	}
 	fmt.Fprintf(writer, "Заводской номер прибора - %v\n", device.Serial)
+	fprintOptional(writer, "Модель прибора", device.Model)
+	fprintOptional(writer, "Производитель", device.Manufacturer)
+	fprintOptional(writer, "Версия ПО прибора", device.Firmware)
+	fprintOptional(writer, "Версия протокола", device.ProtocolVersion)
+	fprintOptional(writer, "Сетевой адрес", device.Address)
 	fmt.Fprintf(writer, "Время опроса - %s\n", device.TimeRequest.Format("02.01.2006 15:04:05"))
 	fmt.Fprintf(writer, "Время на приборе - %s\n", device.Time.Format("02.01.2006 15:04:05"))
 	fmt.Fprintf(writer, "Время работы при включенном питании - %f ч\n", float32(device.TimeOn)/3600.00)
 	fmt.Fprintf(writer, "Время работы без ошибок - %f ч\n", float32(device.TimeRunCommon)/3600.00)
+	fprintMeterStatus(writer, "Состояние прибора", device.MeterStatus)
+
+	coefficients := device.GetCoefficientsQ()
+	fmt.Fprintf(writer, "Стандарт пересчёта энергии - %s", device.GetStandardQ().String())
+	if device.IsOverriddenQ() {
+		fmt.Fprint(writer, ", коэффициенты заданы для прибора")
+	}
+	fmt.Fprintln(writer, "")
+	fmt.Fprintf(writer, "Переводные коэффициенты в ГКал: ГДж - %g, МВт - %g, КВт - %g\n", coefficients.GJ, coefficients.MWh, coefficients.KWh)
 
 	var textUnitQ string
 
@@ -33,26 +55,125 @@ func (format TextFormat) Render(writer io.Writer, device *DataDevice) {
 		}
 		fmt.Fprintln(writer, "")
 		fmt.Fprintf(writer, "Показания системы %d:\n", i+1)
-		fmt.Fprintf(writer, "Q результирующее %f %s\n", system.SigmaQ, textUnitQ)
-		fmt.Fprintf(writer, "Q1 %f %s\n", system.Q1, textUnitQ)
-		fmt.Fprintf(writer, "Q2 %f %s\n", system.Q2, textUnitQ)
-		fmt.Fprintf(writer, "Q3 %f %s\n", system.Q3, textUnitQ)
-		fmt.Fprintf(writer, "V1 %f м3\n", system.V1)
-		fmt.Fprintf(writer, "V2 %f м3\n", system.V2)
-		fmt.Fprintf(writer, "M1 %f тонн\n", system.M1)
-		fmt.Fprintf(writer, "M2 %f тонн\n", system.M2)
-		fmt.Fprintf(writer, "G1 массовый %f тонн/ч\n", system.GM1)
-		fmt.Fprintf(writer, "G2 массовый %f тонн/ч\n", system.GM2)
-		fmt.Fprintf(writer, "G1 объёмный %f м3/ч\n", system.GV1)
-		fmt.Fprintf(writer, "G2 объёмный %f м3/ч\n", system.GV2)
-		fmt.Fprintf(writer, "T1 %f C\n", system.T1)
-		fmt.Fprintf(writer, "T2 %f C\n", system.T2)
-		fmt.Fprintf(writer, "T3 %f C\n", system.T3)
-		fmt.Fprintf(writer, "P1 %f МПа\n", system.P1)
-		fmt.Fprintf(writer, "P2 %f МПа\n", system.P2)
-		fmt.Fprintf(writer, "P3 %f МПа\n", system.P3)
-		fmt.Fprintf(writer, "Время работы системы (без ошибок) № %d - %f ч\n", i+1, float32(system.TimeRunSys)/3600.00)
+		fprintSystemValue(writer, system, FieldSigmaQ, "Q результирующее", system.SigmaQ, textUnitQ)
+		fprintSystemValue(writer, system, FieldQ1, "Q1", system.Q1, textUnitQ)
+		fprintSystemValue(writer, system, FieldQ2, "Q2", system.Q2, textUnitQ)
+		fprintSystemValue(writer, system, FieldQ3, "Q3", system.Q3, textUnitQ)
+		fprintSystemValue(writer, system, FieldV1, "V1", system.V1, device.UnitV.String())
+		fprintSystemValue(writer, system, FieldV2, "V2", system.V2, device.UnitV.String())
+		fprintSystemValue(writer, system, FieldM1, "M1", system.M1, device.UnitM.String())
+		fprintSystemValue(writer, system, FieldM2, "M2", system.M2, device.UnitM.String())
+		fprintSystemValue(writer, system, FieldGM1, "G1 массовый", float64(system.GM1), device.UnitG.MassString())
+		fprintSystemValue(writer, system, FieldGM2, "G2 массовый", float64(system.GM2), device.UnitG.MassString())
+		fprintSystemValue(writer, system, FieldGV1, "G1 объёмный", float64(system.GV1), device.UnitG.String())
+		fprintSystemValue(writer, system, FieldGV2, "G2 объёмный", float64(system.GV2), device.UnitG.String())
+		fprintSystemValue(writer, system, FieldT1, "T1", float64(system.T1), device.UnitT.String())
+		fprintSystemValue(writer, system, FieldT2, "T2", float64(system.T2), device.UnitT.String())
+		fprintSystemValue(writer, system, FieldT3, "T3", float64(system.T3), device.UnitT.String())
+		fprintSystemValue(writer, system, FieldP1, "P1", float64(system.P1), device.UnitP.String())
+		fprintSystemValue(writer, system, FieldP2, "P2", float64(system.P2), device.UnitP.String())
+		fprintSystemValue(writer, system, FieldP3, "P3", float64(system.P3), device.UnitP.String())
+		for _, channel := range system.Channels {
+			fmt.Fprintf(writer, "%s", channel.Quantity.Code())
+			if channel.Number > 0 {
+				fmt.Fprintf(writer, " канал %d", channel.Number)
+			}
+			if channel.Role != RoleUnknown {
+				fmt.Fprintf(writer, " (%s)", channel.Role.String())
+			}
+			fmt.Fprintf(writer, " %f %s\n", channel.Value, channel.UnitString())
+		}
+		if system.IsSupported(FieldTimeRunSys) {
+			fmt.Fprintf(writer, "Время работы системы (без ошибок) № %d - %f ч\n", i+1, float32(system.TimeRunSys)/3600.00)
+		} else {
+			fmt.Fprintf(writer, "Время работы системы (без ошибок) № %d - %s\n", i+1, textNotSupported)
+		}
+		if system.MeterStatus != nil {
+			fprintMeterStatus(writer, fmt.Sprintf("Состояние системы № %d", i+1), system.MeterStatus)
+		}
 	}
 
+	if len(device.Events) > 0 {
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, "События:")
+	}
+	for _, event := range device.Events {
+		if event.System > 0 {
+			fmt.Fprintf(writer, "Система %d. ", event.System)
+		}
+		fmt.Fprint(writer, event.Type.String())
+		if event.Description != "" {
+			fmt.Fprintf(writer, " (%s)", event.Description)
+		}
+		if !event.Start.IsZero() {
+			fmt.Fprintf(writer, ", начало - %s", event.Start.Format("02.01.2006 15:04"))
+		}
+		if !event.End.IsZero() {
+			fmt.Fprintf(writer, ", окончание - %s", event.End.Format("02.01.2006 15:04"))
+		}
+		if event.Duration > 0 {
+			fmt.Fprintf(writer, ", длительность - %f ч", float32(event.Duration)/3600.00)
+		}
+		if event.Count > 0 {
+			fmt.Fprintf(writer, ", количество - %d", event.Count)
+		}
+		fmt.Fprintln(writer, "")
+	}
+
+	fmt.Fprintln(writer, "")
+}
+
+// Вывод значения системы с единицами измерения, либо прочерка, если прибор не поддерживает значение
+func fprintSystemValue(writer io.Writer, system SystemDevice, field FieldEnum, label string, value float64, unit string) {
+	if !system.IsSupported(field) {
+		fmt.Fprintf(writer, "%s %s\n", label, textNotSupported)
+		return
+	}
+	fmt.Fprintf(writer, "%s %f %s\n", label, value, unit)
+}
+
+// Вывод результата опроса, при ошибке - этапа, класса ошибки и сообщения
+func fprintResult(writer io.Writer, result PollResult) {
+	fmt.Fprintf(writer, "Результат опроса - %s\n", result.Status.String())
+	if result.Status == ResultSuccess {
+		return
+	}
+	fmt.Fprintf(writer, "Этап опроса - %s\n", result.Stage.String())
+	fmt.Fprintf(writer, "Класс ошибки - %s\n", result.Class.String())
+	fmt.Fprintf(writer, "Ошибка - %s\n", result.Message)
+}
+
+// Вывод сведений о приборе, либо прочерка, если драйвер их не получает
+func fprintOptional(writer io.Writer, label string, value string) {
+	if value == "" {
+		value = textNotSupported
+	}
+	fmt.Fprintf(writer, "%s - %s\n", label, value)
+}
+
+// Вывод состояния с кодом прибора и расшифровкой флагов, либо прочерка, если драйвер не получает состояние
+func fprintMeterStatus(writer io.Writer, label string, status *MeterStatus) {
+	if status == nil {
+		fmt.Fprintf(writer, "%s - %s\n", label, textNotSupported)
+		return
+	}
+	fmt.Fprintf(writer, "%s - код %X", label, status.Code)
+	flags := status.List()
+	if len(flags) == 0 && status.Code == 0 {
+		fmt.Fprintln(writer, ", нештатных ситуаций нет")
+		return
+	}
+	if len(flags) == 0 {
+		fmt.Fprintln(writer, ", флаги не расшифрованы")
+		return
+	}
+	for i, flag := range flags {
+		if i == 0 {
+			fmt.Fprint(writer, ": ")
+		} else {
+			fmt.Fprint(writer, ", ")
+		}
+		fmt.Fprint(writer, flag.String())
+	}
 	fmt.Fprintln(writer, "")
 }
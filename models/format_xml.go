@@ -0,0 +1,211 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+Вывод в формате XML для загрузки показаний в учётные системы (1С и т.п.). Схема документа - schema/readings.xsd.
+Документ содержит один или несколько приборов: сведения о приборе, время опроса и время на приборе, показания систем
+с единицами измерения, состояние и результат опроса. Значения, которые прибор не поддерживает, не выводятся.
+*/
+type XmlFormat struct {
+	Encoding string // Кодировка документа: XmlUtf8 (по умолчанию) или XmlWindows1251
+}
+
+// Кодировки документа XML
+const (
+	XmlUtf8        = "utf-8"
+	XmlWindows1251 = "windows-1251"
+)
+
+// Версия схемы документа, выводится в атрибуте version
+const xmlSchemaVersion = "1"
+
+type xmlReadings struct {
+	XMLName xml.Name   `xml:"readings"`
+	Version string     `xml:"version,attr"`
+	Created string     `xml:"created,attr"`
+	Meters  []xmlMeter `xml:"meter"`
+}
+
+type xmlMeter struct {
+	Serial          string      `xml:"serial,attr"`
+	Result          xmlResult   `xml:"result"`
+	Model           string      `xml:"model,omitempty"`
+	Manufacturer    string      `xml:"manufacturer,omitempty"`
+	Firmware        string      `xml:"firmware,omitempty"`
+	ProtocolVersion string      `xml:"protocolVersion,omitempty"`
+	Address         string      `xml:"address,omitempty"`
+	Driver          string      `xml:"driver,omitempty"`
+	TimeRequest     string      `xml:"timeRequest"`
+	TimeDevice      string      `xml:"timeDevice,omitempty"`
+	TimeOn          *uint32     `xml:"timeOn"`
+	TimeRunCommon   *uint32     `xml:"timeRunCommon"`
+	Status          *xmlStatus  `xml:"status"`
+	Systems         []xmlSystem `xml:"system"`
+}
+
+type xmlSystem struct {
+	Number     int          `xml:"number,attr"`
+	TimeRunSys *uint32      `xml:"timeRunSys"`
+	Values     []xmlValue   `xml:"value"`
+	Channels   []xmlChannel `xml:"channel"`
+	Status     *xmlStatus   `xml:"status"`
+}
+
+type xmlValue struct {
+	Name  string `xml:"name,attr"`
+	Unit  string `xml:"unit,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlChannel struct {
+	Quantity string `xml:"quantity,attr"`
+	Number   int    `xml:"number,attr,omitempty"`
+	Role     string `xml:"role,attr,omitempty"`
+	Unit     string `xml:"unit,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type xmlStatus struct {
+	Code  uint32    `xml:"code,attr"`
+	Flags []xmlFlag `xml:"flag"`
+}
+
+// Результат опроса, при ошибке - этап, класс ошибки и сообщение
+type xmlResult struct {
+	Status  string `xml:"status,attr"`
+	Stage   string `xml:"stage,attr,omitempty"`
+	Class   string `xml:"class,attr,omitempty"`
+	Message string `xml:",chardata"`
+}
+
+type xmlFlag struct {
+	Code string `xml:"code,attr"`
+	Name string `xml:",chardata"`
+}
+
+func (format XmlFormat) Render(writer io.Writer, device *DataDevice) {
+	format.RenderAll(writer, []*DataDevice{device})
+}
+
+// Документ с данными нескольких приборов, опрошенных за один запуск утилиты
+func (format XmlFormat) RenderAll(writer io.Writer, devices []*DataDevice) {
+	readings := xmlReadings{Version: xmlSchemaVersion, Created: time.Now().Format(time.RFC3339)}
+	for _, device := range devices {
+		readings.Meters = append(readings.Meters, newXmlMeter(device))
+	}
+
+	documentEncoding := XmlUtf8
+	if strings.EqualFold(format.Encoding, XmlWindows1251) {
+		documentEncoding = XmlWindows1251
+		// Символы, которых нет в кодировке, заменяются, чтобы документ не обрывался
+		writer = encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder()).Writer(writer)
+	}
+
+	fmt.Fprintf(writer, "<?xml version=\"1.0\" encoding=\"%s\"?>\n", documentEncoding)
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	err := encoder.Encode(readings)
+	if err != nil {
+		fmt.Fprintln(writer, "<readings/>")
+		return
+	}
+	fmt.Fprintln(writer, "")
+}
+
+func newXmlMeter(device *DataDevice) xmlMeter {
+	meter := xmlMeter{
+		Serial:          device.Serial,
+		Model:           device.Model,
+		Manufacturer:    device.Manufacturer,
+		Firmware:        device.Firmware,
+		ProtocolVersion: device.ProtocolVersion,
+		Address:         device.Address,
+		Driver:          device.Driver,
+		TimeRequest:     device.TimeRequest.Format(time.RFC3339),
+		Result: xmlResult{
+			Status:  device.Result.Status.Code(),
+			Stage:   device.Result.Stage.Code(),
+			Class:   device.Result.Class.Code(),
+			Message: device.Result.Message,
+		},
+	}
+	// При ошибке опроса показания не выводятся, чтобы частично прочитанные данные не приняли за показания
+	if device.Result.Status == ResultFailed {
+		return meter
+	}
+	meter.TimeOn = &device.TimeOn
+	meter.TimeRunCommon = &device.TimeRunCommon
+	meter.Status = newXmlStatus(device.MeterStatus)
+	if !device.Time.IsZero() {
+		meter.TimeDevice = device.Time.Format(time.RFC3339)
+	}
+
+	for i, system := range device.Systems {
+		if system.Status == false {
+			continue
+		}
+		xmlSystem := xmlSystem{Number: i + 1, Status: newXmlStatus(system.MeterStatus)}
+		if system.IsSupported(FieldTimeRunSys) {
+			timeRunSys := system.TimeRunSys
+			xmlSystem.TimeRunSys = &timeRunSys
+		}
+		value := func(field FieldEnum, name string, unit string, value float64) {
+			if system.IsSupported(field) {
+				xmlSystem.Values = append(xmlSystem.Values,
+					xmlValue{Name: name, Unit: unit, Value: strconv.FormatFloat(value, 'f', -1, 64)})
+			}
+		}
+		value(FieldSigmaQ, "SigmaQ", device.UnitQ.Code(), system.SigmaQ)
+		value(FieldQ1, "Q1", device.UnitQ.Code(), system.Q1)
+		value(FieldQ2, "Q2", device.UnitQ.Code(), system.Q2)
+		value(FieldQ3, "Q3", device.UnitQ.Code(), system.Q3)
+		value(FieldV1, "V1", device.UnitV.Code(), system.V1)
+		value(FieldV2, "V2", device.UnitV.Code(), system.V2)
+		value(FieldM1, "M1", device.UnitM.Code(), system.M1)
+		value(FieldM2, "M2", device.UnitM.Code(), system.M2)
+		value(FieldGM1, "GM1", device.UnitG.MassCode(), float64(system.GM1))
+		value(FieldGM2, "GM2", device.UnitG.MassCode(), float64(system.GM2))
+		value(FieldGV1, "GV1", device.UnitG.Code(), float64(system.GV1))
+		value(FieldGV2, "GV2", device.UnitG.Code(), float64(system.GV2))
+		value(FieldT1, "T1", device.UnitT.Code(), float64(system.T1))
+		value(FieldT2, "T2", device.UnitT.Code(), float64(system.T2))
+		value(FieldT3, "T3", device.UnitT.Code(), float64(system.T3))
+		value(FieldP1, "P1", device.UnitP.Code(), float64(system.P1))
+		value(FieldP2, "P2", device.UnitP.Code(), float64(system.P2))
+		value(FieldP3, "P3", device.UnitP.Code(), float64(system.P3))
+
+		for _, channel := range system.Channels {
+			xmlSystem.Channels = append(xmlSystem.Channels, xmlChannel{
+				Quantity: channel.Quantity.Code(),
+				Number:   channel.Number,
+				Role:     channel.Role.Code(),
+				Unit:     channel.Unit,
+				Value:    strconv.FormatFloat(channel.Value, 'f', -1, 64),
+			})
+		}
+		meter.Systems = append(meter.Systems, xmlSystem)
+	}
+	return meter
+}
+
+// Состояние с кодом прибора и расшифрованными флагами. nil - драйвер не получает состояние, элемент не выводится
+func newXmlStatus(status *MeterStatus) *xmlStatus {
+	if status == nil {
+		return nil
+	}
+	result := &xmlStatus{Code: status.Code}
+	for _, flag := range status.List() {
+		result.Flags = append(result.Flags, xmlFlag{Code: flag.Code(), Name: flag.String()})
+	}
+	return result
+}
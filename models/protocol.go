@@ -0,0 +1,340 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"github.com/npat-efault/crc16"
+	"sort"
+)
+
+type ProtocolEnum byte // Протокол обмена с теплосчётчиком: формат кадра и контрольная сумма
+const (
+	ProtocolNone   ProtocolEnum = 0x00 // Кадр без служебных полей, например ТЭМ-05М
+	ProtocolTem    ProtocolEnum = 0x01 // Протокол ТЭМ: 55h/AAh, адрес, инверсный адрес, команда, длина, сумма с дополнением
+	ProtocolMbus   ProtocolEnum = 0x02 // M-Bus (EN 13757-2): E5h, короткий кадр 10h и длинный кадр 68h L L 68h
+	ProtocolModbus ProtocolEnum = 0x03 // Modbus RTU, CRC16
+	ProtocolSku02  ProtocolEnum = 0x04 // SKU-02: 68h L(2 байта) 68h, три контрольные суммы
+)
+
+var protocolCodes = map[ProtocolEnum]string{
+	ProtocolNone:   "none",
+	ProtocolTem:    "tem",
+	ProtocolMbus:   "mbus",
+	ProtocolModbus: "modbus",
+	ProtocolSku02:  "sku02",
+}
+
+var protocolNames = map[ProtocolEnum]string{
+	ProtocolNone:   "без служебных полей",
+	ProtocolTem:    "ТЭМ",
+	ProtocolMbus:   "M-Bus",
+	ProtocolModbus: "Modbus RTU",
+	ProtocolSku02:  "SKU-02",
+}
+
+// Обозначение протокола для флагов утилиты. Например: tem
+func (protocol ProtocolEnum) Code() string {
+	return protocolCodes[protocol]
+}
+
+// Наименование протокола. Например: Modbus RTU
+func (protocol ProtocolEnum) String() string {
+	return protocolNames[protocol]
+}
+
+// Протокол по обозначению из флага утилиты
+func ProtocolByCode(code string) (ProtocolEnum, error) {
+	for protocol, protocolCode := range protocolCodes {
+		if protocolCode == code {
+			return protocol, nil
+		}
+	}
+	return ProtocolNone, errors.New("протокол обмена задан не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+/**
+Поле кадра: смещение от начала кадра, размер в байтах, наименование и расшифрованное значение
+*/
+type FrameField struct {
+	Offset int
+	Size   int
+	Name   string
+	Value  string
+}
+
+/**
+Разбор кадра по протоколу обмена.
+Fields - служебные поля кадра по порядку смещений, данные кадра в Fields не входят, их расположение задают DataOffset и
+DataSize. Checksum - контрольная сумма, рассчитанная по кадру, nil, если протокол не использует контрольную сумму
+или кадр получен не полностью.
+*/
+type Frame struct {
+	Fields     []FrameField
+	DataOffset int
+	DataSize   int
+	Checksum   []byte
+	ChecksumOk bool
+}
+
+// Смещение контрольной суммы в кадре и её правильное значение.
+// Если протокол не использует контрольную сумму или кадр неполный, возвращается nil.
+func (protocol ProtocolEnum) Checksum(frame []byte) (int, []byte) {
+	switch protocol {
+	case ProtocolTem:
+		if len(frame) < 7 {
+			return 0, nil
+		}
+		return len(frame) - 1, []byte{temSum(frame[:len(frame)-1])}
+	case ProtocolMbus:
+		if len(frame) == 5 && frame[0] == 0x10 {
+			return 3, []byte{mbusSum(frame[1:3])}
+		}
+		if len(frame) >= 9 && frame[0] == 0x68 {
+			return len(frame) - 2, []byte{mbusSum(frame[4 : len(frame)-2])}
+		}
+		return 0, nil
+	case ProtocolModbus:
+		if len(frame) < 4 {
+			return 0, nil
+		}
+		crc := crc16.Checksum(crc16.Modbus, frame[:len(frame)-2])
+		return len(frame) - 2, []byte{byte(crc), byte(crc >> 8)}
+	case ProtocolSku02:
+		if len(frame) < 8 {
+			return 0, nil
+		}
+		length := int(frame[1])<<8 | int(frame[2])
+		if length < 8 || length > len(frame) {
+			return 0, nil
+		}
+		return length - 4, sku02Sum(frame[:length-4])
+	}
+	return 0, nil
+}
+
+/**
+Дополнение кадра контрольной суммой протокола. Кадр задаётся без контрольной суммы, у кадров M-Bus и SKU-02 также без
+стоп-байта 16h, он добавляется вместе с суммой. Длина в заголовке кадра не пересчитывается.
+*/
+func (protocol ProtocolEnum) Seal(frame []byte) []byte {
+	sealed := append([]byte{}, frame...)
+	switch protocol {
+	case ProtocolTem:
+		return append(sealed, temSum(frame))
+	case ProtocolMbus:
+		if len(frame) == 3 && frame[0] == 0x10 {
+			return append(sealed, mbusSum(frame[1:3]), 0x16)
+		}
+		if len(frame) >= 7 && frame[0] == 0x68 {
+			return append(sealed, mbusSum(frame[4:]), 0x16)
+		}
+	case ProtocolModbus:
+		crc := crc16.Checksum(crc16.Modbus, frame)
+		return append(sealed, byte(crc), byte(crc>>8))
+	case ProtocolSku02:
+		return append(append(sealed, sku02Sum(frame)...), 0x16)
+	}
+	return sealed
+}
+
+// Кадр получен полностью: длина соответствует заголовку кадра и контрольная сумма верна.
+// Для кадров без служебных полей всегда false, конец такого кадра определяется только по таймауту.
+func (protocol ProtocolEnum) Complete(frame []byte) bool {
+	switch protocol {
+	case ProtocolTem:
+		if len(frame) < 7 || len(frame) != int(frame[5])+7 {
+			return false
+		}
+	case ProtocolMbus:
+		if len(frame) == 1 && frame[0] == 0xE5 {
+			return true
+		}
+		if len(frame) < 5 || frame[0] == 0x68 && len(frame) != int(frame[1])+6 || frame[len(frame)-1] != 0x16 {
+			return false
+		}
+	case ProtocolSku02:
+		if len(frame) < 8 || len(frame) != int(frame[1])<<8|int(frame[2]) || frame[len(frame)-1] != 0x16 {
+			return false
+		}
+	}
+	offset, checksum := protocol.Checksum(frame)
+	return checksum != nil && string(frame[offset:offset+len(checksum)]) == string(checksum)
+}
+
+// Разбор служебных полей кадра. request - кадр является запросом к прибору, иначе ответом прибора
+func (protocol ProtocolEnum) Dissect(frame []byte, request bool) Frame {
+	result := Frame{DataSize: len(frame)}
+	field := func(offset int, size int, name string, value string) {
+		if offset+size <= len(frame) {
+			result.Fields = append(result.Fields, FrameField{Offset: offset, Size: size, Name: name, Value: value})
+		}
+	}
+	data := func(offset int, size int) {
+		if offset > len(frame) {
+			offset = len(frame)
+		}
+		if size < 0 || offset+size > len(frame) {
+			size = len(frame) - offset
+		}
+		result.DataOffset, result.DataSize = offset, size
+	}
+
+	switch protocol {
+	case ProtocolTem:
+		if len(frame) < 6 {
+			return result
+		}
+		direction := "ответ прибора"
+		if frame[0] == 0x55 {
+			direction = "запрос к прибору"
+		} else if frame[0] != 0xAA {
+			direction = "неизвестный заголовок"
+		}
+		field(0, 1, "Заголовок", direction)
+		field(1, 1, "Адрес прибора", fmt.Sprint(frame[1]))
+		field(2, 1, "Инверсный адрес", inverseStatus(frame[1], frame[2]))
+		field(3, 1, "Группа команд", fmt.Sprintf("%02Xh", frame[3]))
+		field(4, 1, "Команда", fmt.Sprintf("%02Xh", frame[4]))
+		field(5, 1, "Длина данных", fmt.Sprint(frame[5]))
+		data(6, int(frame[5]))
+	case ProtocolMbus:
+		switch {
+		case len(frame) == 1 && frame[0] == 0xE5:
+			field(0, 1, "Подтверждение", "E5h")
+			data(1, 0)
+		case len(frame) == 5 && frame[0] == 0x10:
+			field(0, 1, "Старт", "короткий кадр")
+			field(1, 1, "C-поле", mbusControl(frame[1]))
+			field(2, 1, "Адрес прибора", fmt.Sprint(frame[2]))
+			field(4, 1, "Стоп", stopStatus(frame[4]))
+			data(3, 0)
+		case len(frame) >= 9 && frame[0] == 0x68:
+			field(0, 1, "Старт", "длинный кадр")
+			field(1, 1, "Длина L", fmt.Sprint(frame[1]))
+			field(2, 1, "Длина L (повтор)", repeatStatus(frame[1], frame[2]))
+			field(3, 1, "Старт (повтор)", repeatStatus(frame[0], frame[3]))
+			field(4, 1, "C-поле", mbusControl(frame[4]))
+			field(5, 1, "Адрес прибора", fmt.Sprint(frame[5]))
+			field(6, 1, "CI-поле", fmt.Sprintf("%02Xh", frame[6]))
+			field(len(frame)-1, 1, "Стоп", stopStatus(frame[len(frame)-1]))
+			data(7, len(frame)-9)
+		}
+	case ProtocolModbus:
+		if len(frame) < 4 {
+			return result
+		}
+		field(0, 1, "Адрес прибора", fmt.Sprint(frame[0]))
+		field(1, 1, "Функция", fmt.Sprintf("%02Xh", frame[1]))
+		switch {
+		case frame[1]&0x80 != 0:
+			field(2, 1, "Код ошибки", fmt.Sprintf("%02Xh", frame[2]))
+			data(3, 0)
+		case request || frame[1] == 0x10:
+			field(2, 2, "Адрес регистра", fmt.Sprintf("%04Xh", int(frame[2])<<8|int(frame[3])))
+			field(4, 2, "Количество регистров", fmt.Sprint(int(frame[4])<<8|int(frame[5])))
+			data(6, len(frame)-8)
+			if request && frame[1] == 0x10 {
+				field(6, 1, "Длина данных", fmt.Sprint(frame[6]))
+				data(7, len(frame)-9)
+			}
+		default:
+			field(2, 1, "Длина данных", fmt.Sprint(frame[2]))
+			data(3, len(frame)-5)
+		}
+	case ProtocolSku02:
+		if len(frame) < 8 {
+			return result
+		}
+		length := int(frame[1])<<8 | int(frame[2])
+		field(0, 1, "Старт", fmt.Sprintf("%02Xh", frame[0]))
+		field(1, 2, "Длина кадра", fmt.Sprint(length))
+		field(3, 1, "Старт (повтор)", repeatStatus(frame[0], frame[3]))
+		if length >= 8 && length <= len(frame) {
+			field(length-1, 1, "Стоп", stopStatus(frame[length-1]))
+		}
+		data(4, length-8)
+	default:
+		return result
+	}
+
+	if offset, checksum := protocol.Checksum(frame); checksum != nil {
+		result.Checksum = checksum
+		result.ChecksumOk = string(frame[offset:offset+len(checksum)]) == string(checksum)
+		status := "верна"
+		if !result.ChecksumOk {
+			status = fmt.Sprintf("неверна, ожидалась % X", checksum)
+		}
+		field(offset, len(checksum), "Контрольная сумма", status)
+	}
+	sort.Slice(result.Fields, func(i, j int) bool {
+		return result.Fields[i].Offset < result.Fields[j].Offset
+	})
+	return result
+}
+
+// Сумма байт с дополнением, протокол ТЭМ
+func temSum(bytes []byte) byte {
+	var sum byte
+	for _, b := range bytes {
+		sum += b
+	}
+	return ^sum
+}
+
+// Арифметическая сумма байт, M-Bus
+func mbusSum(bytes []byte) byte {
+	var sum byte
+	for _, b := range bytes {
+		sum += b
+	}
+	return sum
+}
+
+// Три контрольные суммы SKU-02: исключающее ИЛИ, инверсия третьей суммы, сумма байт с удвоенным исключающим ИЛИ
+func sku02Sum(bytes []byte) []byte {
+	var xor, sum byte
+	for _, b := range bytes {
+		xor ^= b
+		sum += b
+	}
+	sum += xor * 2
+	return []byte{xor, sum ^ 0xFF, sum}
+}
+
+func inverseStatus(address byte, inverse byte) string {
+	if ^address == inverse {
+		return "совпадает"
+	}
+	return fmt.Sprintf("не совпадает, ожидался %02Xh", ^address)
+}
+
+func repeatStatus(first byte, repeat byte) string {
+	if first == repeat {
+		return "совпадает"
+	}
+	return fmt.Sprintf("не совпадает, ожидался %02Xh", first)
+}
+
+func stopStatus(stop byte) string {
+	if stop == 0x16 {
+		return "16h"
+	}
+	return fmt.Sprintf("неверный %02Xh, ожидался 16h", stop)
+}
+
+// Назначение C-поля M-Bus по коду функции
+func mbusControl(control byte) string {
+	switch control & 0x4F {
+	case 0x40:
+		return fmt.Sprintf("%02Xh, SND_NKE - инициализация", control)
+	case 0x43:
+		return fmt.Sprintf("%02Xh, SND_UD - передача данных", control)
+	case 0x4B:
+		return fmt.Sprintf("%02Xh, REQ_UD2 - запрос данных", control)
+	case 0x4A:
+		return fmt.Sprintf("%02Xh, REQ_UD1 - запрос данных", control)
+	case 0x08:
+		return fmt.Sprintf("%02Xh, RSP_UD - ответ с данными", control)
+	}
+	return fmt.Sprintf("%02Xh", control)
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProtocolChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol ProtocolEnum
+		frame    []byte
+		offset   int
+		checksum []byte
+	}{
+		{"ТЭМ запрос", ProtocolTem, []byte{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x00, 0x00, 0x07, 0x91}, 9, []byte{0x91}},
+		{"ТЭМ ответ", ProtocolTem, []byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03, 0x3D}, 9, []byte{0x3D}},
+		{"ТЭМ неполный", ProtocolTem, []byte{0xAA, 0x01, 0xFE, 0x0F}, 0, nil},
+		{"M-Bus короткий", ProtocolMbus, []byte{0x10, 0x5B, 0x01, 0x5C, 0x16}, 3, []byte{0x5C}},
+		{"M-Bus длинный", ProtocolMbus, []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50, 0xA4, 0x16}, 7, []byte{0xA4}},
+		{"M-Bus подтверждение", ProtocolMbus, []byte{0xE5}, 0, nil},
+		{"Modbus запрос", ProtocolModbus, []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A, 0xC5, 0xCD}, 6, []byte{0xC5, 0xCD}},
+		{"Modbus ответ", ProtocolModbus, []byte{0x01, 0x03, 0x02, 0x00, 0x0A, 0x38, 0x43}, 5, []byte{0x38, 0x43}},
+		{"SKU-02", ProtocolSku02, []byte{0x68, 0x00, 0x0C, 0x68, 0x01, 0x02, 0x03, 0x04, 0x08, 0x09, 0xF6, 0x16}, 8,
+			[]byte{0x08, 0x09, 0xF6}},
+		{"SKU-02 длина больше кадра", ProtocolSku02, []byte{0x68, 0x00, 0x20, 0x68, 0x01, 0x02, 0x03, 0x04}, 0, nil},
+		{"без служебных полей", ProtocolNone, []byte{0x33, 0x81, 0x7E, 0x32}, 0, nil},
+	}
+	for _, test := range tests {
+		offset, checksum := test.protocol.Checksum(test.frame)
+		if offset != test.offset || !bytes.Equal(checksum, test.checksum) {
+			t.Errorf("%s: Checksum() = %d, % X, ожидалось %d, % X", test.name, offset, checksum, test.offset, test.checksum)
+		}
+	}
+}
+
+func TestProtocolDissect(t *testing.T) {
+	frame := ProtocolTem.Dissect([]byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03, 0x3D}, false)
+	if frame.DataOffset != 6 || frame.DataSize != 3 || !frame.ChecksumOk {
+		t.Fatalf("ТЭМ: данные %d+%d, сумма верна %v", frame.DataOffset, frame.DataSize, frame.ChecksumOk)
+	}
+	names := []string{"Заголовок", "Адрес прибора", "Инверсный адрес", "Группа команд", "Команда", "Длина данных",
+		"Контрольная сумма"}
+	if len(frame.Fields) != len(names) {
+		t.Fatalf("ТЭМ: полей %d, ожидалось %d", len(frame.Fields), len(names))
+	}
+	for i, name := range names {
+		if frame.Fields[i].Name != name {
+			t.Errorf("ТЭМ: поле %d - %s, ожидалось %s", i, frame.Fields[i].Name, name)
+		}
+	}
+	if frame.Fields[0].Value != "ответ прибора" || frame.Fields[2].Value != "совпадает" {
+		t.Errorf("ТЭМ: заголовок %q, инверсный адрес %q", frame.Fields[0].Value, frame.Fields[2].Value)
+	}
+
+	frame = ProtocolTem.Dissect([]byte{0xAA, 0x01, 0xFD, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03, 0x00}, false)
+	if frame.ChecksumOk || frame.Fields[2].Value == "совпадает" {
+		t.Errorf("ТЭМ: повреждённый кадр принят, сумма верна %v, инверсный адрес %q", frame.ChecksumOk, frame.Fields[2].Value)
+	}
+
+	frame = ProtocolModbus.Dissect([]byte{0x01, 0x03, 0x02, 0x00, 0x0A, 0x38, 0x43}, false)
+	if frame.DataOffset != 3 || frame.DataSize != 2 || !frame.ChecksumOk {
+		t.Errorf("Modbus ответ: данные %d+%d, сумма верна %v", frame.DataOffset, frame.DataSize, frame.ChecksumOk)
+	}
+	frame = ProtocolModbus.Dissect([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A, 0xC5, 0xCD}, true)
+	if frame.DataSize != 0 || len(frame.Fields) != 5 || frame.Fields[2].Value != "0000h" || frame.Fields[3].Value != "10" {
+		t.Errorf("Modbus запрос: данные %d байт, поля %v", frame.DataSize, frame.Fields)
+	}
+	frame = ProtocolModbus.Dissect([]byte{0x01, 0x83, 0x02, 0xC0, 0xF1}, false)
+	if frame.Fields[2].Name != "Код ошибки" || !frame.ChecksumOk {
+		t.Errorf("Modbus ошибка: поля %v", frame.Fields)
+	}
+
+	frame = ProtocolMbus.Dissect([]byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50, 0xA4, 0x16}, true)
+	if frame.DataOffset != 7 || frame.DataSize != 0 || !frame.ChecksumOk || frame.Fields[len(frame.Fields)-1].Value != "16h" {
+		t.Errorf("M-Bus: данные %d+%d, сумма верна %v, поля %v", frame.DataOffset, frame.DataSize, frame.ChecksumOk, frame.Fields)
+	}
+
+	frame = ProtocolNone.Dissect([]byte{0x33, 0x81, 0x7E, 0x32}, true)
+	if len(frame.Fields) != 0 || frame.DataOffset != 0 || frame.DataSize != 4 || frame.Checksum != nil {
+		t.Errorf("без служебных полей: %+v", frame)
+	}
+}
+
+func TestProtocolByCode(t *testing.T) {
+	for protocol, code := range protocolCodes {
+		found, err := ProtocolByCode(code)
+		if err != nil || found != protocol {
+			t.Errorf("ProtocolByCode(%q) = %v, %v", code, found, err)
+		}
+	}
+	if _, err := ProtocolByCode("tem104"); err == nil {
+		t.Error("ProtocolByCode: неизвестный протокол принят")
+	}
+}
+
+func TestProtocolSealComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol ProtocolEnum
+		frame    []byte
+		sealed   []byte
+	}{
+		{"ТЭМ", ProtocolTem, []byte{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x00, 0x00, 0x07},
+			[]byte{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x00, 0x00, 0x07, 0x91}},
+		{"ТЭМ ответ", ProtocolTem, []byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03},
+			[]byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03, 0x3D}},
+		{"M-Bus короткий", ProtocolMbus, []byte{0x10, 0x5B, 0x01}, []byte{0x10, 0x5B, 0x01, 0x5C, 0x16}},
+		{"M-Bus длинный", ProtocolMbus, []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50},
+			[]byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50, 0xA4, 0x16}},
+		{"Modbus", ProtocolModbus, []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A},
+			[]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A, 0xC5, 0xCD}},
+		{"SKU-02", ProtocolSku02, []byte{0x68, 0x00, 0x0C, 0x68, 0x01, 0x02, 0x03, 0x04},
+			[]byte{0x68, 0x00, 0x0C, 0x68, 0x01, 0x02, 0x03, 0x04, 0x08, 0x09, 0xF6, 0x16}},
+	}
+	for _, test := range tests {
+		sealed := test.protocol.Seal(test.frame)
+		if !bytes.Equal(sealed, test.sealed) {
+			t.Errorf("%s: Seal() = % X, ожидалось % X", test.name, sealed, test.sealed)
+			continue
+		}
+		if !test.protocol.Complete(sealed) {
+			t.Errorf("%s: кадр после Seal() не полный", test.name)
+		}
+		// Кадр без последнего байта и кадр с искажённым байтом данных не принимаются
+		if test.protocol.Complete(sealed[:len(sealed)-1]) {
+			t.Errorf("%s: неполный кадр принят", test.name)
+		}
+		damaged := append([]byte{}, sealed...)
+		damaged[len(test.frame)-1] ^= 0x01
+		if test.protocol.Complete(damaged) {
+			t.Errorf("%s: кадр с неверной контрольной суммой принят", test.name)
+		}
+	}
+
+	if !ProtocolMbus.Complete([]byte{0xE5}) {
+		t.Error("M-Bus: подтверждение E5h не принято")
+	}
+	if ProtocolNone.Complete([]byte{0x33, 0x81, 0x7E, 0x32}) {
+		t.Error("без служебных полей: конец кадра должен определяться только по таймауту")
+	}
+	if frame := []byte{0x01, 0x02}; !bytes.Equal(ProtocolNone.Seal(frame), frame) {
+		t.Error("без служебных полей: Seal() изменил кадр")
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+type QuantityEnum byte // Измеряемая величина канала теплосчётчика
+const (
+	QuantityFlow        QuantityEnum = 0x00 // Объёмный расход
+	QuantityTemperature QuantityEnum = 0x01 // Температура
+	QuantityPressure    QuantityEnum = 0x02 // Давление
+	QuantityMassFlow    QuantityEnum = 0x03 // Массовый расход
+	QuantityVolume      QuantityEnum = 0x04 // Объём
+	QuantityMass        QuantityEnum = 0x05 // Масса
+)
+
+var quantityCodes = map[QuantityEnum]string{
+	QuantityFlow:        "G",
+	QuantityTemperature: "T",
+	QuantityPressure:    "P",
+	QuantityMassFlow:    "GM",
+	QuantityVolume:      "V",
+	QuantityMass:        "M",
+}
+
+var quantityNames = map[QuantityEnum]string{
+	QuantityFlow:        "Расход",
+	QuantityTemperature: "Температура",
+	QuantityPressure:    "Давление",
+	QuantityMassFlow:    "Массовый расход",
+	QuantityVolume:      "Объём",
+	QuantityMass:        "Масса",
+}
+
+// Обозначение величины для машинных форматов вывода. Например: G
+func (quantity QuantityEnum) Code() string {
+	return quantityCodes[quantity]
+}
+
+// Наименование величины. Например: Расход
+func (quantity QuantityEnum) String() string {
+	return quantityNames[quantity]
+}
+
+// Обозначение базовых единиц измерения величины, в которых драйверы заполняют DataDevice. Например: m3/h
+func (quantity QuantityEnum) Unit() string {
+	switch quantity {
+	case QuantityFlow:
+		return PerHour.Code()
+	case QuantityMassFlow:
+		return PerHour.MassCode()
+	case QuantityTemperature:
+		return Celsius.Code()
+	case QuantityPressure:
+		return MPa.Code()
+	case QuantityVolume:
+		return M3.Code()
+	case QuantityMass:
+		return Tonne.Code()
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package models
+
+type ResultStatusEnum byte // Результат опроса прибора
+const (
+	ResultSuccess ResultStatusEnum = 0x00 // Данные прочитаны полностью
+	ResultPartial ResultStatusEnum = 0x01 // Текущие данные прочитаны, дополнительные (журнал событий) - нет
+	ResultFailed  ResultStatusEnum = 0x02 // Данные не прочитаны, выводится только результат опроса
+)
+
+var resultStatusCodes = map[ResultStatusEnum]string{
+	ResultSuccess: "success",
+	ResultPartial: "partial",
+	ResultFailed:  "failed",
+}
+
+var resultStatusNames = map[ResultStatusEnum]string{
+	ResultSuccess: "успешно",
+	ResultPartial: "данные прочитаны частично",
+	ResultFailed:  "ошибка",
+}
+
+// Обозначение результата для машинных форматов вывода. Например: failed
+func (status ResultStatusEnum) Code() string {
+	return resultStatusCodes[status]
+}
+
+// Наименование результата. Например: ошибка
+func (status ResultStatusEnum) String() string {
+	return resultStatusNames[status]
+}
+
+type StageEnum byte // Этап опроса, на котором произошла ошибка
+const (
+	StageNone    StageEnum = 0x00 // Ошибок нет
+	StageConfig  StageEnum = 0x01 // Проверка настроек утилиты
+	StageConnect StageEnum = 0x02 // Установка соединения
+	StageDetect  StageEnum = 0x03 // Автоматическое определение типа теплосчётчика
+	StageInit    StageEnum = 0x04 // Инициализация драйвера
+	StageRead    StageEnum = 0x05 // Чтение текущих данных
+	StageEvents  StageEnum = 0x06 // Чтение журнала событий
+)
+
+var stageCodes = map[StageEnum]string{
+	StageNone:    "",
+	StageConfig:  "config",
+	StageConnect: "connect",
+	StageDetect:  "detect",
+	StageInit:    "init",
+	StageRead:    "read",
+	StageEvents:  "events",
+}
+
+var stageNames = map[StageEnum]string{
+	StageNone:    "",
+	StageConfig:  "проверка настроек",
+	StageConnect: "установка соединения",
+	StageDetect:  "определение типа теплосчётчика",
+	StageInit:    "инициализация драйвера",
+	StageRead:    "чтение текущих данных",
+	StageEvents:  "чтение журнала событий",
+}
+
+// Обозначение этапа для машинных форматов вывода. Например: read
+func (stage StageEnum) Code() string {
+	return stageCodes[stage]
+}
+
+// Наименование этапа. Например: чтение текущих данных
+func (stage StageEnum) String() string {
+	return stageNames[stage]
+}
+
+type ErrorClassEnum byte // Класс ошибки опроса. Каждому классу соответствует свой код завершения утилиты
+const (
+	ErrorNone        ErrorClassEnum = 0x00 // Ошибок нет
+	ErrorUnknown     ErrorClassEnum = 0x01 // Ошибка без уточнения
+	ErrorConfig      ErrorClassEnum = 0x02 // Неверные настройки утилиты
+	ErrorConnection  ErrorClassEnum = 0x03 // Соединение не установлено или разорвано
+	ErrorTimeout     ErrorClassEnum = 0x04 // Прибор не ответил
+	ErrorProtocol    ErrorClassEnum = 0x05 // Прибор ответил некорректно
+	ErrorUnsupported ErrorClassEnum = 0x06 // Прибор не распознан или драйвер не поддерживает запрошенное
+)
+
+var errorClassCodes = map[ErrorClassEnum]string{
+	ErrorNone:        "",
+	ErrorUnknown:     "unknown",
+	ErrorConfig:      "config",
+	ErrorConnection:  "connection",
+	ErrorTimeout:     "timeout",
+	ErrorProtocol:    "protocol",
+	ErrorUnsupported: "unsupported",
+}
+
+var errorClassNames = map[ErrorClassEnum]string{
+	ErrorNone:        "",
+	ErrorUnknown:     "ошибка без уточнения",
+	ErrorConfig:      "неверные настройки",
+	ErrorConnection:  "ошибка соединения",
+	ErrorTimeout:     "прибор не ответил",
+	ErrorProtocol:    "некорректный ответ прибора",
+	ErrorUnsupported: "не поддерживается",
+}
+
+// Обозначение класса ошибки для машинных форматов вывода. Например: timeout
+func (class ErrorClassEnum) Code() string {
+	return errorClassCodes[class]
+}
+
+// Наименование класса ошибки. Например: прибор не ответил
+func (class ErrorClassEnum) String() string {
+	return errorClassNames[class]
+}
+
+// Код завершения утилиты, если опрос частичный: текущие данные выведены, но не всё прочитано
+const ExitPartial = 10
+
+/**
+Результат опроса прибора. Заполняется ядром, нулевое значение - успешный опрос.
+При ошибке фиксируются этап, класс ошибки и сообщение первой ошибки, которая повлияла на результат.
+*/
+type PollResult struct {
+	Status  ResultStatusEnum // Результат опроса
+	Stage   StageEnum        // Этап, на котором произошла ошибка
+	Class   ErrorClassEnum   // Класс ошибки
+	Message string           // Сообщение об ошибке
+}
+
+/**
+Код завершения утилиты: 0 - успешно, ExitPartial - частично, при ошибке - код класса ошибки:
+1 - без уточнения, 2 - настройки, 3 - соединение, 4 - прибор не ответил, 5 - некорректный ответ, 6 - не поддерживается.
+*/
+func (result PollResult) ExitCode() int {
+	switch result.Status {
+	case ResultSuccess:
+		return 0
+	case ResultPartial:
+		return ExitPartial
+	}
+	if result.Class == ErrorNone {
+		return int(ErrorUnknown)
+	}
+	return int(result.Class)
+}
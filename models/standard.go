@@ -0,0 +1,89 @@
+package models
+
+type StandardQEnum byte // Стандарт пересчёта единиц измерения тепловой энергии
+const (
+	StandardManufacturer StandardQEnum = 0x00 // Коэффициенты производителя прибора, если драйвер их задал, иначе ТКП 411-2012
+	StandardTKP411       StandardQEnum = 0x01 // ТКП 411-2012
+	StandardExact        StandardQEnum = 0x02 // Точный пересчёт по международной калории: 1 кал = 4,1868 Дж
+)
+
+/**
+Переводные коэффициенты единиц измерения энергии в ГКал.
+Нулевое значение коэффициента означает, что коэффициент не задан.
+*/
+type CoefficientsQ struct {
+	GJ  float64 // ГДж в ГКал
+	MWh float64 // МВт*ч в ГКал
+	KWh float64 // КВт*ч в ГКал
+}
+
+var standardQCoefficients = map[StandardQEnum]CoefficientsQ{
+	StandardTKP411: {GJ: 0.239, MWh: 0.86, KWh: 0.00086},
+	StandardExact:  {GJ: 1 / 4.1868, MWh: 3.6 / 4.1868, KWh: 0.0036 / 4.1868},
+}
+
+var standardQCodes = map[StandardQEnum]string{
+	StandardManufacturer: "manufacturer",
+	StandardTKP411:       "tkp411",
+	StandardExact:        "exact",
+}
+
+var standardQNames = map[StandardQEnum]string{
+	StandardManufacturer: "коэффициенты производителя прибора",
+	StandardTKP411:       "ТКП 411-2012",
+	StandardExact:        "1 кал = 4,1868 Дж",
+}
+
+// Обозначение стандарта для машинных форматов вывода и флагов утилиты. Например: tkp411
+func (standard StandardQEnum) Code() string {
+	return standardQCodes[standard]
+}
+
+// Наименование стандарта. Например: ТКП 411-2012
+func (standard StandardQEnum) String() string {
+	return standardQNames[standard]
+}
+
+// Стандарт, по которому фактически выполняется пересчёт энергии.
+// Если выбраны коэффициенты производителя, но драйвер их не задал, то используется ТКП 411-2012.
+func (dataDevice *DataDevice) GetStandardQ() StandardQEnum {
+	if dataDevice.StandardQ == StandardManufacturer &&
+		dataDevice.CoefficientGJ <= 0 && dataDevice.CoefficientMWh <= 0 && dataDevice.CoefficientKWh <= 0 {
+		return StandardTKP411
+	}
+	return dataDevice.StandardQ
+}
+
+// Переводные коэффициенты, которые используются при пересчёте энергии с учётом стандарта и заданных для прибора значений.
+func (dataDevice *DataDevice) GetCoefficientsQ() CoefficientsQ {
+	result := standardQCoefficients[StandardTKP411]
+	if dataDevice.StandardQ == StandardManufacturer {
+		result = result.override(CoefficientsQ{
+			GJ:  dataDevice.CoefficientGJ,
+			MWh: dataDevice.CoefficientMWh,
+			KWh: dataDevice.CoefficientKWh,
+		})
+	} else {
+		result = result.override(standardQCoefficients[dataDevice.StandardQ])
+	}
+	return result.override(dataDevice.OverrideQ)
+}
+
+// Есть ли коэффициенты, заданные для прибора пользователем
+func (dataDevice *DataDevice) IsOverriddenQ() bool {
+	return dataDevice.OverrideQ != CoefficientsQ{}
+}
+
+// Замена коэффициентов заданными (больше нуля) значениями
+func (coefficients CoefficientsQ) override(by CoefficientsQ) CoefficientsQ {
+	if by.GJ > 0 {
+		coefficients.GJ = by.GJ
+	}
+	if by.MWh > 0 {
+		coefficients.MWh = by.MWh
+	}
+	if by.KWh > 0 {
+		coefficients.KWh = by.KWh
+	}
+	return coefficients
+}
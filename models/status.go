@@ -0,0 +1,79 @@
+package models
+
+type StatusFlagEnum uint16 // Флаги состояния прибора, битовая маска
+const (
+	StatusError        StatusFlagEnum = 1 << iota // Ошибка прибора, без уточнения
+	StatusSensorBreak                             // Обрыв, КЗ цепи датчика
+	StatusGMin                                    // Расход меньше минимального
+	StatusGMax                                    // Расход больше максимального
+	StatusDeltaTMin                               // Разность температур меньше минимальной
+	StatusPowerFailure                            // Отсутствие питания
+	StatusLowBattery                              // Низкий заряд батареи
+)
+
+// Порядок вывода флагов
+var statusFlags = []StatusFlagEnum{
+	StatusError,
+	StatusSensorBreak,
+	StatusGMin,
+	StatusGMax,
+	StatusDeltaTMin,
+	StatusPowerFailure,
+	StatusLowBattery,
+}
+
+var statusFlagCodes = map[StatusFlagEnum]string{
+	StatusError:        "error",
+	StatusSensorBreak:  "sensorBreak",
+	StatusGMin:         "gMin",
+	StatusGMax:         "gMax",
+	StatusDeltaTMin:    "dtMin",
+	StatusPowerFailure: "powerFailure",
+	StatusLowBattery:   "lowBattery",
+}
+
+var statusFlagNames = map[StatusFlagEnum]string{
+	StatusError:        "Ошибка",
+	StatusSensorBreak:  "Обрыв датчика",
+	StatusGMin:         "Расход меньше минимального",
+	StatusGMax:         "Расход больше максимального",
+	StatusDeltaTMin:    "Разность температур меньше минимальной",
+	StatusPowerFailure: "Отсутствие питания",
+	StatusLowBattery:   "Низкий заряд батареи",
+}
+
+// Код флага для машинных форматов вывода. Например: gMin
+func (flag StatusFlagEnum) Code() string {
+	return statusFlagCodes[flag]
+}
+
+// Наименование флага. Например: Расход меньше минимального
+func (flag StatusFlagEnum) String() string {
+	return statusFlagNames[flag]
+}
+
+/**
+Состояние прибора или системы на момент опроса.
+Code - код состояния в том виде, в котором его передал прибор, Flags - расшифровка кода драйвером.
+Биты кода, которые не удалось расшифровать, в Flags не попадают, но остаются в Code.
+*/
+type MeterStatus struct {
+	Code  uint32         // Код состояния прибора, как есть
+	Flags StatusFlagEnum // Расшифрованные флаги состояния
+}
+
+// Выставлен ли флаг состояния
+func (status MeterStatus) Has(flag StatusFlagEnum) bool {
+	return status.Flags&flag == flag
+}
+
+// Список выставленных флагов в порядке вывода
+func (status MeterStatus) List() []StatusFlagEnum {
+	var result []StatusFlagEnum
+	for _, flag := range statusFlags {
+		if status.Has(flag) {
+			result = append(result, flag)
+		}
+	}
+	return result
+}
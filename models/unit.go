@@ -0,0 +1,275 @@
+package models
+
+/**
+Единицы измерения величин, кроме энергии (для неё см. UnitQEnum).
+Драйверы заполняют DataDevice в базовых единицах: МПа, м3, тонны, м3/ч (т/ч), градусы Цельсия. Нулевое значение каждого
+перечисления - базовая единица. Перевод в другие единицы выполняется методами DataDevice::ChangeUnitX перед выводом.
+*/
+
+type UnitPEnum byte // Единицы измерения давления
+const (
+	MPa    UnitPEnum = 0x00 // Мегапаскали
+	Bar    UnitPEnum = 0x01 // Бары
+	KgfCm2 UnitPEnum = 0x02 // Килограмм-сила на квадратный сантиметр
+	KPa    UnitPEnum = 0x03 // Килопаскали
+)
+
+type UnitVEnum byte // Единицы измерения объёма
+const (
+	M3    UnitVEnum = 0x00 // Метры кубические
+	Liter UnitVEnum = 0x01 // Литры
+)
+
+type UnitMEnum byte // Единицы измерения массы
+const (
+	Tonne    UnitMEnum = 0x00 // Тонны
+	Kilogram UnitMEnum = 0x01 // Килограммы
+)
+
+type UnitGEnum byte // Единицы измерения расхода, объёмного и массового
+const (
+	PerHour   UnitGEnum = 0x00 // м3/ч и т/ч
+	PerSecond UnitGEnum = 0x01 // л/с и кг/с
+)
+
+type UnitTEnum byte // Единицы измерения температуры
+const (
+	Celsius UnitTEnum = 0x00 // Градусы Цельсия
+	Kelvin  UnitTEnum = 0x01 // Кельвины
+)
+
+// Количество единиц давления в 1 МПа
+var unitPScales = map[UnitPEnum]float64{MPa: 1, Bar: 10, KgfCm2: 1 / 0.0980665, KPa: 1000}
+
+var unitPCodes = map[UnitPEnum]string{MPa: "MPa", Bar: "bar", KgfCm2: "kgf/cm2", KPa: "kPa"}
+
+var unitPNames = map[UnitPEnum]string{MPa: "МПа", Bar: "бар", KgfCm2: "кгс/см2", KPa: "кПа"}
+
+// Количество единиц объёма в 1 м3
+var unitVScales = map[UnitVEnum]float64{M3: 1, Liter: 1000}
+
+var unitVCodes = map[UnitVEnum]string{M3: "m3", Liter: "l"}
+
+var unitVNames = map[UnitVEnum]string{M3: "м3", Liter: "л"}
+
+// Количество единиц массы в 1 тонне
+var unitMScales = map[UnitMEnum]float64{Tonne: 1, Kilogram: 1000}
+
+var unitMCodes = map[UnitMEnum]string{Tonne: "t", Kilogram: "kg"}
+
+var unitMNames = map[UnitMEnum]string{Tonne: "тонн", Kilogram: "кг"}
+
+// Количество единиц расхода в 1 м3/ч (т/ч)
+var unitGScales = map[UnitGEnum]float64{PerHour: 1, PerSecond: 1000.0 / 3600.0}
+
+var unitGCodes = map[UnitGEnum]string{PerHour: "m3/h", PerSecond: "l/s"}
+
+var unitGNames = map[UnitGEnum]string{PerHour: "м3/ч", PerSecond: "л/с"}
+
+var unitGMassCodes = map[UnitGEnum]string{PerHour: "t/h", PerSecond: "kg/s"}
+
+var unitGMassNames = map[UnitGEnum]string{PerHour: "тонн/ч", PerSecond: "кг/с"}
+
+// Смещение шкалы температуры относительно градусов Цельсия
+var unitTOffsets = map[UnitTEnum]float64{Celsius: 0, Kelvin: 273.15}
+
+var unitTCodes = map[UnitTEnum]string{Celsius: "C", Kelvin: "K"}
+
+var unitTNames = map[UnitTEnum]string{Celsius: "C", Kelvin: "K"}
+
+// Обозначение единиц для машинных форматов вывода и флагов утилиты. Например: kPa
+func (unit UnitPEnum) Code() string {
+	return unitPCodes[unit]
+}
+
+// Наименование единиц. Например: кПа
+func (unit UnitPEnum) String() string {
+	return unitPNames[unit]
+}
+
+// Перевод давления, измеренного прибором в этих единицах, в МПа
+func (unit UnitPEnum) ToMPa(value float32) float32 {
+	return float32(float64(value) / unitPScales[unit])
+}
+
+func (unit UnitVEnum) Code() string {
+	return unitVCodes[unit]
+}
+
+func (unit UnitVEnum) String() string {
+	return unitVNames[unit]
+}
+
+func (unit UnitMEnum) Code() string {
+	return unitMCodes[unit]
+}
+
+func (unit UnitMEnum) String() string {
+	return unitMNames[unit]
+}
+
+// Обозначение единиц объёмного расхода. Массовый расход выводится в соответствующих единицах массы: т/ч или кг/с
+func (unit UnitGEnum) Code() string {
+	return unitGCodes[unit]
+}
+
+// Наименование единиц объёмного расхода. Например: м3/ч
+func (unit UnitGEnum) String() string {
+	return unitGNames[unit]
+}
+
+// Наименование единиц массового расхода. Например: тонн/ч
+func (unit UnitGEnum) MassString() string {
+	return unitGMassNames[unit]
+}
+
+// Обозначение единиц массового расхода для машинных форматов вывода. Например: t/h
+func (unit UnitGEnum) MassCode() string {
+	return unitGMassCodes[unit]
+}
+
+func (unit UnitTEnum) Code() string {
+	return unitTCodes[unit]
+}
+
+func (unit UnitTEnum) String() string {
+	return unitTNames[unit]
+}
+
+// Изменение единиц измерения давления
+func (dataDevice *DataDevice) ChangeUnitP(u UnitPEnum) {
+	if dataDevice.UnitP == u {
+		return
+	}
+	k := unitPScales[u] / unitPScales[dataDevice.UnitP]
+	for i := range dataDevice.Systems {
+		system := &dataDevice.Systems[i]
+		system.P1 = float32(float64(system.P1) * k)
+		system.P2 = float32(float64(system.P2) * k)
+		system.P3 = float32(float64(system.P3) * k)
+		system.scaleChannels(QuantityPressure, k, u.Code())
+	}
+	dataDevice.UnitP = u
+}
+
+// Изменение единиц измерения объёма
+func (dataDevice *DataDevice) ChangeUnitV(u UnitVEnum) {
+	if dataDevice.UnitV == u {
+		return
+	}
+	k := unitVScales[u] / unitVScales[dataDevice.UnitV]
+	for i := range dataDevice.Systems {
+		system := &dataDevice.Systems[i]
+		system.V1 *= k
+		system.V2 *= k
+		system.scaleChannels(QuantityVolume, k, u.Code())
+	}
+	dataDevice.UnitV = u
+}
+
+// Изменение единиц измерения массы
+func (dataDevice *DataDevice) ChangeUnitM(u UnitMEnum) {
+	if dataDevice.UnitM == u {
+		return
+	}
+	k := unitMScales[u] / unitMScales[dataDevice.UnitM]
+	for i := range dataDevice.Systems {
+		system := &dataDevice.Systems[i]
+		system.M1 *= k
+		system.M2 *= k
+		system.scaleChannels(QuantityMass, k, u.Code())
+	}
+	dataDevice.UnitM = u
+}
+
+// Изменение единиц измерения расхода. Объёмный и массовый расход переводятся одинаково: м3/ч в л/с, т/ч в кг/с
+func (dataDevice *DataDevice) ChangeUnitG(u UnitGEnum) {
+	if dataDevice.UnitG == u {
+		return
+	}
+	k := unitGScales[u] / unitGScales[dataDevice.UnitG]
+	for i := range dataDevice.Systems {
+		system := &dataDevice.Systems[i]
+		system.GV1 = float32(float64(system.GV1) * k)
+		system.GV2 = float32(float64(system.GV2) * k)
+		system.GM1 = float32(float64(system.GM1) * k)
+		system.GM2 = float32(float64(system.GM2) * k)
+		system.scaleChannels(QuantityFlow, k, u.Code())
+		system.scaleChannels(QuantityMassFlow, k, u.MassCode())
+	}
+	dataDevice.UnitG = u
+}
+
+// Изменение единиц измерения температуры
+func (dataDevice *DataDevice) ChangeUnitT(u UnitTEnum) {
+	if dataDevice.UnitT == u {
+		return
+	}
+	shift := unitTOffsets[u] - unitTOffsets[dataDevice.UnitT]
+	for i := range dataDevice.Systems {
+		system := &dataDevice.Systems[i]
+		system.T1 = float32(float64(system.T1) + shift)
+		system.T2 = float32(float64(system.T2) + shift)
+		system.T3 = float32(float64(system.T3) + shift)
+		for c := range system.Channels {
+			if system.Channels[c].Quantity == QuantityTemperature {
+				system.Channels[c].Value += shift
+				system.Channels[c].Unit = u.Code()
+			}
+		}
+	}
+	dataDevice.UnitT = u
+}
+
+// Пересчёт измерений по каналам для одной величины
+func (system *SystemDevice) scaleChannels(quantity QuantityEnum, k float64, unit string) {
+	for c := range system.Channels {
+		if system.Channels[c].Quantity == quantity {
+			system.Channels[c].Value *= k
+			system.Channels[c].Unit = unit
+		}
+	}
+}
+
+// Наименование единиц измерения канала для текстового вывода. Например: м3/ч
+func (channel ChannelDevice) UnitString() string {
+	switch channel.Quantity {
+	case QuantityFlow:
+		for unit, code := range unitGCodes {
+			if code == channel.Unit {
+				return unit.String()
+			}
+		}
+	case QuantityMassFlow:
+		for unit, code := range unitGMassCodes {
+			if code == channel.Unit {
+				return unit.MassString()
+			}
+		}
+	case QuantityTemperature:
+		for unit, code := range unitTCodes {
+			if code == channel.Unit {
+				return unit.String()
+			}
+		}
+	case QuantityPressure:
+		for unit, code := range unitPCodes {
+			if code == channel.Unit {
+				return unit.String()
+			}
+		}
+	case QuantityVolume:
+		for unit, code := range unitVCodes {
+			if code == channel.Unit {
+				return unit.String()
+			}
+		}
+	case QuantityMass:
+		for unit, code := range unitMCodes {
+			if code == channel.Unit {
+				return unit.String()
+			}
+		}
+	}
+	return channel.Unit
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	netService "qBox/services/net"
+	"time"
+)
+
+/**
+Опрос одного прибора: чтение текущих данных и, если задано, журнала событий. Соединение закрывается после опроса.
+Возвращаются данные с результатом опроса, при ошибке - только результат и сведения об опросе.
+Используется командой read, опросом приборов из файла конфигурации опроса и экспортёром.
+*/
+func pollMeter(configService configPackage.Config, logger *logPackage.LoggerService) (result *models.DataDevice) {
+	deviceData := new(models.DataDevice)
+	start := time.Now()
+	var driver models.IDeviceDriver
+	fail := func(stage models.StageEnum, err error) *models.DataDevice {
+		logger.Fatal(err.Error())
+		failPoll(deviceData, models.ResultFailed, stage, err)
+		if deviceData.TimeRequest.IsZero() {
+			deviceData.TimeRequest = start
+		}
+		describePoll(deviceData, driver, start)
+		return deviceData
+	}
+
+	network, err := newMeterNetwork(configService, logger)
+	if err != nil {
+		return fail(models.StageConfig, err)
+	}
+	defer func() {
+		if network.IsConnected() {
+			_ = network.Close()
+		}
+	}()
+
+	driver, stage, err := openMeter(configService, network, logger)
+	if err != nil {
+		return fail(stage, err)
+	}
+
+	stage = models.StageRead
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = fail(stage, fmt.Errorf("паника в драйвере: %v", recovered))
+		}
+	}()
+	logger.Info("Чтение текущих данных")
+	readData, err := driver.Read()
+	if readData != nil {
+		deviceData = readData
+	}
+	if err != nil {
+		return fail(stage, err)
+	}
+
+	if configService.IsReadEvents() {
+		stage = models.StageEvents
+		logger.Info("Чтение журнала событий")
+		eventDriver, ok := driver.(models.IEventDriver)
+		if ok {
+			deviceData.Events, err = eventDriver.ReadEvents()
+			if err != nil {
+				logger.Notice("Журнал событий не прочитан. " + err.Error())
+				failPoll(deviceData, models.ResultPartial, models.StageEvents, err)
+			}
+		} else {
+			logger.Notice("Драйвер не поддерживает чтение журнала событий")
+		}
+	}
+	describePoll(deviceData, driver, start)
+
+	logger.Check("app")
+	logger.Info("Подготовка к выводу данных")
+	prepareData(deviceData, configService, logger)
+	return deviceData
+}
+
+// Сервис соединения с прибором по адресу из флагов утилиты. Соединение устанавливается в openMeter
+func newMeterNetwork(configService configPackage.Config, logger *logPackage.LoggerService) (*netService.Network, error) {
+	host, port, err := netService.SplitHostPort(configService.GetHostPort())
+	if err != nil {
+		return nil, err
+	}
+	network := netService.NewNetwork(host, port, *logger)
+	network.SetMinReadTimeout(configService.GetReadTimeout())
+	return network, nil
+}
+
+/**
+Подготовка прибора к обмену: соединение, определение типа прибора (флаг type=auto) и инициализация драйвера.
+При ошибке возвращается этап, на котором она произошла, и драйвер, если он уже известен. Соединение закрывает вызывающий.
+Паника в драйвере (например, на неожиданном ответе прибора) возвращается как ошибка этапа, чтобы не прерывать опрос
+остальных приборов и работу экспортёра.
+*/
+func openMeter(configService configPackage.Config, network *netService.Network, logger *logPackage.LoggerService) (
+	driver models.IDeviceDriver, stage models.StageEnum, err error) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("паника в драйвере: %v", recovered)
+		}
+	}()
+
+	logger.Check("driver")
+	stage = models.StageConfig
+	if !configService.IsAutoDetect() {
+		driver, err = configService.GetDriver()
+		if err != nil {
+			return
+		}
+	}
+
+	stage = models.StageConnect
+	err = network.Connect()
+	if err != nil {
+		return
+	}
+
+	logger.Check("driver")
+	if configService.IsAutoDetect() {
+		stage = models.StageDetect
+		logger.Info("Автоматическое определение типа теплосчётчика")
+		driver, err = configService.DetectDriver(network, logger)
+		if err != nil {
+			return
+		}
+	}
+
+	stage = models.StageInit
+	logger.Info("Инициализация драйвера")
+	err = driver.Init(configService.GetCounterNumber(), network, logger)
+	return
+}
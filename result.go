@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"qBox/models"
+	netService "qBox/services/net"
+	"syscall"
+)
+
+// Фиксация ошибки опроса. Результат опроса определяется первой ошибкой, последующие добавляются только в список ошибок.
+// Исключение - ошибка, после которой данные не выводятся: она заменяет результат частичного опроса.
+func failPoll(deviceData *models.DataDevice, status models.ResultStatusEnum, stage models.StageEnum, err error) {
+	deviceData.Errors = append(deviceData.Errors, err.Error())
+	if deviceData.Result.Status == models.ResultFailed ||
+		deviceData.Result.Status == models.ResultPartial && status == models.ResultPartial {
+		return
+	}
+	deviceData.Result = models.PollResult{Status: status, Stage: stage, Class: classifyError(stage, err), Message: err.Error()}
+}
+
+/**
+Класс ошибки опроса. Ошибки обмена определяются по ошибкам сетевого сервиса, которые драйверы возвращают без изменений,
+остальные - по этапу опроса, на котором они произошли.
+*/
+func classifyError(stage models.StageEnum, err error) models.ErrorClassEnum {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, netService.ErrNoResponse):
+		return models.ErrorTimeout
+	case errors.Is(err, netService.ErrBadResponse):
+		return models.ErrorProtocol
+	case errors.Is(err, models.ErrClockReadOnly):
+		return models.ErrorUnsupported
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorTimeout
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED):
+		return models.ErrorConnection
+	}
+
+	switch stage {
+	case models.StageConfig:
+		return models.ErrorConfig
+	case models.StageConnect:
+		return models.ErrorConnection
+	case models.StageDetect:
+		return models.ErrorUnsupported
+	}
+	return models.ErrorUnknown
+}
@@ -5,17 +5,25 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"qBox/drivers"
 	"qBox/drivers/skm2"
 	"qBox/drivers/skm2m"
 	"qBox/drivers/tem104k"
 	"qBox/drivers/tem104m"
 	"qBox/models"
+	"qBox/services/influx"
+	"qBox/services/log"
+	"qBox/services/net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Карта зарегистрированных драйверов.
 // Примечание: Добавляя новые драйвера, необходимо добавить описание в HELP для флага type
-var driversMap = [17]models.IDeviceDriver{
+var driversMap = [16]models.IDeviceDriver{
 	new(skm2.SKM),
 	new(drivers.SKU02B),
 	new(drivers.Tem104),
@@ -32,19 +40,77 @@ var driversMap = [17]models.IDeviceDriver{
 	new(drivers.TEM104M2),
 	new(skm2m.SKM),
 	new(drivers.Alfamera),
-	new(drivers.TEM206),
 }
 
 const VersionCoreApp = "0.0.5"
 
+// Команды утилиты
+const (
+	CommandRead     = "read"      // чтение текущих данных теплосчётчика
+	CommandSyncTime = "sync-time" // синхронизация часов теплосчётчика с системным временем
+	CommandConfig   = "config"    // чтение конфигурации (настроек) теплосчётчика
+	CommandExporter = "exporter"  // HTTP сервер для Prometheus, каждый запрос /metrics опрашивает теплосчётчик
+	CommandDecode   = "decode"    // разбор кадров прибора драйвером для отладки протокола
+	CommandDump     = "dump"      // чтение области памяти прибора ТЭМ в файл
+	CommandDumpDiff = "dump-diff" // сравнение двух файлов дампа памяти
+	CommandConsole  = "console"   // ручной обмен кадрами с прибором
+)
+
+// Значение флага type для автоматического определения типа теплосчётчика
+const DeviceTypeAuto = "auto"
+
 type Config struct {
-	log           bool
-	dev           bool
-	hostPort      string
-	deviceType    int
-	format        string
-	counterNumber uint
-	unitQInt      uint
+	log            bool
+	dev            bool
+	hostPort       string
+	deviceType     string
+	format         string
+	csvDelimiter   string
+	csvDecimal     string
+	csvAppend      bool
+	counterNumber  uint
+	unitQInt       uint
+	unitP          string
+	unitV          string
+	unitM          string
+	unitG          string
+	unitT          string
+	standardQ      string
+	coefficientGJ  float64
+	coefficientMWh float64
+	coefficientKWh float64
+	command        string
+	maxCorrection  uint
+	dryRun         bool
+	events         bool
+	listen         string
+	site           string
+	influxName     string
+	influxUrl      string
+	influxOrg      string
+	influxBucket   string
+	influxToken    string
+	influxBatch    uint
+	influxRetry    uint
+	influxSpool    string
+	templateFile   string
+	xmlEncoding    string
+	jsonVersion    uint
+	frame          string
+	session        string
+	memory         string
+	address        string
+	size           string
+	chunk          uint
+	dumpFile       string
+	protocol       string
+	consoleLog     string
+	metersFile     string
+	meterName      string
+	tag            string
+	timeout        uint
+	workers        uint
+	args           []string
 }
 
 func (cS Config) IsOnLog() bool {
@@ -63,21 +129,307 @@ func (cS Config) GetCounterNumber() byte {
 	return byte(cS.counterNumber)
 }
 
+// Наименьший таймаут чтения в секундах для всех запросов к прибору, 0 - таймауты драйвера
+func (cS Config) GetReadTimeout() uint8 {
+	if cS.timeout > 0xFF {
+		return 0xFF
+	}
+	return uint8(cS.timeout)
+}
+
+// Чтение журнала событий (нештатных ситуаций) вместе с текущими данными
+func (cS Config) IsReadEvents() bool {
+	return cS.events
+}
+
+// Возвращает команду, которую должна выполнить утилита.
+// Если команда задана неверно, то возвращается ошибка.
+func (cS Config) GetCommand() (string, error) {
+	switch cS.command {
+	case CommandRead, CommandSyncTime, CommandConfig, CommandExporter, CommandDecode, CommandDump, CommandDumpDiff, CommandConsole:
+		return cS.command, nil
+	}
+	return "", errors.New("задана неверная команда. Список команд доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Максимальная коррекция часов теплосчётчика для команды sync-time
+func (cS Config) GetMaxCorrection() time.Duration {
+	return time.Duration(cS.maxCorrection) * time.Second
+}
+
+// Режим, при котором изменения на приборе не производятся, а только фиксируются в логе
+func (cS Config) IsDryRun() bool {
+	return cS.dryRun
+}
+
+// Тип теплосчётчика определяется автоматически, драйвер выбирается через DetectDriver
+func (cS Config) IsAutoDetect() bool {
+	return cS.deviceType == DeviceTypeAuto
+}
+
+// Возвращает новый экземпляр драйвера заданного типа. Тип задаётся номером или именем драйвера, например tem104m.TEM104M.
+// Драйверы хранят прочитанные данные, поэтому при каждом опросе используется свой экземпляр.
 func (cS *Config) GetDriver() (models.IDeviceDriver, error) {
+	deviceType, err := strconv.Atoi(cS.deviceType)
 	for i, driver := range driversMap {
-		if i == cS.deviceType {
-			return driver, nil
+		if err == nil && i == deviceType || DriverName(driver) == cS.deviceType {
+			return newDriver(driver), nil
 		}
 	}
 	return nil, errors.New("задан не верный драйвер устройства. Список драйверов доступен по флагу \"-help\" или \"-h\"")
 }
 
-func (cS Config) GetFormatter() models.Formatter {
+func newDriver(driver models.IDeviceDriver) models.IDeviceDriver {
+	return reflect.New(reflect.TypeOf(driver).Elem()).Interface().(models.IDeviceDriver)
+}
+
+// Имя драйвера для вывода вместе с данными: пакет и тип. Например: tem104m.TEM104M
+func DriverName(driver models.IDeviceDriver) string {
+	return reflect.TypeOf(driver).Elem().String()
+}
+
+// Тип теплосчётчика (значение флага type), которому соответствует драйвер. Пустая строка, если драйвер не зарегистрирован
+func DriverType(driver models.IDeviceDriver) string {
+	for i, registered := range driversMap {
+		if reflect.TypeOf(registered) == reflect.TypeOf(driver) {
+			return strconv.Itoa(i)
+		}
+	}
+	return ""
+}
+
+// Адрес, на котором HTTP сервер команды exporter принимает запросы. Например: :9710
+func (cS Config) GetListen() string {
+	return cS.listen
+}
+
+/**
+Сеанс обмена для команды decode: кадр ответа прибора из флага frame или записанный сеанс из файла флага session.
+*/
+func (cS Config) GetDecodeSession() ([]net.Exchange, error) {
+	if cS.session != "" {
+		file, err := os.Open(cS.session)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return net.ReadSession(file)
+	}
+	if cS.frame != "" {
+		frame, err := net.ParseHex(cS.frame)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Exchange{{Response: frame}}, nil
+	}
+	return nil, errors.New("для команды \"" + CommandDecode + "\" задайте кадр флагом \"-frame\" или файл сеанса флагом \"-session\"")
+}
+
+/**
+Область памяти для команды dump: область, начальный адрес, размер и размер блока, которым память читается с прибора.
+Адрес и размер задаются десятичным или шестнадцатеричным (0x0200) числом, размер 0 - до конца области памяти.
+*/
+func (cS Config) GetDumpRange() (drivers.TemMemoryEnum, int, int, int, error) {
+	memory, err := drivers.TemMemoryByCode(cS.memory)
+	if err != nil {
+		return memory, 0, 0, 0, err
+	}
+	address, errAddress := strconv.ParseUint(cS.address, 0, 16)
+	size, errSize := strconv.ParseUint(cS.size, 0, 32)
+	if errAddress != nil || errSize != nil || cS.chunk == 0 || cS.chunk > 0xFF {
+		return memory, 0, 0, 0, errors.New("адрес, размер или блок дампа памяти выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+	}
+
+	// Адрес в командах протокола ТЭМ занимает 2 байта
+	limit := memory.Size()
+	if limit == 0 {
+		limit = 0x10000
+	}
+	if size == 0 {
+		if memory.Size() == 0 {
+			return memory, 0, 0, 0, errors.New("для области памяти \"" + memory.Code() + "\" размер не известен, задайте его флагом \"-size\"")
+		}
+		size = uint64(limit) - address
+	}
+	if int(address)+int(size) > limit {
+		return memory, 0, 0, 0, fmt.Errorf("область %04X-%04X выходит за границы памяти (%d байт)", address, address+size-1, limit)
+	}
+	return memory, int(address), int(size), int(cS.chunk), nil
+}
+
+// Файл дампа памяти. Рядом с ним сохраняется файл с тем же именем и расширением .hex
+func (cS Config) GetDumpFile() string {
+	return cS.dumpFile
+}
+
+// Файлы дампов для команды dump-diff: ранний и поздний
+func (cS Config) GetDumpDiffFiles() (string, string, error) {
+	if len(cS.args) != 2 {
+		return "", "", errors.New("для команды \"" + CommandDumpDiff + "\" задайте два файла дампа: qBox -command=" + CommandDumpDiff + " old.bin new.bin")
+	}
+	return cS.args[0], cS.args[1], nil
+}
+
+/**
+Протокол обмена для команды console: из флага protocol, если он не задан - протокол драйвера из флага type.
+Если тип теплосчётчика не задан или драйвер не сообщает протокол, кадры отправляются как есть.
+*/
+func (cS Config) GetConsoleProtocol() (models.ProtocolEnum, error) {
+	if cS.protocol != "" {
+		return models.ProtocolByCode(cS.protocol)
+	}
+	if cS.deviceType == "" || cS.IsAutoDetect() {
+		return models.ProtocolNone, nil
+	}
+	driver, err := cS.GetDriver()
+	if err != nil {
+		return models.ProtocolNone, err
+	}
+	if protocolDriver, ok := driver.(models.IProtocolDriver); ok {
+		return protocolDriver.Protocol(), nil
+	}
+	return models.ProtocolNone, nil
+}
+
+// Файл записи сеанса команды console, пустая строка - сеанс не записывается
+func (cS Config) GetConsoleLog() string {
+	return cS.consoleLog
+}
+
+/**
+Копия конфигурации для опроса другого теплосчётчика: адрес, тип и номер прибора.
+Используется командой exporter, где прибор задаётся параметрами запроса. Пустые значения не меняют конфигурацию.
+*/
+func (cS Config) ForTarget(hostPort string, deviceType string, counterNumber string) (Config, error) {
+	if hostPort != "" {
+		cS.hostPort = hostPort
+	}
+	if deviceType != "" {
+		cS.deviceType = deviceType
+	}
+	if counterNumber != "" {
+		number, err := strconv.ParseUint(counterNumber, 10, 8)
+		if err != nil {
+			return cS, errors.New("номер теплосчётчика задан не правильно, возможны значения от 0 до 255")
+		}
+		cS.counterNumber = uint(number)
+	}
+	return cS, nil
+}
+
+// Имя формата вывода результата, как у флага format
+func (cS Config) GetFormat() string {
+	return cS.format
+}
+
+// Возвращает формат вывода результата.
+// Если неверно задан формат, настройки CSV или шаблон, то возвращается ошибка и текстовый формат либо CSV с настройками
+// по умолчанию.
+func (cS Config) GetFormatter() (models.Formatter, error) {
 	switch cS.format {
+	case "text":
+		return new(models.TextFormat), nil
 	case "json":
-		return new(models.JsonFormat)
+		return cS.GetJsonFormat()
+	case "prometheus":
+		return new(models.PrometheusFormat), nil
+	case "influx":
+		return cS.GetInfluxFormat(), nil
+	case "csv":
+		return cS.GetCsvFormat()
+	case "xml":
+		return cS.GetXmlFormat()
+	case "template":
+		format, err := cS.GetTemplateFormat()
+		if err != nil {
+			return new(models.TextFormat), err
+		}
+		return format, nil
+	}
+	return new(models.TextFormat), errors.New("формат вывода выставлен не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает настройки формата CSV.
+// Разделитель колонок и десятичный разделитель - по одному символу, они не должны совпадать.
+// Если неверно заданы, то возвращается ошибка и разделители ";" и ",".
+func (cS Config) GetCsvFormat() (*models.CsvFormat, error) {
+	format := models.CsvFormat{Delimiter: ';', Decimal: ",", NoHeader: cS.csvAppend}
+	delimiter := []rune(cS.csvDelimiter)
+	decimal := []rune(cS.csvDecimal)
+	if len(delimiter) != 1 || len(decimal) != 1 || delimiter[0] == decimal[0] || delimiter[0] == '"' {
+		return &format, errors.New("разделители формата CSV выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+	}
+	format.Delimiter = delimiter[0]
+	format.Decimal = cS.csvDecimal
+	return &format, nil
+}
+
+// Возвращает формат JSON заданной версии схемы.
+// Если неверно задана версия, то возвращается ошибка и первая версия.
+func (cS Config) GetJsonFormat() (models.Formatter, error) {
+	switch cS.jsonVersion {
+	case 1:
+		return new(models.JsonFormat), nil
+	case 2:
+		return new(models.JsonV2Format), nil
+	}
+	return new(models.JsonFormat), errors.New("версия формата JSON выставлена не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает настройки формата XML.
+// Если неверно задана кодировка, то возвращается ошибка и кодировка UTF-8.
+func (cS Config) GetXmlFormat() (*models.XmlFormat, error) {
+	for _, encoding := range []string{models.XmlUtf8, models.XmlWindows1251} {
+		if strings.EqualFold(cS.xmlEncoding, encoding) {
+			return &models.XmlFormat{Encoding: encoding}, nil
+		}
+	}
+	return &models.XmlFormat{Encoding: models.XmlUtf8}, errors.New("кодировка XML выставлена не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает формат вывода по шаблону из файла флага template.
+// Если файл не задан, не прочитан или шаблон содержит ошибки, то возвращается ошибка.
+func (cS Config) GetTemplateFormat() (*models.TemplateFormat, error) {
+	if cS.templateFile == "" {
+		return nil, errors.New("не задан файл шаблона вывода. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+	}
+	text, err := os.ReadFile(cS.templateFile)
+	if err != nil {
+		return nil, errors.New("не удалось прочитать файл шаблона вывода: " + err.Error())
 	}
-	return new(models.TextFormat)
+	format, err := models.NewTemplateFormat(filepath.Base(cS.templateFile), string(text))
+	if err != nil {
+		return nil, errors.New("ошибка в шаблоне вывода: " + err.Error())
+	}
+	return format, nil
+}
+
+// Возвращает настройки формата InfluxDB line protocol
+func (cS Config) GetInfluxFormat() *models.InfluxFormat {
+	return &models.InfluxFormat{Measurement: cS.influxName, Site: cS.site}
+}
+
+// Данные отправляются в InfluxDB, если задан её адрес
+func (cS Config) IsInfluxWrite() bool {
+	return cS.influxUrl != ""
+}
+
+// Возвращает настроенную отправку данных в InfluxDB.
+// API токен берётся из флага influxToken, если не задан - из переменной окружения INFLUX_TOKEN.
+// Если не задан bucket, то возвращается ошибка.
+func (cS Config) GetInfluxWriter(logger *log.LoggerService) (*influx.Writer, error) {
+	if cS.influxBucket == "" {
+		return nil, errors.New("не задан bucket InfluxDB. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+	}
+	token := cS.influxToken
+	if token == "" {
+		token = os.Getenv("INFLUX_TOKEN")
+	}
+	writer := influx.NewWriter(cS.influxUrl, cS.influxOrg, cS.influxBucket, token, logger)
+	writer.BatchSize = int(cS.influxBatch)
+	writer.Retries = int(cS.influxRetry)
+	writer.SpoolDir = cS.influxSpool
+	return writer, nil
 }
 
 // Возвращает конфигурацию для единиц измерения энергии.
@@ -97,6 +449,77 @@ func (cS Config) GetUnitQ() (models.UnitQEnum, error) {
 	return models.Gcal, errors.New("единицы измерения энергии выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
 }
 
+// Возвращает единицы измерения давления для вывода.
+// Если неверно заданы, то возвращается ошибка и МПа.
+func (cS Config) GetUnitP() (models.UnitPEnum, error) {
+	for _, unit := range []models.UnitPEnum{models.MPa, models.Bar, models.KgfCm2, models.KPa} {
+		if cS.unitP == unit.Code() {
+			return unit, nil
+		}
+	}
+	return models.MPa, errors.New("единицы измерения давления выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает единицы измерения объёма для вывода.
+// Если неверно заданы, то возвращается ошибка и м3.
+func (cS Config) GetUnitV() (models.UnitVEnum, error) {
+	for _, unit := range []models.UnitVEnum{models.M3, models.Liter} {
+		if cS.unitV == unit.Code() {
+			return unit, nil
+		}
+	}
+	return models.M3, errors.New("единицы измерения объёма выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает единицы измерения массы для вывода.
+// Если неверно заданы, то возвращается ошибка и тонны.
+func (cS Config) GetUnitM() (models.UnitMEnum, error) {
+	for _, unit := range []models.UnitMEnum{models.Tonne, models.Kilogram} {
+		if cS.unitM == unit.Code() {
+			return unit, nil
+		}
+	}
+	return models.Tonne, errors.New("единицы измерения массы выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает единицы измерения расхода для вывода.
+// Если неверно заданы, то возвращается ошибка и м3/ч.
+func (cS Config) GetUnitG() (models.UnitGEnum, error) {
+	for _, unit := range []models.UnitGEnum{models.PerHour, models.PerSecond} {
+		if cS.unitG == unit.Code() {
+			return unit, nil
+		}
+	}
+	return models.PerHour, errors.New("единицы измерения расхода выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает единицы измерения температуры для вывода.
+// Если неверно заданы, то возвращается ошибка и градусы Цельсия.
+func (cS Config) GetUnitT() (models.UnitTEnum, error) {
+	for _, unit := range []models.UnitTEnum{models.Celsius, models.Kelvin} {
+		if cS.unitT == unit.Code() {
+			return unit, nil
+		}
+	}
+	return models.Celsius, errors.New("единицы измерения температуры выставлены не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Возвращает стандарт пересчёта единиц энергии.
+// Если неверно задан, то возвращается ошибка и коэффициенты производителя прибора.
+func (cS Config) GetStandardQ() (models.StandardQEnum, error) {
+	for _, standard := range []models.StandardQEnum{models.StandardManufacturer, models.StandardTKP411, models.StandardExact} {
+		if cS.standardQ == standard.Code() {
+			return standard, nil
+		}
+	}
+	return models.StandardManufacturer, errors.New("стандарт пересчёта энергии выставлен не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+}
+
+// Переводные коэффициенты энергии в ГКал, заданные для прибора. Незаданные коэффициенты равны нулю.
+func (cS Config) GetCoefficientsQ() models.CoefficientsQ {
+	return models.CoefficientsQ{GJ: cS.coefficientGJ, MWh: cS.coefficientMWh, KWh: cS.coefficientKWh}
+}
+
 // Инициализация конфигурации системы. Используются возможности стандартного пакета "flag"
 // Ошибки игнорируются для этого метода, т.к. flag.Parse() сам грохает терминал при ошибках.
 // Валидация должна производиться в методах Config.
@@ -129,11 +552,12 @@ func InitConfig() Config {
 			"Выключенный флаг - режим производства, отладачная информация в логах скрыта.\n\t"+
 			"Принимает значения 1, 0.")
 
-	flag.IntVar(
+	flag.StringVar(
 		&configService.deviceType,
 		"type",
-		9999,
+		"",
 		"Обязательный атрибут. Тип теплосчётчика, в зависимости от выбранного типа используется тот или иной драйвер\n\t"+
+			"Значение \""+DeviceTypeAuto+"\" - тип определяется автоматически по ответам прибора.\n\t"+
 			"Доступные типы(драйвера):"+
 			"\n\t   0 - СКМ-2"+
 			"\n\t   1 - SKU-02-B (5b)."+
@@ -151,7 +575,7 @@ func InitConfig() Config {
 			"\n\t   13 - TEM-104M2"+
 			"\n\t   14 - SKM2M."+
 			"\n\t   15 - alfamera."+
-		        "\n\t   16 - TEM-206")
+			"\n\tВместо номера можно задать имя драйвера, например tem104m.TEM104M")
 
 	flag.UintVar(
 		&configService.counterNumber,
@@ -159,11 +583,89 @@ func InitConfig() Config {
 		0,
 		"Номер теплосчётчика. Может принимать значения от 0 до 255")
 
+	flag.UintVar(
+		&configService.timeout,
+		"timeout",
+		0,
+		"Наименьший таймаут чтения ответа прибора в секундах, до 255. По умолчанию 0 - таймауты драйвера.\n\t"+
+			"Для медленных каналов, например GPRS с большим пингом")
+
+	flag.StringVar(
+		&configService.metersFile,
+		"metersFile",
+		"",
+		"Файл конфигурации опроса нескольких теплосчётчиков (TOML): объекты учёта, транспорты, приборы, единицы\n\t"+
+			"измерения, вывод и таймауты. Флаги утилиты задают значения по умолчанию. Формат файла описан в README")
+
+	flag.StringVar(
+		&configService.meterName,
+		"meter",
+		"",
+		"Имя прибора из файла metersFile, который нужно опросить. Если не задан, опрашиваются все приборы файла")
+
+	flag.StringVar(
+		&configService.tag,
+		"tag",
+		"",
+		"Тег приборов из файла metersFile, которые нужно опросить")
+
+	flag.UintVar(
+		&configService.workers,
+		"workers",
+		1,
+		"Количество одновременных опросов приборов из файла metersFile, от 1 до 256. По умолчанию 1.\n\t"+
+			"Приборы за одним транспортом (шлюзом, шиной) всегда опрашиваются по очереди")
+
 	flag.StringVar(
 		&configService.format,
 		"format",
 		"text",
-		"Формат вывода результата. По умолчанию текстовый вид \"text\". Также доступен формат \"json\"")
+		"Формат вывода результата. По умолчанию текстовый вид \"text\". Также доступны форматы \"json\", \"csv\",\n\t"+
+			"\"xml\" (для загрузки в учётные системы), \"prometheus\" (формат экспозиции Prometheus/OpenMetrics),\n\t"+
+			"\"influx\" (InfluxDB line protocol) и \"template\" (пользовательский шаблон из флага template)")
+
+	flag.UintVar(
+		&configService.jsonVersion,
+		"jsonVersion",
+		1,
+		"Версия схемы формата \"json\". По умолчанию 1. Возможно:"+
+			"\n\t   1 - первая версия, поля как в структурах Go, время в unix time"+
+			"\n\t   2 - поля в camelCase, время ISO 8601, обозначения единиц, сведения об опросе.\n\t"+
+			"       Схема документа - schema/qbox-v2.schema.json")
+
+	flag.StringVar(
+		&configService.xmlEncoding,
+		"xmlEncoding",
+		models.XmlUtf8,
+		"Кодировка документа для формата \"xml\". По умолчанию \""+models.XmlUtf8+"\". Возможно:"+
+			"\n\t   "+models.XmlUtf8+" - UTF-8"+
+			"\n\t   "+models.XmlWindows1251+" - для учётных систем, которые не принимают UTF-8")
+
+	flag.StringVar(
+		&configService.templateFile,
+		"template",
+		"",
+		"Файл шаблона text/template для формата \"template\". Шаблону передаются данные прибора DataDevice,\n\t"+
+			"функции шаблона описаны в README")
+
+	flag.StringVar(
+		&configService.csvDelimiter,
+		"csvDelimiter",
+		";",
+		"Разделитель колонок для формата \"csv\", один символ. По умолчанию \";\", как ожидает Excel с русской локалью")
+
+	flag.StringVar(
+		&configService.csvDecimal,
+		"csvDecimal",
+		",",
+		"Десятичный разделитель для формата \"csv\", один символ. По умолчанию \",\". Не должен совпадать с разделителем колонок")
+
+	flag.BoolVar(
+		&configService.csvAppend,
+		"csvAppend",
+		false,
+		"Вывод в формате \"csv\" без строки заголовков, для дописывания результатов опросов в один файл.\n\t"+
+			"Например: qBox -format=csv -csvAppend=1 ... >> result.csv. Принимает значения 1, 0.")
 
 	flag.UintVar( // Значения такие же как models.unitQ
 		&configService.unitQInt,
@@ -175,6 +677,240 @@ func InitConfig() Config {
 			"\n\t   3 - КВт"+
 			"\n\t   0 - МВт")
 
+	flag.StringVar(
+		&configService.standardQ,
+		"standardQ",
+		models.StandardManufacturer.Code(),
+		"Стандарт пересчёта единиц энергии. По умолчанию \""+models.StandardManufacturer.Code()+"\". Возможно:"+
+			"\n\t   "+models.StandardManufacturer.Code()+" - коэффициенты производителя прибора, если их нет - ТКП 411-2012"+
+			"\n\t   "+models.StandardTKP411.Code()+" - ТКП 411-2012"+
+			"\n\t   "+models.StandardExact.Code()+" - точный пересчёт, 1 кал = 4,1868 Дж")
+
+	flag.Float64Var(
+		&configService.coefficientGJ,
+		"coefficientGJ",
+		0,
+		"Переводной коэффициент ГДж в ГКал для прибора. Если задан, то используется вместо коэффициента стандарта")
+
+	flag.Float64Var(
+		&configService.coefficientMWh,
+		"coefficientMWh",
+		0,
+		"Переводной коэффициент МВт*ч в ГКал для прибора. Если задан, то используется вместо коэффициента стандарта")
+
+	flag.Float64Var(
+		&configService.coefficientKWh,
+		"coefficientKWh",
+		0,
+		"Переводной коэффициент КВт*ч в ГКал для прибора. Если задан, то используется вместо коэффициента стандарта")
+
+	flag.StringVar(
+		&configService.unitP,
+		"unitP",
+		models.MPa.Code(),
+		"Единицы измерения давления. По умолчанию \""+models.MPa.Code()+"\". Возможно:"+
+			"\n\t   "+models.MPa.Code()+" - МПа"+
+			"\n\t   "+models.Bar.Code()+" - бар"+
+			"\n\t   "+models.KgfCm2.Code()+" - кгс/см2"+
+			"\n\t   "+models.KPa.Code()+" - кПа")
+
+	flag.StringVar(
+		&configService.unitV,
+		"unitV",
+		models.M3.Code(),
+		"Единицы измерения объёма. По умолчанию \""+models.M3.Code()+"\". Возможно:"+
+			"\n\t   "+models.M3.Code()+" - м3"+
+			"\n\t   "+models.Liter.Code()+" - литры")
+
+	flag.StringVar(
+		&configService.unitM,
+		"unitM",
+		models.Tonne.Code(),
+		"Единицы измерения массы. По умолчанию \""+models.Tonne.Code()+"\". Возможно:"+
+			"\n\t   "+models.Tonne.Code()+" - тонны"+
+			"\n\t   "+models.Kilogram.Code()+" - килограммы")
+
+	flag.StringVar(
+		&configService.unitG,
+		"unitG",
+		models.PerHour.Code(),
+		"Единицы измерения расхода. По умолчанию \""+models.PerHour.Code()+"\". Возможно:"+
+			"\n\t   "+models.PerHour.Code()+" - м3/ч, массовый расход в т/ч"+
+			"\n\t   "+models.PerSecond.Code()+" - л/с, массовый расход в кг/с")
+
+	flag.StringVar(
+		&configService.unitT,
+		"unitT",
+		models.Celsius.Code(),
+		"Единицы измерения температуры. По умолчанию \""+models.Celsius.Code()+"\". Возможно:"+
+			"\n\t   "+models.Celsius.Code()+" - градусы Цельсия"+
+			"\n\t   "+models.Kelvin.Code()+" - Кельвины")
+
+	flag.StringVar(
+		&configService.command,
+		"command",
+		CommandRead,
+		"Команда утилиты. По умолчанию \""+CommandRead+"\". Возможно:"+
+			"\n\t   "+CommandRead+" - чтение текущих данных теплосчётчика"+
+			"\n\t   "+CommandSyncTime+" - синхронизация часов теплосчётчика с системным временем"+
+			"\n\t   "+CommandConfig+" - чтение конфигурации (настроек) теплосчётчика"+
+			"\n\t   "+CommandExporter+" - HTTP сервер для Prometheus по адресу из флага listen. Каждый запрос\n\t"+
+			"     /metrics?target=ipAddress:port&type=2&number=1 опрашивает теплосчётчик, type и number по умолчанию из флагов"+
+			"\n\t   "+CommandDecode+" - разбор кадров прибора драйвером из флага type: кадр из флага frame или сеанс из\n\t"+
+			"     файла флага session. Выводятся служебные поля кадра, поля данных со смещениями и контрольная сумма"+
+			"\n\t   "+CommandDump+" - чтение области памяти прибора ТЭМ (флаги memory, address, size, chunk) в файл dumpFile"+
+			"\n\t   "+CommandDumpDiff+" - сравнение двух файлов дампа: qBox -command="+CommandDumpDiff+" old.bin new.bin"+
+			"\n\t   "+CommandConsole+" - ручной обмен кадрами с прибором: кадры вводятся в шестнадцатеричном виде,\n\t"+
+			"     контрольная сумма добавляется по протоколу из флага protocol. Список команд - по команде help")
+
+	flag.StringVar(
+		&configService.protocol,
+		"protocol",
+		"",
+		"Протокол обмена для команды \""+CommandConsole+"\", по нему к кадрам добавляется контрольная сумма.\n\t"+
+			"По умолчанию - протокол драйвера из флага type. Возможно:"+
+			"\n\t   "+models.ProtocolTem.Code()+" - протокол ТЭМ, сумма байт с дополнением"+
+			"\n\t   "+models.ProtocolMbus.Code()+" - M-Bus, сумма байт и стоп-байт 16h"+
+			"\n\t   "+models.ProtocolModbus.Code()+" - Modbus RTU, CRC16"+
+			"\n\t   "+models.ProtocolSku02.Code()+" - SKU-02, три контрольные суммы и стоп-байт 16h"+
+			"\n\t   "+models.ProtocolNone.Code()+" - кадры отправляются как есть")
+
+	flag.StringVar(
+		&configService.consoleLog,
+		"consoleLog",
+		"console.log",
+		"Файл, в который дописывается сеанс команды \""+CommandConsole+"\". Файл можно разобрать командой\n\t"+
+			"\""+CommandDecode+"\" с флагом session. Пустое значение - сеанс не записывается")
+
+	flag.StringVar(
+		&configService.memory,
+		"memory",
+		drivers.TemMemory2K.Code(),
+		"Область памяти прибора ТЭМ для команды \""+CommandDump+"\". По умолчанию \""+drivers.TemMemory2K.Code()+"\". Возможно:"+
+			"\n\t   "+drivers.TemMemory2K.Code()+" - память 2К (у ТЭМ-104К - EEPROM 512), команда 0F01h"+
+			"\n\t   "+drivers.TemMemoryRam.Code()+" - оперативная память, команда 0C01h. Размер задаётся флагом size"+
+			"\n\t   "+drivers.TemMemoryFlash.Code()+" - flash память (EEPROM 64К), команда 0F03h")
+
+	flag.StringVar(
+		&configService.address,
+		"address",
+		"0",
+		"Начальный адрес дампа памяти, десятичное или шестнадцатеричное число. Например: 0x0200")
+
+	flag.StringVar(
+		&configService.size,
+		"size",
+		"0",
+		"Размер дампа памяти в байтах, десятичное или шестнадцатеричное число. По умолчанию 0 - до конца области памяти")
+
+	flag.UintVar(
+		&configService.chunk,
+		"chunk",
+		64,
+		"Размер блока, которым память читается с прибора, от 1 до 255 байт. По умолчанию 64.\n\t"+
+			"Большие блоки по GPRS приходят не полностью")
+
+	flag.StringVar(
+		&configService.dumpFile,
+		"dumpFile",
+		"dump.bin",
+		"Файл дампа памяти для команды \""+CommandDump+"\". Рядом сохраняется текстовый файл с расширением .hex")
+
+	flag.StringVar(
+		&configService.frame,
+		"frame",
+		"",
+		"Кадр ответа прибора в шестнадцатеричном виде для команды \""+CommandDecode+"\". Например: \"AA 01 FE 0F 01 04 ...\"")
+
+	flag.StringVar(
+		&configService.session,
+		"session",
+		"",
+		"Файл сеанса обмена для команды \""+CommandDecode+"\": лог app.log, записанный с флагом -dev=1, или текстовый\n\t"+
+			"файл, в котором строка \"> байты\" - запрос, \"< байты\" - ответ прибора")
+
+	flag.StringVar(
+		&configService.listen,
+		"listen",
+		":9710",
+		"Адрес HTTP сервера для команды \""+CommandExporter+"\". Например: \":9710\" или \"127.0.0.1:9710\"")
+
+	flag.StringVar(
+		&configService.site,
+		"site",
+		"",
+		"Объект учёта, на котором установлен теплосчётчик. Выводится тегом site в формате \"influx\"")
+
+	flag.StringVar(
+		&configService.influxName,
+		"influxMeasurement",
+		"heat",
+		"Имя measurement для формата \"influx\" и отправки в InfluxDB. По умолчанию \"heat\"")
+
+	flag.StringVar(
+		&configService.influxUrl,
+		"influxUrl",
+		"",
+		"Адрес InfluxDB, например \"http://localhost:8086\". Если задан, то данные дополнительно отправляются\n\t"+
+			"в InfluxDB через /api/v2/write в формате line protocol")
+
+	flag.StringVar(
+		&configService.influxOrg,
+		"influxOrg",
+		"",
+		"Организация InfluxDB")
+
+	flag.StringVar(
+		&configService.influxBucket,
+		"influxBucket",
+		"",
+		"Bucket InfluxDB для записи данных. Обязателен, если задан флаг influxUrl")
+
+	flag.StringVar(
+		&configService.influxToken,
+		"influxToken",
+		"",
+		"API токен InfluxDB. Если не задан, берётся из переменной окружения INFLUX_TOKEN")
+
+	flag.UintVar(
+		&configService.influxBatch,
+		"influxBatch",
+		5000,
+		"Количество строк в одном запросе к InfluxDB")
+
+	flag.UintVar(
+		&configService.influxRetry,
+		"influxRetry",
+		3,
+		"Количество повторных попыток отправки в InfluxDB при ошибке сети или перегрузке сервера")
+
+	flag.StringVar(
+		&configService.influxSpool,
+		"influxSpool",
+		"",
+		"Директория очереди для строк, которые не удалось отправить в InfluxDB. Строки из очереди отправляются\n\t"+
+			"при следующем запуске. Если не задана, неотправленные данные теряются")
+
+	flag.UintVar(
+		&configService.maxCorrection,
+		"maxCorrection",
+		300,
+		"Максимальная коррекция часов теплосчётчика в секундах для команды \""+CommandSyncTime+"\".\n\t"+
+			"Если расхождение часов больше, то время на приборе не изменяется.")
+
+	flag.BoolVar(
+		&configService.dryRun,
+		"dryRun",
+		false,
+		"Режим проверки. Изменения на приборе не производятся, а только фиксируются в логе. Принимает значения 1, 0.")
+
+	flag.BoolVar(
+		&configService.events,
+		"events",
+		false,
+		"Чтение журнала событий (нештатных ситуаций) теплосчётчика вместе с текущими данными. Принимает значения 1, 0.\n\t"+
+			"Для некоторых теплосчётчиков требует дополнительных запросов.")
+
 	var versionFlag *bool
 	versionFlag = flag.Bool("version", false, "Версия "+VersionCoreApp)
 
@@ -186,6 +922,7 @@ func InitConfig() Config {
 	}
 
 	configService.hostPort = flag.Arg(0)
+	configService.args = flag.Args()
 
 	return *configService
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"qBox/models"
+	"qBox/services/log"
+	"qBox/services/net"
+	"strings"
+)
+
+/**
+Порядок проверки драйверов при автоматическом определении типа теплосчётчика.
+Первыми проверяются приборы ТЭМ с идентификацией по наименованию, затем ТЭМ-104-1 и ТЭМ-104 по содержимому памяти,
+далее приборы M-Bus, SKU-02 и ModBus. Драйверы одной группы отвечают на одни и те же запросы идентификации, поэтому
+в группе проверяются все драйверы: прибор определён, только если его распознал ровно один из них.
+Драйверы без интерфейса IDetectDriver автоматически не определяются.
+*/
+var detectOrder = [][]int{{8}, {12}, {13, 11, 6}, {7}, {2}, {9, 10, 1}, {0, 14}, {4}, {5}}
+
+// Автоматическое определение типа теплосчётчика.
+// Группы драйверов опрашиваются по порядку detectOrder, выбирается первая группа, в которой прибор распознан.
+// Если прибор распознали несколько драйверов группы, возвращается ошибка со списком подходящих типов.
+// Проверку выполняет новый экземпляр драйвера: Detect сохраняет в драйвере соединение и номер прибора, а драйверы из
+// driversMap общие для одновременных опросов.
+func (cS *Config) DetectDriver(network *net.Network, logger *log.LoggerService) (models.IDeviceDriver, error) {
+	for _, group := range detectOrder {
+		var matchedTypes []int
+		for _, deviceType := range group {
+			detector, ok := newDriver(driversMap[deviceType]).(models.IDetectDriver)
+			if !ok {
+				continue
+			}
+
+			logger.Info("Проверка типа %d", deviceType)
+			matched, reason := detector.Detect(cS.GetCounterNumber(), network, logger)
+			if !matched {
+				logger.Info("Тип %d не подходит: %s", deviceType, reason)
+				continue
+			}
+
+			logger.Info("Подходит тип %d: %s", deviceType, reason)
+			matchedTypes = append(matchedTypes, deviceType)
+		}
+
+		switch len(matchedTypes) {
+		case 0:
+			continue
+		case 1:
+			logger.Info("Определён тип %d", matchedTypes[0])
+			return newDriver(driversMap[matchedTypes[0]]), nil
+		default:
+			return nil, fmt.Errorf("%w: прибор подходит под типы %s, задайте тип флагом \"-type\"",
+				ErrAmbiguousType, joinTypes(matchedTypes))
+		}
+	}
+	return nil, errors.New("не удалось определить тип теплосчётчика, задайте его флагом \"-type\"")
+}
+
+// Ошибка автоматического определения: прибор распознан несколькими драйверами
+var ErrAmbiguousType = errors.New("тип теплосчётчика определён неоднозначно")
+
+func joinTypes(deviceTypes []int) string {
+	names := make([]string, len(deviceTypes))
+	for i, deviceType := range deviceTypes {
+		names[i] = fmt.Sprint(deviceType)
+	}
+	return strings.Join(names, ", ")
+}
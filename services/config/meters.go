@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"strconv"
+	"strings"
+)
+
+/**
+Файл конфигурации опроса нескольких теплосчётчиков в формате TOML.
+Объекты учёта (site), транспорты (transport) - шлюзы, через которые доступны приборы, например GPRS модем или
+преобразователь RS-485/Ethernet, и приборы (meter). Флаги утилиты задают значения по умолчанию для всех приборов.
+*/
+type metersFile struct {
+	Output     meterOutput      `toml:"output"`
+	Sites      []siteEntry      `toml:"site"`
+	Transports []transportEntry `toml:"transport"`
+	Meters     []meterEntry     `toml:"meter"`
+}
+
+type siteEntry struct {
+	Name  string   `toml:"name"`  // имя объекта учёта, выводится тегом site в формате influx
+	Title string   `toml:"title"` // описание объекта учёта. Например: Котельная №1
+	Tags  []string `toml:"tags"`  // теги всех приборов объекта
+}
+
+type transportEntry struct {
+	Name    string `toml:"name"`
+	Address string `toml:"address"` // адрес шлюза ipAddress:port
+	Timeout uint   `toml:"timeout"` // наименьший таймаут чтения в секундах для приборов за шлюзом
+}
+
+type meterEntry struct {
+	Name      string      `toml:"name"`
+	Site      string      `toml:"site"`
+	Transport string      `toml:"transport"` // имя транспорта из файла
+	Address   string      `toml:"address"`   // адрес ipAddress:port, если прибор подключен без описанного транспорта
+	Type      string      `toml:"type"`      // значение флага type: номер, имя драйвера или auto
+	Number    *uint       `toml:"number"`
+	Tags      []string    `toml:"tags"`
+	Timeout   uint        `toml:"timeout"`
+	Events    *bool       `toml:"events"`
+	Units     meterUnits  `toml:"units"`
+	Output    meterOutput `toml:"output"`
+}
+
+// Единицы измерения прибора, значения как у флагов unitQ, unitP и т.д. Пустые значения берутся из флагов
+type meterUnits struct {
+	Q         *uint  `toml:"q"`
+	P         string `toml:"p"`
+	V         string `toml:"v"`
+	M         string `toml:"m"`
+	G         string `toml:"g"`
+	T         string `toml:"t"`
+	StandardQ string `toml:"standardQ"`
+}
+
+// Вывод результата опроса: формат, как у флага format, и файл. Если файл не задан, результат выводится в консоль
+type meterOutput struct {
+	Format string `toml:"format"`
+	File   string `toml:"file"`
+}
+
+/**
+Теплосчётчик из файла конфигурации опроса.
+Config - настройки утилиты для опроса прибора: флаги, дополненные значениями прибора из файла.
+Transport - шлюз, через который доступен прибор: имя транспорта из файла или адрес, если транспорт не описан.
+*/
+type Meter struct {
+	Name       string
+	Site       string
+	Transport  string
+	Tags       []string
+	OutputFile string
+	Config     Config
+}
+
+// Файл конфигурации опроса задан, приборы для опроса берутся из него
+func (cS Config) IsMetersFile() bool {
+	return cS.metersFile != ""
+}
+
+/**
+Приборы из файла конфигурации опроса в порядке описания в файле. Флаг meter выбирает прибор по имени, флаг tag - все
+приборы с тегом (теги объекта учёта относятся ко всем его приборам), без этих флагов опрашиваются все приборы файла.
+*/
+func (cS Config) GetMeters() ([]Meter, error) {
+	var file metersFile
+	metaData, err := toml.DecodeFile(cS.metersFile, &file)
+	if err != nil {
+		return nil, fmt.Errorf("файл конфигурации опроса %s: %w", cS.metersFile, err)
+	}
+	if undecoded := metaData.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("файл конфигурации опроса %s: неизвестные параметры %s", cS.metersFile, strings.Join(keys, ", "))
+	}
+
+	sites := map[string]siteEntry{}
+	for _, site := range file.Sites {
+		if site.Name == "" || sites[site.Name].Name != "" {
+			return nil, fmt.Errorf("объект учёта \"%s\": имя не задано или повторяется", site.Name)
+		}
+		sites[site.Name] = site
+	}
+	transports := map[string]transportEntry{}
+	for _, transport := range file.Transports {
+		if transport.Name == "" || transports[transport.Name].Name != "" {
+			return nil, fmt.Errorf("транспорт \"%s\": имя не задано или повторяется", transport.Name)
+		}
+		transports[transport.Name] = transport
+	}
+
+	var meters []Meter
+	names := map[string]bool{}
+	for _, entry := range file.Meters {
+		if entry.Name == "" || names[entry.Name] {
+			return nil, fmt.Errorf("прибор \"%s\": имя не задано или повторяется", entry.Name)
+		}
+		names[entry.Name] = true
+		meter, err := cS.forMeter(entry, file.Output, sites, transports)
+		if err != nil {
+			return nil, fmt.Errorf("прибор \"%s\": %w", entry.Name, err)
+		}
+		if cS.meterName != "" && meter.Name != cS.meterName || cS.tag != "" && !meter.HasTag(cS.tag) {
+			continue
+		}
+		meters = append(meters, meter)
+	}
+
+	if len(meters) == 0 {
+		switch {
+		case cS.meterName != "":
+			return nil, fmt.Errorf("прибор \"%s\" не найден в файле %s", cS.meterName, cS.metersFile)
+		case cS.tag != "":
+			return nil, fmt.Errorf("приборы с тегом \"%s\" не найдены в файле %s", cS.tag, cS.metersFile)
+		}
+		return nil, errors.New("в файле " + cS.metersFile + " нет приборов")
+	}
+	return meters, nil
+}
+
+// Прибор отмечен тегом
+func (meter Meter) HasTag(tag string) bool {
+	for _, meterTag := range meter.Tags {
+		if meterTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Обозначение прибора для сообщений: имя и транспорт
+func (meter Meter) String() string {
+	return meter.Name + " (" + meter.Transport + ", номер " + strconv.Itoa(int(meter.Config.GetCounterNumber())) + ")"
+}
+
+// Настройки опроса прибора: значения из файла заменяют значения флагов
+func (cS Config) forMeter(entry meterEntry, output meterOutput, sites map[string]siteEntry,
+	transports map[string]transportEntry) (Meter, error) {
+
+	meter := Meter{Name: entry.Name, Site: entry.Site, Tags: entry.Tags, OutputFile: output.File}
+	if entry.Site != "" {
+		site, ok := sites[entry.Site]
+		if !ok {
+			return meter, errors.New("объект учёта \"" + entry.Site + "\" не описан")
+		}
+		meter.Tags = append(append([]string{}, entry.Tags...), site.Tags...)
+		cS.site = site.Name
+	}
+
+	timeout := entry.Timeout
+	switch {
+	case entry.Transport != "":
+		transport, ok := transports[entry.Transport]
+		if !ok {
+			return meter, errors.New("транспорт \"" + entry.Transport + "\" не описан")
+		}
+		if entry.Address != "" {
+			return meter, errors.New("задан и транспорт, и адрес прибора")
+		}
+		meter.Transport = transport.Name
+		cS.hostPort = transport.Address
+		if timeout == 0 {
+			timeout = transport.Timeout
+		}
+	case entry.Address != "":
+		meter.Transport = entry.Address
+		cS.hostPort = entry.Address
+	default:
+		return meter, errors.New("не задан транспорт или адрес прибора")
+	}
+	if timeout > 0xFF {
+		return meter, errors.New("таймаут задан не правильно, возможны значения до 255 секунд")
+	}
+	if timeout > 0 {
+		cS.timeout = timeout
+	}
+
+	if entry.Type != "" {
+		cS.deviceType = entry.Type
+	}
+	if !cS.IsAutoDetect() {
+		if _, err := cS.GetDriver(); err != nil {
+			return meter, err
+		}
+	}
+	if entry.Number != nil {
+		if *entry.Number > 0xFF {
+			return meter, errors.New("номер теплосчётчика задан не правильно, возможны значения от 0 до 255")
+		}
+		cS.counterNumber = *entry.Number
+	}
+	if entry.Events != nil {
+		cS.events = *entry.Events
+	}
+
+	if entry.Units.Q != nil {
+		cS.unitQInt = *entry.Units.Q
+	}
+	for _, unit := range []struct {
+		value  string
+		target *string
+	}{
+		{entry.Units.P, &cS.unitP},
+		{entry.Units.V, &cS.unitV},
+		{entry.Units.M, &cS.unitM},
+		{entry.Units.G, &cS.unitG},
+		{entry.Units.T, &cS.unitT},
+		{entry.Units.StandardQ, &cS.standardQ},
+	} {
+		if unit.value != "" {
+			*unit.target = unit.value
+		}
+	}
+
+	if output.Format != "" {
+		cS.format = output.Format
+	}
+	if entry.Output.Format != "" {
+		cS.format = entry.Output.Format
+	}
+	if entry.Output.File != "" {
+		meter.OutputFile = entry.Output.File
+	}
+
+	meter.Config = cS
+	return meter, nil
+}
+
+// Количество одновременных опросов приборов из файла конфигурации опроса
+func (cS Config) GetWorkers() (int, error) {
+	if cS.workers < 1 || cS.workers > 256 {
+		return 1, errors.New("количество одновременных опросов выставлено не правильно. Список возможных вариантов доступен по флагу \"-help\" или \"-h\"")
+	}
+	return int(cS.workers), nil
+}
+
+// Настройки для вывода результата в файл, в который уже выведен результат другого прибора: CSV без строки заголовков
+func (cS Config) ForAppend() Config {
+	cS.csvAppend = true
+	return cS
+}
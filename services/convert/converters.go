@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"math"
+	"time"
 )
 
 // Переворачивает байты и возвращает результат
@@ -113,3 +114,15 @@ func LongLongLittleEndianByPointer(datum []byte, pointer uint32) uint64 {
 func LongWordLittleEndianByPointer(datum []byte, pointer uint32) uint32 {
 	return binary.BigEndian.Uint32([]byte{datum[pointer+3], datum[pointer+2], datum[pointer+1], datum[pointer]})
 }
+
+// Кодирование даты и времени в тип F протокола M-Bus (EN 13757-3): минуты, часы, день и младшие биты года,
+// месяц и старшие биты года. Секунды в типе F не передаются.
+func EncodeTypeF(t time.Time) []byte {
+	year := t.Year() - 2000
+	return []byte{
+		byte(t.Minute()) & 0x3F,
+		byte(t.Hour()) & 0x1F,
+		byte(t.Day())&0x1F | byte(year&0x07)<<5,
+		byte(t.Month())&0x0F | byte(year&0x78)<<1,
+	}
+}
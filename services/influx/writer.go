@@ -0,0 +1,269 @@
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"qBox/services/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Расширение файлов очереди, в которые сохраняются неотправленные строки
+const spoolExtension = ".lp"
+
+/**
+Отправка строк line protocol в InfluxDB через HTTP API /api/v2/write.
+Строки отправляются пакетами по BatchSize, при ошибке сети или ответе 429, 5xx пакет отправляется повторно до Retries раз
+с удваиванием паузы. Если InfluxDB недоступна и задана директория очереди SpoolDir, неотправленные строки сохраняются
+в файл и отправляются первыми при следующем запуске. Пакет, который InfluxDB отклонила (ошибка в строке, слишком
+большой запрос), делится пополам до отдельных строк: принятые строки записываются, а ошибочные отбрасываются с записью
+в лог, т.к. повторная отправка их не исправит.
+*/
+type Writer struct {
+	URL        string        // Адрес InfluxDB, например http://localhost:8086
+	Org        string        // Организация
+	Bucket     string        // Bucket для записи
+	Token      string        // API токен
+	BatchSize  int           // Количество строк в одном запросе
+	Retries    int           // Количество повторных попыток отправки пакета
+	RetryDelay time.Duration // Пауза перед первой повторной попыткой
+	SpoolDir   string        // Директория очереди неотправленных строк. Если не задана, строки теряются
+	client     *http.Client
+	logger     *log.LoggerService
+}
+
+func NewWriter(serverUrl string, org string, bucket string, token string, logger *log.LoggerService) *Writer {
+	return &Writer{
+		URL:        serverUrl,
+		Org:        org,
+		Bucket:     bucket,
+		Token:      token,
+		BatchSize:  5000,
+		Retries:    3,
+		RetryDelay: time.Second,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}
+}
+
+// Ошибка, при которой повторная отправка не поможет: InfluxDB отклонила данные
+type rejectedError struct {
+	message string
+}
+
+func (err rejectedError) Error() string {
+	return err.message
+}
+
+/**
+Отправка строк. Сначала отправляются строки из очереди, затем новые.
+При ошибке оставшиеся строки сохраняются в очередь, возвращается ошибка отправки.
+*/
+func (writer *Writer) Write(lines []string) error {
+	spooled, files, err := writer.readSpool()
+	if err != nil {
+		return err
+	}
+	if len(spooled) > 0 {
+		writer.logger.Info("Из очереди %s прочитано строк: %d", writer.SpoolDir, len(spooled))
+	}
+	lines = append(spooled, lines...)
+
+	var rejectedErr error
+	batchSize := writer.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(lines)
+	}
+	for start := 0; start < len(lines); start += batchSize {
+		end := start + batchSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+		done, rejection, err := writer.sendSplit(lines[start:end])
+		if rejection != nil {
+			rejectedErr = rejection
+		}
+		if err != nil {
+			return writer.spool(lines[start+done:], files, err)
+		}
+	}
+	writer.removeFiles(files)
+	return rejectedErr
+}
+
+/**
+Отправка пакета с делением: пакет, отклонённый InfluxDB, делится пополам, пока не останутся отдельные ошибочные строки,
+они отбрасываются. Возвращает количество отправленных или отброшенных строк от начала пакета, ошибку последнего
+отклонения и ошибку отправки, при которой оставшиеся строки нужно сохранить в очередь.
+*/
+func (writer *Writer) sendSplit(lines []string) (int, error, error) {
+	err := writer.send(lines)
+	rejection, rejected := err.(rejectedError)
+	if !rejected {
+		if err != nil {
+			return 0, nil, err
+		}
+		return len(lines), nil, nil
+	}
+	if len(lines) == 1 {
+		writer.logger.Error("InfluxDB отклонила строку %s. %s", lines[0], rejection.Error())
+		return 1, rejection, nil
+	}
+
+	half := len(lines) / 2
+	done, firstRejection, err := writer.sendSplit(lines[:half])
+	if err != nil {
+		return done, firstRejection, err
+	}
+	done, lastRejection, err := writer.sendSplit(lines[half:])
+	if lastRejection == nil {
+		lastRejection = firstRejection
+	}
+	return half + done, lastRejection, err
+}
+
+// Отправка пакета с повторами
+func (writer *Writer) send(lines []string) error {
+	var err error
+	delay := writer.RetryDelay
+	for attempt := 0; attempt <= writer.Retries; attempt++ {
+		if attempt > 0 {
+			writer.logger.Notice("Повторная отправка в InfluxDB через %s. %s", delay.String(), err.Error())
+			time.Sleep(delay)
+			delay *= 2
+		}
+		err = writer.post(lines)
+		if _, rejected := err.(rejectedError); err == nil || rejected {
+			return err
+		}
+	}
+	return err
+}
+
+func (writer *Writer) post(lines []string) error {
+	endpoint, err := writer.endpoint()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if writer.Token != "" {
+		request.Header.Set("Authorization", "Token "+writer.Token)
+	}
+
+	response, err := writer.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		writer.logger.Debug("В InfluxDB отправлено строк: %d", len(lines))
+		return nil
+	}
+	message := fmt.Sprintf("InfluxDB ответила %s: %s", response.Status, strings.TrimSpace(string(body)))
+	switch response.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return rejectedError{message: message}
+	}
+	return errors.New(message)
+}
+
+// Адрес API записи с параметрами org, bucket и precision. Если адрес уже содержит путь API, он не дополняется
+func (writer *Writer) endpoint() (string, error) {
+	endpoint, err := url.Parse(writer.URL)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return "", errors.New("адрес InfluxDB задан не правильно, например: http://localhost:8086")
+	}
+	if !strings.HasSuffix(endpoint.Path, "/api/v2/write") {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/api/v2/write"
+	}
+	query := endpoint.Query()
+	query.Set("org", writer.Org)
+	query.Set("bucket", writer.Bucket)
+	query.Set("precision", "ns")
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// Чтение очереди в порядке создания файлов
+func (writer *Writer) readSpool() ([]string, []string, error) {
+	if writer.SpoolDir == "" {
+		return nil, nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(writer.SpoolDir, "*"+spoolExtension))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+
+	var lines []string
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				lines = append(lines, scanner.Text())
+			}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return lines, files, nil
+}
+
+// Сохранение неотправленных строк в новый файл очереди, прочитанные файлы очереди заменяются им
+func (writer *Writer) spool(lines []string, files []string, sendErr error) error {
+	if writer.SpoolDir == "" {
+		return fmt.Errorf("строки не отправлены в InfluxDB, очередь не задана. %s", sendErr.Error())
+	}
+	err := os.MkdirAll(writer.SpoolDir, 0755)
+	if err != nil {
+		return fmt.Errorf("строки не отправлены в InfluxDB и не сохранены в очередь: %s. %s", err.Error(), sendErr.Error())
+	}
+
+	var content bytes.Buffer
+	for _, line := range lines {
+		content.WriteString(line + "\n")
+	}
+	name := filepath.Join(writer.SpoolDir, strconv.FormatInt(time.Now().UnixNano(), 10)+spoolExtension)
+	err = ioutil.WriteFile(name+".tmp", content.Bytes(), 0644)
+	if err == nil {
+		err = os.Rename(name+".tmp", name)
+	}
+	if err != nil {
+		return fmt.Errorf("строки не отправлены в InfluxDB и не сохранены в очередь: %s. %s", err.Error(), sendErr.Error())
+	}
+	writer.removeFiles(files)
+	writer.logger.Notice("В очередь %s сохранено строк: %d", name, len(lines))
+	return sendErr
+}
+
+func (writer *Writer) removeFiles(files []string) {
+	for _, name := range files {
+		err := os.Remove(name)
+		if err != nil {
+			writer.logger.Error("Не удалось удалить файл очереди %s: %s", name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package influx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"qBox/services/log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Тестовый сервер InfluxDB: запоминает принятые пакеты, ответ на запрос задаёт handler
+type fakeInflux struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	requests int
+	accepted [][]string
+}
+
+func newFakeInflux(t *testing.T, handler func(request int, lines []string) int) *fakeInflux {
+	fake := &fakeInflux{}
+	fake.server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/api/v2/write" || request.URL.Query().Get("bucket") != "meters" ||
+			request.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("неожиданный запрос %s, авторизация %q", request.URL, request.Header.Get("Authorization"))
+		}
+		body, _ := ioutil.ReadAll(request.Body)
+		lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+
+		fake.mutex.Lock()
+		fake.requests++
+		status := handler(fake.requests, lines)
+		if status == http.StatusNoContent {
+			fake.accepted = append(fake.accepted, lines)
+		}
+		fake.mutex.Unlock()
+		response.WriteHeader(status)
+	}))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+func newTestWriter(url string) *Writer {
+	logger := log.LoggerService{}
+	logger.OpenDiscard()
+	writer := NewWriter(url, "org", "meters", "secret", &logger)
+	writer.RetryDelay = time.Millisecond
+	return writer
+}
+
+func TestWriterBatches(t *testing.T) {
+	fake := newFakeInflux(t, func(int, []string) int { return http.StatusNoContent })
+	writer := newTestWriter(fake.server.URL)
+	writer.BatchSize = 2
+
+	err := writer.Write([]string{"heat a=1", "heat a=2", "heat a=3", "heat a=4", "heat a=5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.accepted) != 3 || len(fake.accepted[0]) != 2 || fake.accepted[2][0] != "heat a=5" {
+		t.Errorf("пакеты: %v", fake.accepted)
+	}
+}
+
+func TestWriterRetry(t *testing.T) {
+	fake := newFakeInflux(t, func(request int, _ []string) int {
+		if request == 1 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusNoContent
+	})
+	writer := newTestWriter(fake.server.URL)
+
+	err := writer.Write([]string{"heat a=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.requests != 2 || len(fake.accepted) != 1 {
+		t.Errorf("запросов %d, принято пакетов %d", fake.requests, len(fake.accepted))
+	}
+}
+
+func TestWriterSpool(t *testing.T) {
+	available := false
+	fake := newFakeInflux(t, func(int, []string) int {
+		if !available {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusNoContent
+	})
+	writer := newTestWriter(fake.server.URL)
+	writer.Retries = 1
+	writer.SpoolDir = t.TempDir()
+
+	if err := writer.Write([]string{"heat a=1", "heat a=2"}); err == nil {
+		t.Fatal("ожидалась ошибка отправки")
+	}
+	files, _ := filepath.Glob(filepath.Join(writer.SpoolDir, "*"+spoolExtension))
+	if len(files) != 1 {
+		t.Fatalf("файлов очереди %d, ожидался 1", len(files))
+	}
+
+	fake.mutex.Lock()
+	available = true
+	fake.mutex.Unlock()
+	if err := writer.Write([]string{"heat a=3"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.accepted) != 1 || strings.Join(fake.accepted[0], ";") != "heat a=1;heat a=2;heat a=3" {
+		t.Errorf("принято: %v", fake.accepted)
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("файл очереди %s не удалён", files[0])
+	}
+}
+
+func TestWriterRejectedLines(t *testing.T) {
+	fake := newFakeInflux(t, func(_ int, lines []string) int {
+		for _, line := range lines {
+			if strings.Contains(line, "bad") {
+				return http.StatusBadRequest
+			}
+		}
+		return http.StatusNoContent
+	})
+	writer := newTestWriter(fake.server.URL)
+	writer.SpoolDir = t.TempDir()
+
+	err := writer.Write([]string{"heat a=1", "heat bad", "heat a=3", "heat a=4", "heat a=5"})
+	if _, rejected := err.(rejectedError); !rejected {
+		t.Fatalf("ожидалась ошибка отклонения, получено %v", err)
+	}
+
+	var accepted []string
+	for _, batch := range fake.accepted {
+		accepted = append(accepted, batch...)
+	}
+	if strings.Join(accepted, ";") != "heat a=1;heat a=3;heat a=4;heat a=5" {
+		t.Errorf("принято: %v", accepted)
+	}
+	if files, _ := filepath.Glob(filepath.Join(writer.SpoolDir, "*")); len(files) != 0 {
+		t.Errorf("отклонённые строки сохранены в очередь: %v", files)
+	}
+}
@@ -3,10 +3,16 @@ package log
 import (
 	"github.com/go-ozzo/ozzo-log"
 	"fmt"
+	"os"
 )
 
+/**
+Лог утилиты. Категория сообщений переключается методом Check и хранится в экземпляре сервиса, поэтому при одновременном
+опросе нескольких приборов каждый опрос получает свой экземпляр методом ForScope.
+*/
 type LoggerService struct {
 	logger *log.Logger
+	scope  string // имя прибора, к которому относятся сообщения. Добавляется к категории и к ошибкам в stderr
 }
 
 func (l *LoggerService) Open(devEnv bool, silentMode bool) error {
@@ -34,6 +40,12 @@ func (l *LoggerService) Open(devEnv bool, silentMode bool) error {
 	return nil
 }
 
+// Лог без получателей: сообщения не записываются, ошибки (Fatal) по-прежнему выводятся в stderr.
+// Используется, когда драйвер запускается многократно и его сообщения не нужны, например при разборе кадров
+func (l *LoggerService) OpenDiscard() {
+	l.logger = log.NewLogger()
+}
+
 func (l *LoggerService) Close() {
 	l.Check("app")
 	l.Info("Закончено")
@@ -42,9 +54,19 @@ func (l *LoggerService) Close() {
 }
 
 func (l *LoggerService) Check(category string) {
+	if l.scope != "" {
+		category = l.scope + "/" + category
+	}
 	l.logger = l.logger.GetLogger(category)
 }
 
+// Отдельный экземпляр лога для опроса прибора scope. Сообщения записываются в тот же лог с категорией "scope/категория"
+func (l LoggerService) ForScope(scope string) *LoggerService {
+	l.scope = scope
+	l.Check("app")
+	return &l
+}
+
 func (l LoggerService) Info(format string, a ...interface{}) {
 	if len(a) > 0 {
 		l.logger.Info(format, a...)
@@ -54,13 +76,18 @@ func (l LoggerService) Info(format string, a ...interface{}) {
 }
 
 func (l LoggerService) Fatal(format string, a ...interface{}) {
+	message := format
 	if len(a) > 0 {
 		l.logger.Emergency(format, a...)
-		fmt.Printf(format+"\n", a)
+		message = fmt.Sprintf(format, a...)
 	} else {
 		l.logger.Emergency(format)
-		fmt.Println(format)
 	}
+	// Одной записью, чтобы сообщения одновременных опросов не перемешивались
+	if l.scope != "" {
+		message = l.scope + ": " + message
+	}
+	fmt.Fprintln(os.Stderr, message)
 }
 
 func (l LoggerService) Error(format string, a ...interface{}) {
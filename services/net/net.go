@@ -14,6 +14,12 @@ import (
 const connected = 0x01
 const disconnected = 0x02
 
+// Ошибки обмена с прибором. Драйверы возвращают их без изменений, по ним ядро определяет класс ошибки опроса
+var (
+	ErrNoResponse  = errors.New("прибор не ответил")
+	ErrBadResponse = errors.New("получен некорректный ответ")
+)
+
 /**
 Сервис для работы с TCP соединением
 */
@@ -23,12 +29,22 @@ type Network struct {
 	connection       *net.TCPConn
 	logger           log.LoggerService
 	connectionStatus byte
+	replay           *replay // записанный сеанс, если обмен воспроизводится вместо TCP соединения
+	minReadTimeout   uint8   // наименьший таймаут чтения в секундах для всех запросов, 0 - таймауты драйвера
 }
 
 func NewNetwork(ip string, port int, logger log.LoggerService) *Network {
 	return &Network{host: ip, port: port, logger: logger, connectionStatus: disconnected}
 }
 
+/**
+Наименьший таймаут чтения для всех запросов драйвера, в секундах. Таймауты драйверов подобраны для обычных каналов,
+для медленных (GPRS с большим пингом) их можно увеличить, не меняя драйвер. Больший таймаут запроса не уменьшается.
+*/
+func (network *Network) SetMinReadTimeout(seconds uint8) {
+	network.minReadTimeout = seconds
+}
+
 func (network *Network) IsConnected() bool {
 	return network.connectionStatus == connected
 }
@@ -99,6 +115,10 @@ func (network *Network) RunIO(request Request) ([]byte, error) {
 
 	network.logger.Check("netService")
 
+	if network.replay != nil {
+		return network.runReplay(request)
+	}
+
 	if !network.IsConnected() {
 		err = network.Connect()
 		if err != nil {
@@ -130,11 +150,14 @@ func (network *Network) RunIO(request Request) ([]byte, error) {
 	errorsCount := 0
 	for {
 
-		tempResponse, err := network.doRead(request.SecondsReadTimeout)
+		tempResponse, err := network.doRead(network.readTimeout(request.SecondsReadTimeout))
 
 		if err == io.EOF && request.Reconnect {
 			network.logger.Debug("Получен EOF")
 			network.Reconnect()
+			if request.Attempts == 0 {
+				break
+			}
 			err = write()
 			if err != nil {
 				return response, err
@@ -171,6 +194,10 @@ func (network *Network) RunIO(request Request) ([]byte, error) {
 				break
 			}
 
+			if request.Attempts == 0 {
+				break
+			}
+
 			if len(response) == 0 {
 				// Зафиксирована ошибка, требуется послать запрос заново.
 				err = write()
@@ -214,13 +241,79 @@ func (network *Network) RunIO(request Request) ([]byte, error) {
 
 	if !request.ControlFunction(response) {
 		network.logger.Debug("Проверка ответа завершилась неудачей. Повторные попытки все исчерпаны.")
-		return response, errors.New("получен некорректный ответ")
+		if len(response) == 0 {
+			return response, ErrNoResponse
+		}
+		return response, ErrBadResponse
 	}
 
 	network.logger.Debug("Результат - %X", response)
 	return response, err
 }
 
+/**
+Часть ответа прибора, полученная одним чтением, и время её получения от момента отправки запроса
+*/
+type Chunk struct {
+	Elapsed time.Duration
+	Bytes   []byte
+}
+
+/**
+Однократная отправка кадра без проверки ответа и повторных попыток, для ручного обмена с прибором.
+Ответ читается, пока complete не подтвердит получение кадра (эхо запроса при проверке не учитывается) или пока
+очередное чтение не завершится по таймауту. Части ответа возвращаются как получены, вместе с эхом.
+*/
+func (network *Network) Transmit(frame []byte, timeout time.Duration, complete func(response []byte) bool) ([]Chunk, error) {
+	network.logger.Check("netService")
+
+	if network.replay != nil {
+		return nil, errors.New("ручной обмен при воспроизведении сеанса не поддерживается")
+	}
+	if !network.IsConnected() {
+		err := network.Connect()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := network.connection.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	_, err = network.connection.Write(frame)
+	network.logger.Debug("Отправка %d байт: %X", len(frame), frame)
+	if err != nil {
+		network.logger.Debug("%s", err.Error())
+		return nil, err
+	}
+
+	var chunks []Chunk
+	var response []byte
+	buffer := make([]byte, 1200)
+	for {
+		err = network.connection.SetReadDeadline(time.Now().Add(timeout))
+		if err != nil {
+			return chunks, err
+		}
+		n, err := network.connection.Read(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return chunks, nil
+			}
+			network.logger.Debug("%s", err.Error())
+			return chunks, err
+		}
+		network.logger.Debug("Получено %d байт: %X", n, buffer[:n])
+		chunks = append(chunks, Chunk{Elapsed: time.Since(start), Bytes: append([]byte{}, buffer[:n]...)})
+		response = append(response, buffer[:n]...)
+		if complete != nil && complete(trimEcho(frame, response)) {
+			return chunks, nil
+		}
+	}
+}
+
 func (network *Network) doRead(secondsTimeout uint8) ([]byte, error) {
 	var err error
 	err = network.setReadTimeout(secondsTimeout)
@@ -246,6 +339,13 @@ func (network *Network) doRead(secondsTimeout uint8) ([]byte, error) {
 	return buffer[:n], nil
 }
 
+func (network *Network) readTimeout(seconds uint8) uint8 {
+	if seconds < network.minReadTimeout {
+		return network.minReadTimeout
+	}
+	return seconds
+}
+
 func (network *Network) setReadTimeout(seconds uint8) error {
 	return network.connection.SetReadDeadline(time.Now().Add(time.Duration(seconds) * time.Second))
 }
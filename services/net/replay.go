@@ -0,0 +1,226 @@
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"qBox/services/log"
+	"regexp"
+	"strings"
+)
+
+/**
+Обмен с прибором: запрос и полученный на него ответ.
+Запрос может отсутствовать, если известен только ответ прибора, например кадр, скопированный из лога.
+*/
+type Exchange struct {
+	Request  []byte
+	Response []byte
+}
+
+/**
+Воспроизведение записанного сеанса обмена вместо TCP соединения.
+Драйвер получает ответы из сеанса, поэтому разбирает их своим кодом так же, как при опросе прибора.
+*/
+type replay struct {
+	exchanges []Exchange
+	used      []bool
+	calls     []ReplayCall
+}
+
+/**
+Запрос драйвера при воспроизведении сеанса.
+Exchange - номер обмена сеанса, ответ которого получил драйвер, -1, если ответа в сеансе не нашлось.
+Passed - результат проверки ответа функцией драйвера Request.ControlFunction.
+*/
+type ReplayCall struct {
+	Request  []byte
+	Exchange int
+	Passed   bool
+}
+
+// Сервис, который вместо обмена по TCP отвечает на запросы драйвера ответами из записанного сеанса
+func NewReplayNetwork(exchanges []Exchange, logger log.LoggerService) *Network {
+	return &Network{
+		logger:           logger,
+		connectionStatus: disconnected,
+		replay:           &replay{exchanges: exchanges, used: make([]bool, len(exchanges))},
+	}
+}
+
+// Запросы драйвера, на которые были выданы ответы сеанса, по порядку
+func (network *Network) ReplayCalls() []ReplayCall {
+	if network.replay == nil {
+		return nil
+	}
+	return network.replay.calls
+}
+
+/**
+Ответ на запрос из сеанса. Выбирается первый неиспользованный обмен с тем же запросом, если такого нет - первый
+неиспользованный обмен без запроса или, если сеанс записан для другого номера прибора, просто следующий по порядку.
+*/
+func (network *Network) runReplay(request Request) ([]byte, error) {
+	network.logger.Debug("Воспроизведение запроса %X", request.Bytes)
+	r := network.replay
+	call := ReplayCall{Request: request.Bytes, Exchange: r.next(request.Bytes)}
+	if call.Exchange < 0 {
+		r.calls = append(r.calls, call)
+		network.logger.Debug("Ответ на запрос в сеансе не найден")
+		return nil, ErrNoResponse
+	}
+
+	r.used[call.Exchange] = true
+	response := append([]byte{}, r.exchanges[call.Exchange].Response...)
+	call.Passed = request.ControlFunction(response)
+	r.calls = append(r.calls, call)
+	network.logger.Debug("Ответ из сеанса - %X", response)
+	if !call.Passed {
+		if len(response) == 0 {
+			return response, ErrNoResponse
+		}
+		return response, ErrBadResponse
+	}
+	return response, nil
+}
+
+func (r *replay) next(request []byte) int {
+	free := -1
+	for i, exchange := range r.exchanges {
+		if r.used[i] {
+			continue
+		}
+		if bytes.Equal(exchange.Request, request) {
+			return i
+		}
+		if free < 0 && exchange.Request == nil {
+			free = i
+		}
+	}
+	if free >= 0 {
+		return free
+	}
+	for i := range r.exchanges {
+		if !r.used[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	sessionSent     = regexp.MustCompile(`Отправка \d+ байт: ([0-9A-F]*)`)
+	sessionReceived = regexp.MustCompile(`Получено \d+ байт: ([0-9A-F]*)`)
+	sessionResult   = regexp.MustCompile(`Результат - ([0-9A-F]*)`)
+)
+
+/**
+Чтение сеанса обмена. Поддерживается два вида записи:
+ - лог утилиты app.log, записанный с флагом -dev=1. Берётся последний запуск утилиты, в котором был обмен с прибором;
+ - текстовый файл, в котором строка "> байты" - запрос, "< байты" - ответ прибора, "#" - комментарий.
+Байты записываются в шестнадцатеричном виде, пробелы между байтами допускаются.
+*/
+func ReadSession(reader io.Reader) ([]Exchange, error) {
+	var session, last []Exchange
+	var received []byte
+	waiting := false // последний запрос ещё не получил итоговый ответ
+
+	finish := func() {
+		if waiting && len(received) > 0 {
+			session[len(session)-1].Response = trimEcho(session[len(session)-1].Request, received)
+		}
+		waiting = false
+		received = nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := sessionSent.FindStringSubmatch(line); match != nil {
+			request, err := ParseHex(match[1])
+			if err != nil {
+				return nil, err
+			}
+			if !waiting || !bytes.Equal(session[len(session)-1].Request, request) {
+				finish()
+				session = append(session, Exchange{Request: request})
+			}
+			// Повторная отправка того же запроса: учитывается ответ на последнюю попытку
+			waiting = true
+			received = nil
+			continue
+		}
+		if match := sessionReceived.FindStringSubmatch(line); match != nil {
+			chunk, err := ParseHex(match[1])
+			if err != nil {
+				return nil, err
+			}
+			received = append(received, chunk...)
+			continue
+		}
+		if match := sessionResult.FindStringSubmatch(line); match != nil && waiting {
+			response, err := ParseHex(match[1])
+			if err != nil {
+				return nil, err
+			}
+			session[len(session)-1].Response = response
+			waiting = false
+			received = nil
+			continue
+		}
+		if strings.HasSuffix(line, "] Начато") {
+			finish()
+			if len(session) > 0 {
+				last = session
+			}
+			session = nil
+			continue
+		}
+		if strings.HasPrefix(line, ">") || strings.HasPrefix(line, "<") {
+			finish()
+			frame, err := ParseHex(line[1:])
+			if err != nil {
+				return nil, err
+			}
+			if line[0] == '>' {
+				session = append(session, Exchange{Request: frame})
+			} else if len(session) > 0 && session[len(session)-1].Response == nil {
+				session[len(session)-1].Response = frame
+			} else {
+				session = append(session, Exchange{Response: frame})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+
+	if len(session) == 0 {
+		session = last
+	}
+	if len(session) == 0 {
+		return nil, errors.New("в сеансе не найдено ни одного обмена с прибором")
+	}
+	return session, nil
+}
+
+// Байты из шестнадцатеричной записи. Например: "AA 01 FE" или "AA01FE"
+func ParseHex(text string) ([]byte, error) {
+	text = strings.Join(strings.Fields(text), "")
+	frame, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, errors.New("байты кадра заданы не правильно: " + err.Error())
+	}
+	return frame, nil
+}
+
+func trimEcho(request []byte, response []byte) []byte {
+	if len(request) > 0 && bytes.HasPrefix(response, request) {
+		return response[len(request):]
+	}
+	return response
+}
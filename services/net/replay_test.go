@@ -0,0 +1,124 @@
+package net
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Два запуска утилиты с флагом -dev=1: берётся последний, в нём повтор запроса после таймаута и ответ с эхом
+const appLogSession = `2026-10-19T01:30:00Z [Info][app] Начато
+2026-10-19T01:30:00Z [Debug][netService] Отправка 4 байт: 33817E32
+2026-10-19T01:30:01Z [Debug][netService] Получено 2 байт: 4420
+2026-10-19T01:30:01Z [Debug][netService] Результат - 4420
+2026-10-19T01:30:01Z [Info][app] Закончено
+2026-10-19T01:33:13Z [Info][app] Начато
+2026-10-19T01:33:13Z [Info][netService] Соединение установлено.
+2026-10-19T01:33:13Z [Debug][netService] Отправка 3 байт: 01AB02
+2026-10-19T01:33:16Z [Debug][netService] i/o timeout
+2026-10-19T01:33:16Z [Debug][netService] Отправка 3 байт: 01AB02
+2026-10-19T01:33:16Z [Debug][netService] Получено 4 байт: 01AB0244
+2026-10-19T01:33:17Z [Debug][netService] Получено 2 байт: 2030
+2026-10-19T01:33:17Z [Debug][netService] Отправка 2 байт: 0C0D
+2026-10-19T01:33:17Z [Debug][netService] Получено 3 байт: 0E0F10
+2026-10-19T01:33:17Z [Debug][netService] Результат - 0E0F
+2026-10-19T01:33:17Z [Info][app] Закончено
+`
+
+// Сеанс команды console: запросы, ответы и комментарии
+const frameSession = `# qBox console: 127.0.0.1:4001, протокол tem, 2026-10-19T01:40:00Z
+# 2026-10-19 01:40:01
+> 55 01 FE 0F 01 03 00 00 07 91
+< AA01FE0F0103010203 3D
+# 2026-10-19 01:40:02
+> 5501FE
+# ошибка: прибор не ответил
+< E5
+`
+
+func TestReadSessionAppLog(t *testing.T) {
+	session, err := ReadSession(strings.NewReader(appLogSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Exchange{
+		{Request: []byte{0x01, 0xAB, 0x02}, Response: []byte{0x44, 0x20, 0x30}},
+		{Request: []byte{0x0C, 0x0D}, Response: []byte{0x0E, 0x0F}},
+	}
+	checkSession(t, session, expected)
+}
+
+func TestReadSessionFrames(t *testing.T) {
+	session, err := ReadSession(strings.NewReader(frameSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Exchange{
+		{Request: []byte{0x55, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x00, 0x00, 0x07, 0x91},
+			Response: []byte{0xAA, 0x01, 0xFE, 0x0F, 0x01, 0x03, 0x01, 0x02, 0x03, 0x3D}},
+		{Request: []byte{0x55, 0x01, 0xFE}, Response: []byte{0xE5}},
+	}
+	checkSession(t, session, expected)
+}
+
+func TestReadSessionErrors(t *testing.T) {
+	if _, err := ReadSession(strings.NewReader("# только комментарий\n")); err == nil {
+		t.Error("пустой сеанс принят")
+	}
+	if _, err := ReadSession(strings.NewReader("> 55 0G\n")); err == nil {
+		t.Error("неверные байты кадра приняты")
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		text  string
+		frame []byte
+		fail  bool
+	}{
+		{"AA 01 FE", []byte{0xAA, 0x01, 0xFE}, false},
+		{"aa01fe", []byte{0xAA, 0x01, 0xFE}, false},
+		{"  AA\t01  FE ", []byte{0xAA, 0x01, 0xFE}, false},
+		{"", []byte{}, false},
+		{"AA 1", nil, true},
+		{"ZZ", nil, true},
+	}
+	for _, test := range tests {
+		frame, err := ParseHex(test.text)
+		if (err != nil) != test.fail || !bytes.Equal(frame, test.frame) {
+			t.Errorf("ParseHex(%q) = % X, %v", test.text, frame, err)
+		}
+	}
+}
+
+func TestTrimEcho(t *testing.T) {
+	tests := []struct {
+		request  []byte
+		response []byte
+		trimmed  []byte
+	}{
+		{[]byte{0x01, 0xAB, 0x02}, []byte{0x01, 0xAB, 0x02, 0x44, 0x20}, []byte{0x44, 0x20}},
+		{[]byte{0x01, 0xAB, 0x02}, []byte{0x01, 0xAB, 0x02}, []byte{}},
+		{[]byte{0x01, 0xAB, 0x02}, []byte{0x01, 0xAB, 0x44}, []byte{0x01, 0xAB, 0x44}},
+		{[]byte{0x01, 0xAB, 0x02}, []byte{0x01, 0xAB}, []byte{0x01, 0xAB}},
+		{[]byte{}, []byte{0x44, 0x20}, []byte{0x44, 0x20}},
+	}
+	for _, test := range tests {
+		if trimmed := trimEcho(test.request, test.response); !bytes.Equal(trimmed, test.trimmed) {
+			t.Errorf("trimEcho(% X, % X) = % X, ожидалось % X", test.request, test.response, trimmed, test.trimmed)
+		}
+	}
+}
+
+func checkSession(t *testing.T, session []Exchange, expected []Exchange) {
+	t.Helper()
+	if len(session) != len(expected) {
+		t.Fatalf("обменов %d, ожидалось %d: %v", len(session), len(expected), session)
+	}
+	for i := range expected {
+		if !bytes.Equal(session[i].Request, expected[i].Request) || !bytes.Equal(session[i].Response, expected[i].Response) {
+			t.Errorf("обмен %d: > % X < % X, ожидалось > % X < % X", i, session[i].Request, session[i].Response,
+				expected[i].Request, expected[i].Response)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package net
 
-// Структура запроса к теплосчётчику
+// Структура запроса к теплосчётчику.
+// Запрос с Attempts = 0 отправляется один раз и не повторяется ни после таймаута, ни после переподключения. Так
+// отправляются команды записи, например времени: прибор мог получить и выполнить первый запрос, а повторная запись
+// устаревшего на время обмена значения нежелательна.
 type Request struct {
 	Bytes              []byte                     // байты, которые будут посланы в порт теплосчётчика
 	ControlFunction    func(response []byte) bool // Функция проверки полученного результата от теплосчётчика
-	Attempts           uint8                      // количество попыток перепосылки байтов в порт теплосчётчика в случае ошибки при чтении данных
+	Attempts           uint8                      // количество попыток перепосылки байтов в порт теплосчётчика в случае ошибки при чтении данных, 0 - запрос отправляется один раз
 	Reconnect          bool                       // требуется ли производить переподключение соединения при ошибки EOF
 	SecondsReadTimeout uint8                      // таймаут при чтении данных с теплосчётчика
 }
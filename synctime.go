@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	"time"
+)
+
+// Формат вывода времени в лог и в результат команды
+const syncTimeLayout = "02.01.2006 15:04:05"
+
+// Синхронизация часов теплосчётчика с системным временем.
+// За системное время в момент чтения часов принимается середина интервала между отправкой запроса и получением ответа,
+// так же половина задержки обмена добавляется к записываемому времени.
+// Если расхождение меньше точности часов прибора (у приборов M-Bus время передаётся с точностью до минуты) или больше
+// допустимой коррекции, то часы прибора не изменяются.
+func syncTime(driver models.IDeviceDriver, configService configPackage.Config, logger *logPackage.LoggerService) error {
+	clock, ok := driver.(models.IClockDriver)
+	if !ok {
+		return errors.New("драйвер не поддерживает работу с часами теплосчётчика")
+	}
+
+	logger.Check("driver")
+	logger.Info("Чтение времени на приборе")
+	start := time.Now()
+	deviceTime, err := clock.ReadTime()
+	if err != nil {
+		return err
+	}
+	roundTrip := time.Since(start)
+	systemTime := start.Add(roundTrip / 2)
+	drift := deviceTime.Sub(systemTime).Round(time.Second)
+
+	logger.Check("app")
+	logger.Info("Время на приборе - %s", deviceTime.Format(syncTimeLayout))
+	logger.Info("Системное время - %s", systemTime.Format(syncTimeLayout))
+	logger.Info("Задержка обмена - %s, расхождение часов - %s", roundTrip.Round(time.Millisecond), drift)
+
+	resolution := clock.TimeResolution()
+	if drift < resolution && -drift < resolution {
+		logger.Info("Коррекция часов не требуется, точность часов прибора - %s", resolution)
+		renderSyncTime(deviceTime, deviceTime, "коррекция не требуется")
+		return nil
+	}
+
+	maxCorrection := configService.GetMaxCorrection()
+	if drift > maxCorrection || -drift > maxCorrection {
+		renderSyncTime(deviceTime, deviceTime, "отказ")
+		return fmt.Errorf("расхождение часов %s превышает допустимую коррекцию %s", drift, maxCorrection)
+	}
+
+	newTime := time.Now().Add(roundTrip / 2)
+	if configService.IsDryRun() {
+		logger.Info("Режим проверки. Запись времени %s на прибор не производится", newTime.Format(syncTimeLayout))
+		renderSyncTime(deviceTime, newTime, "режим проверки")
+		return nil
+	}
+
+	logger.Check("driver")
+	logger.Info("Запись времени %s на прибор", newTime.Format(syncTimeLayout))
+	err = clock.WriteTime(newTime)
+	if errors.Is(err, models.ErrClockReadOnly) {
+		renderSyncTime(deviceTime, deviceTime, "запись не поддерживается")
+		return err
+	}
+	if err != nil {
+		renderSyncTime(deviceTime, newTime, "ошибка записи")
+		return err
+	}
+
+	logger.Check("app")
+	logger.Info("Время на приборе изменено с %s на %s", deviceTime.Format(syncTimeLayout), newTime.Format(syncTimeLayout))
+	renderSyncTime(deviceTime, newTime, "успешно")
+	return nil
+}
+
+func renderSyncTime(oldTime time.Time, newTime time.Time, result string) {
+	_, _ = fmt.Fprintf(os.Stdout, "Время на приборе: %s\n", oldTime.Format(syncTimeLayout))
+	_, _ = fmt.Fprintf(os.Stdout, "Новое время: %s\n", newTime.Format(syncTimeLayout))
+	_, _ = fmt.Fprintf(os.Stdout, "Результат: %s\n", result)
+}
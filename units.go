@@ -0,0 +1,78 @@
+package main
+
+import (
+	"qBox/models"
+	configPackage "qBox/services/config"
+	logPackage "qBox/services/log"
+	"strconv"
+)
+
+// Подготовка данных к выводу: пересчёт энергии по выбранному стандарту и приведение всех величин к единицам,
+// заданным флагами утилиты. Если драйвер не заполнил сетевой адрес прибора, подставляется номер из флага number.
+func prepareData(deviceData *models.DataDevice, configService configPackage.Config, logger *logPackage.LoggerService) {
+	var err error
+
+	if deviceData.Address == "" {
+		deviceData.Address = strconv.Itoa(int(configService.GetCounterNumber()))
+	}
+
+	logger.Info("Приведение значения энергии к нужным единицам измерения")
+	switch deviceData.UnitQ {
+	case models.MWh:
+		logger.Info("Единицы измерения энергии по протоколу МВт")
+	case models.KWh:
+		logger.Info("Единицы измерения энергии по протоколу КВт")
+	case models.GJ:
+		logger.Info("Единицы измерения энергии по протоколу ГДж")
+	case models.Gcal:
+		logger.Info("Единицы измерения энергии по протоколу ГКал")
+	}
+	deviceData.StandardQ, err = configService.GetStandardQ()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.OverrideQ = configService.GetCoefficientsQ()
+	logger.Info("Стандарт пересчёта энергии - %s", deviceData.GetStandardQ().String())
+	unitQ, err := configService.GetUnitQ()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitQ(unitQ)
+
+	logger.Info("Приведение остальных значений к нужным единицам измерения")
+	changeUnits(deviceData, configService, logger)
+}
+
+// Приведение давления, объёма, массы, расхода и температуры к единицам, заданным флагами утилиты.
+// При неверно заданных единицах значения остаются в базовых единицах драйвера, ошибка фиксируется в логе.
+func changeUnits(deviceData *models.DataDevice, configService configPackage.Config, logger *logPackage.LoggerService) {
+	unitP, err := configService.GetUnitP()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitP(unitP)
+
+	unitV, err := configService.GetUnitV()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitV(unitV)
+
+	unitM, err := configService.GetUnitM()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitM(unitM)
+
+	unitG, err := configService.GetUnitG()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitG(unitG)
+
+	unitT, err := configService.GetUnitT()
+	if err != nil {
+		logger.Notice(err.Error())
+	}
+	deviceData.ChangeUnitT(unitT)
+}